@@ -0,0 +1,33 @@
+-- OAuth 2.0 / OpenID Connect provider
+
+CREATE TABLE oauthClients (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  secret VARCHAR(100) NOT NULL DEFAULT '',
+  name VARCHAR(100) NOT NULL,
+  redirectUris TEXT NOT NULL,
+  logoutUris TEXT NOT NULL,
+  created DATETIME NOT NULL
+);
+
+CREATE TABLE oauthCodes (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  clientId VARCHAR(80) NOT NULL,
+  accountId VARCHAR(80) NOT NULL,
+  redirectUri TEXT NOT NULL,
+  scope VARCHAR(255) NOT NULL DEFAULT '',
+  nonce VARCHAR(255) NOT NULL DEFAULT '',
+  codeChallenge VARCHAR(128) NOT NULL DEFAULT '',
+  created DATETIME NOT NULL
+);
+
+CREATE TABLE oauthTokens (
+  id CHAR(64) NOT NULL PRIMARY KEY,
+  type VARCHAR(20) NOT NULL,
+  clientId VARCHAR(80) NOT NULL,
+  accountId VARCHAR(80) NOT NULL DEFAULT '',
+  scope VARCHAR(255) NOT NULL DEFAULT '',
+  expires DATETIME NOT NULL,
+  created DATETIME NOT NULL,
+  INDEX (accountId),
+  INDEX (clientId)
+);
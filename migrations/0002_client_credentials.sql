@@ -0,0 +1,6 @@
+-- Machine clients using the client credentials grant
+
+ALTER TABLE oauthClients
+  ADD COLUMN grantTypes VARCHAR(255) NOT NULL DEFAULT 'authorization_code refresh_token',
+  ADD COLUMN scopes VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN revoked TINYINT(1) NOT NULL DEFAULT 0;
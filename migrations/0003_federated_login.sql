@@ -0,0 +1,21 @@
+-- Sign in with external identity providers
+
+CREATE TABLE identities (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  accountId VARCHAR(80) NOT NULL,
+  provider VARCHAR(100) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL DEFAULT '',
+  created DATETIME NOT NULL,
+  UNIQUE (provider, subject),
+  INDEX (accountId)
+);
+
+CREATE TABLE federatedStates (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  provider VARCHAR(100) NOT NULL,
+  nonce VARCHAR(80) NOT NULL,
+  verifier VARCHAR(80) NOT NULL,
+  continueUrl TEXT NOT NULL,
+  created DATETIME NOT NULL
+);
@@ -0,0 +1,11 @@
+-- Multiple login identities per account
+
+ALTER TABLE identities ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'federated' AFTER accountId;
+
+ALTER TABLE federatedStates ADD COLUMN accountId VARCHAR(80) NOT NULL DEFAULT '' AFTER continueUrl;
+
+ALTER TABLE users ADD COLUMN lastLogin DATETIME NULL;
+
+-- Existing accounts with a password get a password identity
+INSERT INTO identities (id, accountId, type, provider, subject, email, created)
+SELECT REPLACE(UUID(), '-', ''), id, 'password', 'local', id, email, NOW() FROM users WHERE password <> '';
@@ -0,0 +1,9 @@
+-- Passwordless login by email
+
+CREATE TABLE magicLinks (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  accountId VARCHAR(80) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  created DATETIME NOT NULL,
+  INDEX (accountId)
+);
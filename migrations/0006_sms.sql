@@ -0,0 +1,15 @@
+-- SMS delivery of one time codes
+
+ALTER TABLE users ADD COLUMN phoneVerified TINYINT(1) NOT NULL DEFAULT 0;
+
+ALTER TABLE users ADD COLUMN deliveryChannel VARCHAR(10) NOT NULL DEFAULT 'email';
+
+CREATE TABLE phoneVerifications (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  accountId VARCHAR(80) NOT NULL,
+  phone VARCHAR(50) NOT NULL,
+  code VARCHAR(10) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  created DATETIME NOT NULL,
+  INDEX (accountId)
+);
@@ -0,0 +1,14 @@
+-- Personal access tokens
+
+CREATE TABLE personalTokens (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  accountId VARCHAR(80) NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  tokenHash CHAR(64) NOT NULL,
+  scopes TEXT NOT NULL,
+  expires DATETIME NOT NULL,
+  lastUsed DATETIME NULL,
+  created DATETIME NOT NULL,
+  UNIQUE (tokenHash),
+  INDEX (accountId)
+);
@@ -0,0 +1,6 @@
+-- Service accounts. They have no password or email and only authenticate with tokens
+
+ALTER TABLE users
+  ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'user',
+  ADD COLUMN ownerId VARCHAR(80) NOT NULL DEFAULT '',
+  ADD INDEX (ownerId);
@@ -0,0 +1,50 @@
+-- Database driven roles and permissions. Accounts can have many roles.
+-- The role column is kept as the old level (999 ADMIN, 0 DEFAULT) and stays in sync with the built in roles
+
+CREATE TABLE roles (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  name VARCHAR(50) NOT NULL,
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  created DATETIME NOT NULL,
+  UNIQUE (name)
+);
+
+CREATE TABLE permissions (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE rolePermissions (
+  roleId VARCHAR(80) NOT NULL,
+  permissionId VARCHAR(50) NOT NULL,
+  PRIMARY KEY (roleId, permissionId)
+);
+
+CREATE TABLE accountRoles (
+  accountId VARCHAR(80) NOT NULL,
+  roleId VARCHAR(80) NOT NULL,
+  PRIMARY KEY (accountId, roleId),
+  INDEX (roleId)
+);
+
+INSERT INTO permissions (id, description) VALUES
+  ('accounts:read', 'List accounts'),
+  ('accounts:write', 'Create and update accounts'),
+  ('accounts:delete', 'Delete accounts'),
+  ('sessions:revoke', 'Revoke session tokens'),
+  ('roles:read', 'List roles and permissions'),
+  ('roles:write', 'Create, update, delete and assign roles'),
+  ('clients:write', 'Manage oauth clients');
+
+INSERT INTO roles (id, name, description, created) VALUES
+  ('admin', 'ADMIN', 'Full access', NOW()),
+  ('default', 'DEFAULT', 'Every user', NOW());
+
+INSERT INTO rolePermissions (roleId, permissionId)
+  SELECT 'admin', id FROM permissions;
+
+-- Move accounts onto the built in roles of their level
+INSERT INTO accountRoles (accountId, roleId)
+  SELECT id, 'admin' FROM users WHERE role = 999;
+INSERT INTO accountRoles (accountId, roleId)
+  SELECT id, 'default' FROM users WHERE role IN (0, 999);
@@ -0,0 +1,24 @@
+-- Organizations. Accounts can belong to several with a role in each (999 admin, 0 member)
+
+CREATE TABLE organizations (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  name VARCHAR(100) NOT NULL,
+  slug VARCHAR(50) NOT NULL,
+  created DATETIME NOT NULL,
+  UNIQUE (slug)
+);
+
+CREATE TABLE orgMembers (
+  orgId VARCHAR(80) NOT NULL,
+  accountId VARCHAR(80) NOT NULL,
+  role INT NOT NULL DEFAULT 0,
+  created DATETIME NOT NULL,
+  PRIMARY KEY (orgId, accountId),
+  INDEX (accountId)
+);
+
+ALTER TABLE users
+  ADD COLUMN activeOrg VARCHAR(80) NOT NULL DEFAULT '';
+
+INSERT INTO permissions (id, description) VALUES ('orgs:write', 'Create organizations');
+INSERT INTO rolePermissions (roleId, permissionId) VALUES ('admin', 'orgs:write');
@@ -0,0 +1,31 @@
+-- Groups of accounts. Roles given to a group are inherited by its members.
+-- Groups can be nested: members of a nested group also get the roles of every group containing it
+
+CREATE TABLE accountGroups (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  name VARCHAR(50) NOT NULL,
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  created DATETIME NOT NULL,
+  UNIQUE (name)
+);
+
+CREATE TABLE groupMembers (
+  groupId VARCHAR(80) NOT NULL,
+  accountId VARCHAR(80) NOT NULL,
+  PRIMARY KEY (groupId, accountId),
+  INDEX (accountId)
+);
+
+CREATE TABLE groupRoles (
+  groupId VARCHAR(80) NOT NULL,
+  roleId VARCHAR(80) NOT NULL,
+  PRIMARY KEY (groupId, roleId),
+  INDEX (roleId)
+);
+
+CREATE TABLE groupNesting (
+  groupId VARCHAR(80) NOT NULL,
+  memberGroupId VARCHAR(80) NOT NULL,
+  PRIMARY KEY (groupId, memberGroupId),
+  INDEX (memberGroupId)
+);
@@ -0,0 +1,15 @@
+-- Invitations to create an account. The link holds a signed token with the invite id and expiry.
+-- Accepting or revoking an invite removes it
+
+CREATE TABLE invites (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  email VARCHAR(255) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  role INT NOT NULL DEFAULT 0,
+  orgId VARCHAR(80) NOT NULL DEFAULT '',
+  invitedBy VARCHAR(80) NOT NULL,
+  created DATETIME NOT NULL,
+  expires DATETIME NOT NULL,
+  INDEX (email),
+  INDEX (orgId)
+);
@@ -0,0 +1,12 @@
+-- Public sign up. Accounts created by sign up cannot login until their email is verified.
+-- Never verified accounts are deleted after 7 days
+
+ALTER TABLE users ADD COLUMN pendingVerification TINYINT(1) NOT NULL DEFAULT 0;
+
+CREATE TABLE emailVerifications (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  accountId VARCHAR(80) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  created DATETIME NOT NULL,
+  INDEX (accountId)
+);
@@ -0,0 +1,6 @@
+-- Account status. Suspended, locked and pending accounts cannot login or use their sessions until reactivated or the end date passes
+
+ALTER TABLE users
+  ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active',
+  ADD COLUMN statusReason VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN statusUntil DATETIME NULL;
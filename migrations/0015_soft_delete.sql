@@ -0,0 +1,8 @@
+-- Deleted accounts are kept for a restore window before they are purged with all their rows.
+-- When names are not reserved the username and email are moved aside so they can be used again
+
+ALTER TABLE users
+  ADD COLUMN deletedAt DATETIME NULL,
+  ADD COLUMN deletedUserName VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN deletedEmail VARCHAR(255) NOT NULL DEFAULT '',
+  ADD INDEX (deletedAt);
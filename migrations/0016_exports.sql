@@ -0,0 +1,26 @@
+-- Audit trail of logins and admin actions, and data exports answering subject access requests
+
+CREATE TABLE auditEvents (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  accountId VARCHAR(80) NOT NULL,
+  actorId VARCHAR(80) NOT NULL DEFAULT '',
+  event VARCHAR(50) NOT NULL,
+  detail VARCHAR(255) NOT NULL DEFAULT '',
+  ip VARCHAR(50) NOT NULL DEFAULT '',
+  created DATETIME NOT NULL,
+  INDEX (accountId),
+  INDEX (actorId)
+);
+
+CREATE TABLE exports (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  accountId VARCHAR(80) NOT NULL,
+  requestedBy VARCHAR(80) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  format VARCHAR(10) NOT NULL DEFAULT 'json',
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  file VARCHAR(255) NOT NULL DEFAULT '',
+  created DATETIME NOT NULL,
+  completed DATETIME NULL,
+  INDEX (accountId)
+);
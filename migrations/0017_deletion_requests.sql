@@ -0,0 +1,11 @@
+-- Account deletions requested by the account itself. Carried out once the grace period passes unless cancelled
+
+CREATE TABLE deletionRequests (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  accountId VARCHAR(80) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  created DATETIME NOT NULL,
+  scheduledFor DATETIME NOT NULL,
+  UNIQUE (accountId),
+  INDEX (scheduledFor)
+);
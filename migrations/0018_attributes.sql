@@ -0,0 +1,23 @@
+-- Admin defined profile attributes and their values for each account
+
+CREATE TABLE attributeDefinitions (
+  id VARCHAR(80) NOT NULL PRIMARY KEY,
+  name VARCHAR(50) NOT NULL UNIQUE,
+  label VARCHAR(100) NOT NULL DEFAULT '',
+  type VARCHAR(20) NOT NULL DEFAULT 'text',
+  required TINYINT(1) NOT NULL DEFAULT 0,
+  pattern VARCHAR(255) NOT NULL DEFAULT '',
+  minValue DOUBLE NULL,
+  maxValue DOUBLE NULL,
+  options TEXT NOT NULL,
+  visibility VARCHAR(20) NOT NULL DEFAULT 'self',
+  created DATETIME NOT NULL
+);
+
+CREATE TABLE accountAttributes (
+  accountId VARCHAR(80) NOT NULL,
+  attributeId VARCHAR(80) NOT NULL,
+  value VARCHAR(255) NOT NULL,
+  PRIMARY KEY (accountId, attributeId),
+  INDEX (attributeId)
+);
@@ -0,0 +1,11 @@
+-- Users can change their username. Old usernames are held for their account for a while and reserved names are never given out
+
+ALTER TABLE users ADD COLUMN userNameChanged DATETIME NULL;
+
+CREATE TABLE userNameHolds (
+  userName VARCHAR(255) NOT NULL PRIMARY KEY,
+  accountId VARCHAR(80) NOT NULL DEFAULT '', -- Empty for reserved names
+  created DATETIME NOT NULL,
+  expires DATETIME NULL, -- Null for reserved names
+  INDEX (accountId)
+);
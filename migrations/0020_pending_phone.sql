@@ -0,0 +1,6 @@
+-- A new phone is kept pending until the code sent to it is confirmed. Phones are stored in E.164 format.
+-- Phones stored before this are converted when the server starts (Authenticate.NormalizePhones) and must be verified again
+
+ALTER TABLE users ADD COLUMN pendingPhone VARCHAR(50) NOT NULL DEFAULT '';
+
+CREATE INDEX users_phone ON users (phone);
@@ -0,0 +1,6 @@
+-- Roles are ordered by rank. Access policies compare the highest rank of the actor's roles with the target's,
+-- so custom roles can manage accounts ranked below them. Built in roles keep their old levels
+
+ALTER TABLE roles ADD COLUMN `rank` INT NOT NULL DEFAULT 0;
+
+UPDATE roles SET `rank` = 999 WHERE id = 'admin';
@@ -0,0 +1,4 @@
+-- Passkey identities keep the public key and signature counter of their credential.
+-- The subject of a passkey identity is its base64url credential id
+
+ALTER TABLE identities ADD COLUMN publicKey BLOB NULL, ADD COLUMN signCount INT UNSIGNED NOT NULL DEFAULT 0;
@@ -29,6 +29,28 @@ func getConfig(mode string) *types.Config {
 				SMTPAddress: "smtp.office365.com",
 				SMTPPort:    587,
 			},
+			SMS: types.SMSConfig{
+				Provider: "file",
+				File:     "", //Empty writes messages to the console
+			},
+			Phones: types.PhoneConfig{CountryCode: "1", Unique: false, ResendWait: 1}, //Numbers entered without a country code get CountryCode. Unique stops accounts sharing a phone. ResendWait is in minutes
+			OAuth: types.OAuthConfig{
+				Issuer:          "http://localhost:4000",
+				KeyFile:         "./keys/oauth.pem",
+				AccessTokenTTL:  3600,    //How long access tokens last (Seconds)
+				RefreshTokenTTL: 2592000, //How long refresh tokens last (Seconds)
+			},
+			Providers:   []types.IdentityProviderConfig{},                                                                                                //External identity providers users can sign in with
+			SAML:        []types.SAMLProviderConfig{},                                                                                                    //External saml identity providers users can sign in with
+			LDAP:        types.LDAPConfig{},                                                                                                              //Set URL to let users login with their directory account
+			Passkeys:    types.PasskeyConfig{RPID: "", RPName: "Auth", Origin: ""},                                                                       //Set RPID to the site domain to let users login with passkeys
+			LoginOrder:  []string{"local", "ldap"},                                                                                                       //Order login credentials are checked in
+			PolicyFile:  "",                                                                                                                              //Access policy rules for admin operations. Empty uses the default rules
+			Orgs:        types.OrgConfig{UniquePerOrg: false},                                                                                            //True lets each organization reuse usernames and emails
+			InviteTTL:   72,                                                                                                                              //Hours an invite link can be used for
+			Signup:      types.SignupConfig{Enabled: false, ResendWait: 5},                                                                               //Enabled lets anyone create an account and verify it by email
+			Deletion:    types.DeletionConfig{RestoreDays: 30, ReserveNames: false, GraceDays: 14},                                                       //Deleted accounts can be restored for RestoreDays then are purged
+			UserNames:   types.UserNameConfig{CooldownDays: 30, HoldDays: 30, Reserved: []string{"admin", "administrator", "root", "support", "system"}}, //Old usernames are held for HoldDays after a change
 			ServerPort:  ":4000",
 			Host:        "http://localhost:3000",
 			LogDuration: 30, //Days
@@ -52,6 +74,28 @@ func getConfig(mode string) *types.Config {
 			SMTPAddress: "smtp.office365.com",
 			SMTPPort:    587,
 		},
+		SMS: types.SMSConfig{
+			Provider: "file",
+			File:     "", //Empty writes messages to the console
+		},
+		Phones: types.PhoneConfig{CountryCode: "1", Unique: false, ResendWait: 1}, //Numbers entered without a country code get CountryCode. Unique stops accounts sharing a phone. ResendWait is in minutes
+		OAuth: types.OAuthConfig{
+			Issuer:          "http://localhost:4000",
+			KeyFile:         "./keys/oauth.pem",
+			AccessTokenTTL:  3600,    //How long access tokens last (Seconds)
+			RefreshTokenTTL: 2592000, //How long refresh tokens last (Seconds)
+		},
+		Providers:   []types.IdentityProviderConfig{},                                                                                                //External identity providers users can sign in with
+		SAML:        []types.SAMLProviderConfig{},                                                                                                    //External saml identity providers users can sign in with
+		LDAP:        types.LDAPConfig{},                                                                                                              //Set URL to let users login with their directory account
+		Passkeys:    types.PasskeyConfig{RPID: "", RPName: "Auth", Origin: ""},                                                                       //Set RPID to the site domain to let users login with passkeys
+		LoginOrder:  []string{"local", "ldap"},                                                                                                       //Order login credentials are checked in
+		PolicyFile:  "",                                                                                                                              //Access policy rules for admin operations. Empty uses the default rules
+		Orgs:        types.OrgConfig{UniquePerOrg: false},                                                                                            //True lets each organization reuse usernames and emails
+		InviteTTL:   72,                                                                                                                              //Hours an invite link can be used for
+		Signup:      types.SignupConfig{Enabled: false, ResendWait: 5},                                                                               //Enabled lets anyone create an account and verify it by email
+		Deletion:    types.DeletionConfig{RestoreDays: 30, ReserveNames: false, GraceDays: 14},                                                       //Deleted accounts can be restored for RestoreDays then are purged
+		UserNames:   types.UserNameConfig{CooldownDays: 30, HoldDays: 30, Reserved: []string{"admin", "administrator", "root", "support", "system"}}, //Old usernames are held for HoldDays after a change
 		ServerPort:  ":4000",
 		Host:        "http://localhost:3000",
 		LogDuration: 30, //Days
@@ -79,5 +123,9 @@ func main() {
 		return
 	}
 	auth := auth.Authenticate{}.Init(db, config)
+	if auth == nil {
+		fmt.Println("Cannot start authentication")
+		return
+	}
 	router.Router{}.Init(auth, config)
 }
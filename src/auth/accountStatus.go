@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"time"
+	"types"
+	"utils"
+)
+
+//checkStatusTarget - returns the admin and the account whose status it is changing.
+//Returns a reason if it is their own account or the policies deny it
+func (auth Authenticate) checkStatusTarget(session *types.Session, request *types.AccountStatusRequest) (string, *types.Account, *types.Account, error) {
+	actor, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if actor.ID == request.ID {
+		return "Cannot change the status of your own account", nil, nil, nil
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByID(request.ID, auth.DB)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if account == nil {
+		return "", nil, nil, errors.New("No account found: " + request.ID)
+	}
+
+	res, err := auth.checkPolicy(types.ScopeAccountsWrite, actor, account, nil, orgID)
+	if err != nil || res != "" {
+		return res, nil, nil, err
+	}
+
+	return "", actor, account, nil
+}
+
+//SuspendAccount - blocks an account until it is reactivated or the end date passes. Its sessions stop working right away.
+//Returns the account to notify, or a reason if the status is invalid
+func (auth Authenticate) SuspendAccount(session *types.Session, request *types.AccountStatusRequest) (string, *types.Account, error) {
+	if !utils.Contains(request.Status, []string{types.StatusSuspended, types.StatusLocked, types.StatusPending}) {
+		return "Invalid status: " + request.Status, nil, nil
+	}
+	if request.Until != nil && request.Until.Before(time.Now()) {
+		return "End date must be in the future", nil, nil
+	}
+
+	res, actor, account, err := auth.checkStatusTarget(session, request)
+	if err != nil || res != "" {
+		return res, nil, err
+	}
+
+	account.Status = request.Status
+	account.StatusReason = request.Reason
+	account.StatusUntil = request.Until
+	err = manager.AccountManager{}.SetStatus(account, auth.DB, auth.Cache)
+	if err != nil {
+		return "", nil, err
+	}
+	auth.audit(account.ID, actor.ID, types.AuditStatusChanged, account.Status+" "+account.StatusReason, session)
+
+	return "", account, nil
+}
+
+//ReactivateAccount - makes an account active again. Returns the account to notify
+func (auth Authenticate) ReactivateAccount(session *types.Session, request *types.AccountStatusRequest) (string, *types.Account, error) {
+	res, actor, account, err := auth.checkStatusTarget(session, request)
+	if err != nil || res != "" {
+		return res, nil, err
+	}
+	//Statuses whose end date passed are still cleared
+	if account.Status == types.StatusActive {
+		return "Account is already active", nil, nil
+	}
+
+	account.Status = types.StatusActive
+	account.StatusReason = ""
+	account.StatusUntil = nil
+	err = manager.AccountManager{}.SetStatus(account, auth.DB, auth.Cache)
+	if err != nil {
+		return "", nil, err
+	}
+	auth.audit(account.ID, actor.ID, types.AuditStatusChanged, account.Status+" "+account.StatusReason, session)
+
+	return "", account, nil
+}
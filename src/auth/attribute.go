@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"types"
+)
+
+//checkAttribute - returns a reason if an attribute definition is invalid
+func (auth Authenticate) checkAttribute(def *types.AttributeDefinition) (string, error) {
+	if def.Label == "" {
+		def.Label = def.Name
+	}
+	if def.Visibility == "" {
+		def.Visibility = types.VisibilitySelf
+	}
+	if err := def.Check(); err != nil {
+		return err.Error(), nil
+	}
+
+	return manager.AttributeManager{}.CheckDuplicateDefinition(def, auth.DB)
+}
+
+//GetAttributes - returns the attribute definitions. Accounts that cannot manage accounts do not see hidden ones
+func (auth Authenticate) GetAttributes(session *types.Session) (*[]types.AttributeDefinition, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	defs, err := manager.AttributeManager{}.GetDefinitions(auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	admin := account.Can(types.ScopeAccountsWrite)
+	visible := []types.AttributeDefinition{}
+	for _, def := range *defs {
+		if def.VisibleTo(admin) {
+			visible = append(visible, def)
+		}
+	}
+	return &visible, nil
+}
+
+//CreateAttribute - creates an attribute definition. Returns a reason if it is invalid
+func (auth Authenticate) CreateAttribute(session *types.Session, def *types.AttributeDefinition) (string, error) {
+	_, err := auth.checkPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return "", err
+	}
+
+	def.ID = ""
+	res, err := auth.checkAttribute(def)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	return "", manager.AttributeManager{}.CreateDefinition(def, auth.DB)
+}
+
+//UpdateAttribute - updates an attribute definition. Values already stored are not checked again
+func (auth Authenticate) UpdateAttribute(session *types.Session, def *types.AttributeDefinition) (string, error) {
+	_, err := auth.checkPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := manager.AttributeManager{}.GetDefinition(def.ID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if existing == nil {
+		return "", errors.New("No attribute found: " + def.ID)
+	}
+
+	res, err := auth.checkAttribute(def)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	return "", manager.AttributeManager{}.UpdateDefinition(def, auth.DB)
+}
+
+//DeleteAttribute - deletes an attribute definition and the values every account has for it
+func (auth Authenticate) DeleteAttribute(session *types.Session, request *types.AttributeRequest) error {
+	_, err := auth.checkPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return err
+	}
+
+	return manager.AttributeManager{}.DeleteDefinition(request.ID, auth.DB)
+}
+
+//LoadAttributes - sets the attribute values an account can see of itself
+func (auth Authenticate) LoadAttributes(account *types.Account) error {
+	return manager.AttributeManager{}.LoadAccountAttributes(account, account.Can(types.ScopeAccountsWrite), auth.DB)
+}
+
+//checkAttributeValues - validates the attribute values of an account given by name. Returns them by attribute id, or a reason if one is invalid.
+//Admins can change every attribute, accounts only the ones they can edit. Nil values leave every attribute unchanged
+func (auth Authenticate) checkAttributeValues(accountID string, values map[string]string, admin bool) (map[string]string, string, error) {
+	atm := manager.AttributeManager{}
+
+	checked := map[string]string{}
+	if values == nil {
+		return checked, "", nil
+	}
+
+	defs, err := atm.GetDefinitions(auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+	current, err := atm.GetValues(accountID, auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+	byName := map[string]types.AttributeDefinition{}
+	for _, def := range *defs {
+		byName[def.Name] = def
+	}
+
+	for name, value := range values {
+		def, ok := byName[name]
+		if !ok || !def.VisibleTo(admin) {
+			return nil, "Unknown attribute: " + name, nil
+		}
+		//Values the account can see but not edit are sent back unchanged
+		if !def.EditableBy(admin) {
+			if value != current[accountID][def.ID] {
+				return nil, "Cannot change attribute: " + def.Label, nil
+			}
+			continue
+		}
+		if err := def.CheckValue(value); err != nil {
+			return nil, err.Error(), nil
+		}
+		checked[def.ID] = value
+	}
+	return checked, "", nil
+}
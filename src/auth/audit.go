@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"fmt"
+	"manager"
+	"types"
+)
+
+//audit - records an event on an account. Failures are printed and never stop the action
+func (auth Authenticate) audit(accountID string, actorID string, event string, detail string, session *types.Session) {
+	ip := ""
+	if session != nil {
+		ip = session.IP
+	}
+	err := manager.AuditManager{}.Record(&types.AuditEvent{AccountID: accountID, ActorID: actorID, Event: event, Detail: detail, IP: ip}, auth.DB)
+	if err != nil {
+		fmt.Println("Failed recording audit event: " + err.Error())
+	}
+}
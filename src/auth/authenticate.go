@@ -3,22 +3,83 @@ package auth
 import (
 	"cache"
 	"db"
+	"directory"
 	"errors"
+	"federation"
+	"fmt"
+	"jwt"
 	"manager"
+	"policy"
+	"saml"
+	"time"
 	"types"
 	"utils"
+	"webauthn"
 )
 
 //Authenticate - Authenticate class
 type Authenticate struct {
-	DB    *db.MySQL
-	Cache *cache.Cache
+	DB        *db.MySQL
+	Cache     *cache.Cache
+	Signer    *jwt.Signer
+	Config    *types.Config
+	Providers map[string]*federation.Provider
+	SAML      map[string]*saml.Provider
+	Directory *directory.Directory
+	Passkeys  *webauthn.RelyingParty
+	Policy    *policy.Engine
 }
 
-//Init - Start authentication service
+//Init - Start authentication service. Nil if the service cannot be setup
 func (auth Authenticate) Init(db *db.MySQL, config *types.Config) *Authenticate {
 	auth.DB = db
 	auth.Cache = cache.Cache{}.Init(config)
+	signer, err := jwt.Signer{}.Init(config)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	auth.Signer = signer
+	auth.Config = config
+
+	//Setup external identity providers
+	auth.Providers = map[string]*federation.Provider{}
+	for _, provider := range config.Providers {
+		auth.Providers[provider.Name] = federation.Provider{}.Init(provider, config.OAuth.Issuer+"/api/auth/federated/"+provider.Name+"/callback")
+	}
+
+	auth.SAML = map[string]*saml.Provider{}
+	for _, provider := range config.SAML {
+		auth.SAML[provider.Name] = saml.Provider{}.Init(provider, auth.SAMLEntityID(), config.OAuth.Issuer+"/api/auth/saml/acs")
+	}
+
+	//Setup ldap login. Nil if not configured
+	auth.Directory = directory.Directory{}.Init(config.LDAP)
+
+	//Setup passkey login. Nil if not configured
+	auth.Passkeys = webauthn.RelyingParty{}.Init(config.Passkeys, config.Host)
+
+	//Never run with rules other than the ones configured
+	engine, err := policy.Engine{}.Init(config.PolicyFile)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	auth.Policy = engine
+
+	if err := auth.ReserveUserNames(); err != nil {
+		fmt.Println("Failed reserving usernames: " + err.Error())
+	}
+	if err := auth.NormalizePhones(); err != nil {
+		fmt.Println("Failed normalizing phones: " + err.Error())
+	}
+
+	//Setup interval to purge deleted accounts after their restore window
+	utils.Schedule(auth.PurgeDeleted, 1*time.Hour)
+	utils.Schedule(auth.CleanExports, 1*time.Hour)
+	utils.Schedule(auth.ProcessDeletions, 1*time.Hour)
+	utils.Schedule(auth.PurgeUnverified, 1*time.Hour)
+
 	return &auth
 }
 
@@ -41,20 +102,105 @@ func (auth Authenticate) getAccountSession(session *types.Session) (*types.Accou
 
 //Login - Checks if login is valid
 func (auth Authenticate) Login(login *types.Login, session *types.Session) (*types.Account, *types.Device, error) {
-	am := manager.AccountManager{}
-	dm := manager.DeviceManager{}
+	account, err := auth.checkCredentials(login, session)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	//Get account by username or email provided
-	account, err := am.GetAccountLoginDetails(login.UserName, auth.DB)
+	//Start the session in the organization logged in to
+	if login.Org != "" {
+		org, err := auth.orgBySlug(login.Org)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = auth.enterOrg(account, org.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return auth.startSession(account, session)
+}
 
+//checkCredentials - tries each login backend in the configured order.
+//Returns the account of the first backend that accepts the login
+func (auth Authenticate) checkCredentials(login *types.Login, session *types.Session) (*types.Account, error) {
+	order := auth.Config.LoginOrder
+	if len(order) == 0 {
+		order = []string{types.LocalProvider}
+	}
+
+	var lastErr error
+	for _, backend := range order {
+		var account *types.Account
+		var err error
+		switch backend {
+		case types.LocalProvider:
+			account, err = auth.localLogin(login, session)
+		case types.LDAPProvider:
+			//Skip ldap if it is not configured
+			if auth.Directory == nil {
+				continue
+			}
+			account, err = auth.ldapLogin(login)
+		default:
+			err = errors.New("Unknown login backend: " + backend)
+		}
+		if err == nil {
+			return account, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("No login backend available")
+	}
+	return nil, lastErr
+}
+
+//localLogin - checks the login against the password stored on the account
+func (auth Authenticate) localLogin(login *types.Login, session *types.Session) (*types.Account, error) {
+	orgID := ""
+	if login.Org != "" {
+		org, err := auth.orgBySlug(login.Org)
+		if err != nil {
+			return nil, err
+		}
+		orgID = org.ID
+	}
+
+	//Get account by username or email provided
+	account, err := manager.AccountManager{}.GetAccountLoginDetails(login.UserName, orgID, auth.DB)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	//Check if password matches hash
 	valid := utils.CheckPasswordHash(login.Password, account.Password)
 	if !valid {
-		return nil, nil, errors.New("Invalid Password Attempt: " + account.Name)
+		auth.audit(account.ID, account.ID, types.AuditLoginFailed, "", session)
+		return nil, errors.New("Invalid Password Attempt: " + account.Name)
+	}
+
+	return account, nil
+}
+
+//startSession - gives the account a new session. Accounts with permissions or 2FA also get a device to verify
+func (auth Authenticate) startSession(account *types.Account, session *types.Session) (*types.Account, *types.Device, error) {
+	am := manager.AccountManager{}
+	dm := manager.DeviceManager{}
+
+	//Service accounts only authenticate with tokens
+	if account.IsService() {
+		return nil, nil, errors.New("Service accounts cannot login: " + account.Name)
+	}
+
+	if account.IsBlocked() {
+		return nil, nil, errors.New("Account is " + account.Status + ": " + account.Name)
+	}
+
+	//Signed up accounts need to verify their email first
+	if account.PendingVerification {
+		return nil, nil, errors.New("Email is not verified: " + account.Name)
 	}
 
 	oldToken := account.Token
@@ -63,10 +209,13 @@ func (auth Authenticate) Login(login *types.Login, session *types.Session) (*typ
 	account.Token = utils.RandomString()
 
 	//Get Account Roles
-	account = account.GetAccountPermissions()
+	err := manager.RoleManager{}.LoadAccountRoles(account, auth.DB)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	//If account is ADMIN or above or 2FA is enabled then make sure device is verified.
-	if utils.Contains("ADMIN", account.Roles) || account.TwoFA {
+	//If account has any permission or 2FA is enabled then make sure device is verified.
+	if account.IsPrivileged() || account.TwoFA {
 		device, err := dm.GetDevice(session, auth.DB, auth.Cache)
 		if err != nil {
 			return nil, nil, err
@@ -94,6 +243,11 @@ func (auth Authenticate) Login(login *types.Login, session *types.Session) (*typ
 		if err != nil {
 			return nil, nil, err
 		}
+		err = am.UpdateLastLogin(account, auth.DB)
+		if err != nil {
+			return nil, nil, err
+		}
+		auth.audit(account.ID, account.ID, types.AuditLogin, "", session)
 		auth.Cache.Del(oldToken)
 		am.SaveToCache(account, auth.Cache)
 		dm.SaveToCache(device, auth.Cache)
@@ -105,6 +259,11 @@ func (auth Authenticate) Login(login *types.Login, session *types.Session) (*typ
 	if err != nil {
 		return nil, nil, err
 	}
+	err = am.UpdateLastLogin(account, auth.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+	auth.audit(account.ID, account.ID, types.AuditLogin, "", session)
 	auth.Cache.Del(oldToken)
 	am.SaveToCache(account, auth.Cache)
 	return account, nil, nil
@@ -120,9 +279,14 @@ func (auth Authenticate) Logout(session *types.Session) error {
 	return nil
 }
 
-//CheckAccountSession - Checks if the session provided is valid
+//CheckAccountSession - Checks if the session provided is valid.
+//A personal access token with the account scope can be used instead of a session
 func (auth Authenticate) CheckAccountSession(session *types.Session) (*types.Account, error) {
 
+	if session.Token == "" && types.IsPersonalToken(session.Bearer) {
+		return auth.checkPersonalToken(session.Bearer, types.ScopeAccount)
+	}
+
 	//Invalid sessionId
 	if session.Token == "" {
 		return nil, errors.New("Invalid Session Id")
@@ -132,12 +296,18 @@ func (auth Authenticate) CheckAccountSession(session *types.Session) (*types.Acc
 	if err != nil {
 		return nil, err
 	}
+	if account.IsBlocked() {
+		return nil, errors.New("Account is " + account.Status + ": " + account.Name)
+	}
 
 	//Get Account Roles
-	account = account.GetAccountPermissions()
+	err = manager.RoleManager{}.LoadAccountRoles(account, auth.DB)
+	if err != nil {
+		return nil, err
+	}
 
-	//If account is ADMIN or above or 2FA is enabled then make sure device is verified.
-	if utils.Contains("ADMIN", account.Roles) || account.TwoFA {
+	//If account has any permission or 2FA is enabled then make sure device is verified.
+	if account.IsPrivileged() || account.TwoFA {
 		device, err := manager.DeviceManager{}.GetDevice(session, auth.DB, auth.Cache)
 		if err != nil {
 			return nil, err
@@ -152,45 +322,116 @@ func (auth Authenticate) CheckAccountSession(session *types.Session) (*types.Acc
 	return account, nil
 }
 
-//GetAllAccounts - Checks if the session provided is valid
-func (auth Authenticate) GetAllAccounts(session *types.Session) (*[]types.Account, error) {
-	account, err := auth.CheckAccountSession(session)
+//checkPolicy - checks the access policies for an admin action on an account.
+//Returns a reason if a rule denies it. Updated is the new values of the account if it is being changed.
+//In an organization the target must be a member and role levels are the roles there.
+//Accounts ranked higher than the actor outside the organization cannot be managed from it
+func (auth Authenticate) checkPolicy(action string, actor *types.Account, target *types.Account, updated *types.Account, orgID string) (string, error) {
+	err := manager.RoleManager{}.LoadAccountRoles(target, auth.DB)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	//Get Account Roles
-	account = account.GetAccountPermissions()
+	if orgID != "" {
+		member, err := manager.OrgManager{}.GetMember(orgID, target.ID, auth.DB)
+		if err != nil {
+			return "", err
+		}
+		if member == nil {
+			return "Account is not in your organization", nil
+		}
+		if target.Rank > actor.Rank {
+			return "Account has a higher role than yours outside your organization", nil
+		}
+		target.OrgRole = member.Role
+	}
 
-	//Only Accounts with ADMIN privliges can make this request
-	if !utils.Contains("ADMIN", account.Roles) {
-		return nil, errors.New("Invalid Privilges: " + account.Name)
+	request := types.PolicyRequest{
+		Action: action,
+		Actor:  policy.AccountAttributes(actor),
+		Target: policy.AccountAttributes(target),
+	}
+	if updated != nil {
+		//The roles given are the ones listed or the built in roles of the level. Organizations rank members by their role there
+		updated.Rank = updated.Role
+		if orgID == "" {
+			roles := updated.Roles
+			if roles == nil {
+				roles = types.LegacyRoles(updated.Role)
+			}
+			updated.Rank, err = manager.RoleManager{}.RolesRank(roles, auth.DB)
+			if err != nil {
+				return "", err
+			}
+		}
+		request.New = policy.AccountAttributes(updated)
 	}
+	if orgID != "" {
+		request.Actor["level"], request.Actor["rank"] = actor.OrgRole, actor.OrgRole
+		request.Target["level"], request.Target["rank"] = target.OrgRole, target.OrgRole
+	}
+
+	decision := auth.Policy.Evaluate(&request)
+	if !decision.Allowed {
+		return "Not allowed by policy: " + decision.Rule, nil
+	}
+	return "", nil
+}
 
-	accounts, err := manager.AccountManager{}.GetAllAccounts(auth.DB)
+//checkPermission - returns the account making an admin request. Accounts need a role with the permission.
+//Machine clients and personal access tokens are allowed if they were granted it as a scope
+func (auth Authenticate) checkPermission(session *types.Session, scope string) (*types.Account, error) {
+	var account *types.Account
+	var err error
+
+	switch {
+	//Personal access tokens need the scope as well as the permission
+	case session.Token == "" && types.IsPersonalToken(session.Bearer):
+		account, err = auth.checkPersonalToken(session.Bearer, scope)
+	case session.Bearer != "" && !types.IsPersonalToken(session.Bearer):
+		client, err := auth.checkClientScope(session.Bearer, scope)
+		if err != nil {
+			return nil, err
+		}
+		return &types.Account{ID: client.ID, Name: client.Name, Type: types.AccountClient}, nil
+	default:
+		account, err = auth.CheckAccountSession(session)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return accounts, nil
+	//Only Accounts with the permission can make this request
+	if !account.Can(scope) {
+		return nil, errors.New("Invalid Privilges: " + account.Name + " missing " + scope)
+	}
+
+	return account, nil
 }
 
-//GetAccounts - returns accounts from the role given
-func (auth Authenticate) GetAccounts(session *types.Session, roles []int) (*[]types.Account, error) {
-	account, err := auth.CheckAccountSession(session)
+//GetAllAccounts - Checks if the session provided is valid
+func (auth Authenticate) GetAllAccounts(session *types.Session) (*[]types.Account, error) {
+	_, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsRead)
 	if err != nil {
 		return nil, err
 	}
 
-	//Get Account Roles
-	account = account.GetAccountPermissions()
+	accounts, err := manager.AccountManager{}.GetAllAccounts(orgID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
 
-	//Only Accounts with REGIONAL_SUPERVISOR privliges can make this request
-	if !utils.Contains("ADMIN", account.Roles) {
-		return nil, errors.New("Invalid Privilges: " + account.Name)
+//GetAccounts - returns accounts with one of the role names given
+func (auth Authenticate) GetAccounts(session *types.Session, roles []string) (*[]types.Account, error) {
+	_, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsRead)
+	if err != nil {
+		return nil, err
 	}
 
-	accounts, err := manager.AccountManager{}.GetAccounts(roles, auth.DB)
+	accounts, err := manager.AccountManager{}.GetAccounts(roles, orgID, auth.DB)
 	if err != nil {
 		return nil, err
 	}
@@ -198,111 +439,210 @@ func (auth Authenticate) GetAccounts(session *types.Session, roles []int) (*[]ty
 	return accounts, nil
 }
 
-//RegisterAccount - register a new account
+//RegisterAccount - register a new account. Sessions in an organization add the account to it with the role given.
+//The role cannot rank above the one of the account registering it
 func (auth Authenticate) RegisterAccount(session *types.Session, newAccount *types.Account) (string, error) {
-	account, err := auth.CheckAccountSession(session)
+	account, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsWrite)
 	if err != nil {
 		return "", err
 	}
 
-	//Get Account Roles
-	account = account.GetAccountPermissions()
+	newAccount.OrgUnique = auth.Config.Orgs.UniquePerOrg
+	newAccount.ActiveOrg = orgID
+	orgRole := newAccount.Role
+	if orgID != "" {
+		//The role is only given in the organization
+		if orgRole > account.OrgRole {
+			return "Cannot give a higher role than your own", nil
+		}
+		newAccount.Role = types.LevelDefault
+	} else if account.Type != types.AccountClient {
+		rank, err := manager.RoleManager{}.RolesRank(types.LegacyRoles(newAccount.Role), auth.DB)
+		if err != nil {
+			return "", err
+		}
+		if rank > account.Rank {
+			return "Cannot give a higher role than your own", nil
+		}
+	}
 
-	//Only Accounts with ADMIN privliges can make this request
-	if !utils.Contains("ADMIN", account.Roles) {
-		return "", errors.New("Invalid Privilges: " + account.Name)
+	res, err := auth.checkPhone(newAccount)
+	if err != nil || res != "" {
+		return res, err
 	}
 
-	//Get newAccount Roles
-	newAccount = newAccount.GetAccountPermissions()
+	res, err = manager.AccountManager{}.CreateAccount(newAccount, account, auth.DB)
+	if err != nil || res != "" {
+		return res, err
+	}
 
-	res, err := manager.AccountManager{}.CreateAccount(newAccount, account, auth.DB)
-	if err != nil {
-		return "", err
+	if orgID != "" {
+		err = manager.OrgManager{}.AddMember(&types.OrgMember{OrgID: orgID, AccountID: newAccount.ID, Role: orgRole}, auth.DB)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	return res, nil
+	return "", nil
 }
 
-//UpdateAccountSettings - update account settings
-func (auth Authenticate) UpdateAccountSettings(updatedAccount *types.Account, session *types.Session) (string, error) {
+//UpdateAccountSettings - update account settings.
+//A new phone is kept pending and the verification to send to it is returned
+func (auth Authenticate) UpdateAccountSettings(updatedAccount *types.Account, session *types.Session) (string, *types.PhoneVerification, error) {
 	account, err := auth.CheckAccountSession(session)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	res, err := manager.AccountManager{}.UpdateAccountSettings(updatedAccount, account, auth.DB, auth.Cache)
+	attributes, res, err := auth.checkAttributeValues(account.ID, updatedAccount.Attributes, false)
+	if err != nil || res != "" {
+		return res, nil, err
+	}
+
+	phone, err := types.NormalizePhone(updatedAccount.Phone, auth.Config.Phones.CountryCode)
+	if err == nil {
+		err = (types.Account{Phone: phone}).CheckPhone()
+	}
 	if err != nil {
-		return "", err
+		return err.Error(), nil, nil
+	}
+	if phone != account.Phone {
+		res, err = auth.checkPhoneTaken(phone, account.ID)
+		if err != nil || res != "" {
+			return res, nil, err
+		}
 	}
 
-	return res, nil
-}
+	res, err = manager.AccountManager{}.UpdateAccountSettings(updatedAccount, account, auth.DB, auth.Cache)
+	if err != nil || res != "" {
+		return res, nil, err
+	}
 
-//UpdateOtherAccountSettings - update account settings for another user
-func (auth Authenticate) UpdateOtherAccountSettings(updatedAccount *types.Account, session *types.Session) (string, error) {
-	account, err := auth.CheckAccountSession(session)
+	verification, err := auth.changePhone(account, phone)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	//Get Account Roles
-	account = account.GetAccountPermissions()
+	return "", verification, manager.AttributeManager{}.SetValues(account.ID, attributes, auth.DB)
+}
 
-	//Only Accounts with ADMIN privliges can make this request
-	if !utils.Contains("ADMIN", account.Roles) {
-		return "", errors.New("Invalid Privilges: " + account.Name)
+//UpdateOtherAccountSettings - update account settings for another user.
+//Sessions in an organization can only update its members and the role given is their role there.
+//A new phone is kept pending until the account confirms it and the verification to send to it is returned
+func (auth Authenticate) UpdateOtherAccountSettings(updatedAccount *types.Account, session *types.Session) (string, *types.PhoneVerification, error) {
+	actor, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return "", nil, err
 	}
 
 	accountData, err := manager.AccountManager{}.GetAccountByID(updatedAccount.ID, auth.DB)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	if accountData == nil {
+		return "", nil, errors.New("No account found: " + updatedAccount.ID)
+	}
+
+	res, err := auth.checkPolicy(types.ScopeAccountsWrite, actor, accountData, updatedAccount, orgID)
+	if err != nil || res != "" {
+		return res, nil, err
+	}
+
+	attributes, res, err := auth.checkAttributeValues(accountData.ID, updatedAccount.Attributes, true)
+	if err != nil || res != "" {
+		return res, nil, err
 	}
 
-	//Get roles of the account we are trying to update
-	accountData = accountData.GetAccountPermissions()
+	phone, err := types.NormalizePhone(updatedAccount.Phone, auth.Config.Phones.CountryCode)
+	//Service accounts do not need a phone
+	if err == nil && (phone != "" || !accountData.IsService()) {
+		err = (types.Account{Phone: phone}).CheckPhone()
+	}
+	if err != nil {
+		return err.Error(), nil, nil
+	}
+	if phone != accountData.Phone {
+		res, err = auth.checkPhoneTaken(phone, accountData.ID)
+		if err != nil || res != "" {
+			return res, nil, err
+		}
+	}
 
+	oldUserName := accountData.UserName
 	accountData.Name = updatedAccount.Name
 	accountData.UserName = updatedAccount.UserName
-	accountData.Phone = updatedAccount.Phone
+	//Service accounts cannot confirm a code so their phone is set directly
+	if accountData.IsService() {
+		accountData.ChangePhone(phone)
+	}
 	accountData.Email = updatedAccount.Email
-	accountData.Role = updatedAccount.Role
+	accountData.OrgUnique = auth.Config.Orgs.UniquePerOrg
+	if orgID == "" {
+		accountData.Role = updatedAccount.Role
+	}
 
-	res, err := manager.AccountManager{}.UpdateOtherAccountSettings(accountData, auth.DB, auth.Cache)
-	if err != nil {
-		return "", err
+	res, err = manager.AccountManager{}.UpdateOtherAccountSettings(accountData, auth.DB, auth.Cache)
+	if err != nil || res != "" {
+		return res, nil, err
 	}
 
-	return res, nil
+	if orgID != "" {
+		err = manager.OrgManager{}.AddMember(&types.OrgMember{OrgID: orgID, AccountID: accountData.ID, Role: updatedAccount.Role}, auth.DB)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	if accountData.UserName != oldUserName {
+		err = manager.UserNameManager{}.HoldUserName(oldUserName, accountData.ID, auth.userNameHold(), auth.DB)
+		if err != nil {
+			return "", nil, err
+		}
+		auth.audit(accountData.ID, actor.ID, types.AuditUserNameChanged, oldUserName+" -> "+accountData.UserName, session)
+	}
+
+	var verification *types.PhoneVerification
+	if !accountData.IsService() {
+		verification, err = auth.changePhone(accountData, phone)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	return "", verification, manager.AttributeManager{}.SetValues(accountData.ID, attributes, auth.DB)
 }
 
-//DeleteAccount - deletes an account
+//DeleteAccount - deletes an account. It can be restored until the restore window passes.
+//Sessions in an organization only remove the account from it
 func (auth Authenticate) DeleteAccount(del *types.DeleteAccountRequest, session *types.Session) (string, error) {
-	account, err := auth.CheckAccountSession(session)
+	actor, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsDelete)
 	if err != nil {
 		return "", err
 	}
 
-	//Get Account Roles
-	account = account.GetAccountPermissions()
-
-	//Only Accounts with ADMIN privliges can make this request
-	if !utils.Contains("ADMIN", account.Roles) {
-		return "", errors.New("Invalid Privilges: " + account.Name)
-	}
-
 	delAccount, err := manager.AccountManager{}.GetAccountByID(del.ID, auth.DB)
 	if err != nil {
 		return "", err
 	}
+	if delAccount == nil {
+		return "", errors.New("No account found: " + del.ID)
+	}
 
-	//Get roles of the account we are trying to delete
-	delAccount = delAccount.GetAccountPermissions()
+	res, err := auth.checkPolicy(types.ScopeAccountsDelete, actor, delAccount, nil, orgID)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	//Organizations only own their memberships. The account itself is never deleted from one
+	if orgID != "" {
+		return "", manager.OrgManager{}.RemoveMember(orgID, delAccount.ID, auth.DB)
+	}
 
-	err = manager.AccountManager{}.DeleteAccount(delAccount, auth.DB, auth.Cache)
+	err = manager.AccountManager{}.DeleteAccount(delAccount, auth.Config.Deletion.ReserveNames, auth.DB, auth.Cache)
 	if err != nil {
 		return "", err
 	}
+	auth.audit(delAccount.ID, actor.ID, types.AuditAccountDeleted, "", session)
 
 	return "", nil
 }
@@ -403,12 +743,9 @@ func (auth Authenticate) DisableTwoFA(session *types.Session) error {
 		return err
 	}
 
-	//Get Account Roles
-	account = account.GetAccountPermissions()
-
-	//ADMIN accounts cannot disable 2FA. Even if they do they will still be required to activate a device
-	if utils.Contains("ADMIN", account.Roles) {
-		return errors.New("ADMIN account cannot disable TwoFA: " + account.Name)
+	//Accounts with permissions cannot disable 2FA. Even if they do they will still be required to activate a device
+	if account.IsPrivileged() {
+		return errors.New("Privileged account cannot disable TwoFA: " + account.Name)
 	}
 
 	err = manager.AccountManager{}.DisableTwoFA(account, auth.DB, auth.Cache)
@@ -434,6 +771,9 @@ func (auth Authenticate) ChangeEmail(session *types.Session, emailRequest *types
 	}
 
 	//Make sure email does not already exist
+	tmpAccount.ID = account.ID
+	tmpAccount.ActiveOrg = account.ActiveOrg
+	tmpAccount.OrgUnique = auth.Config.Orgs.UniquePerOrg
 	res, err := manager.AccountManager{}.CheckDuplicates(tmpAccount, auth.DB)
 	if err != nil {
 		return "", nil, err
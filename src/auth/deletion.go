@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"manager"
+	"time"
+	"types"
+)
+
+//GetDeletedAccounts - returns the deleted accounts that can still be restored
+func (auth Authenticate) GetDeletedAccounts(session *types.Session) (*[]types.Account, error) {
+	_, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsDelete)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.AccountManager{}.GetDeletedAccounts(orgID, auth.DB)
+}
+
+//RestoreAccount - undoes a delete within the restore window. Returns a reason if it cannot be restored
+func (auth Authenticate) RestoreAccount(session *types.Session, request *types.DeleteAccountRequest) (string, error) {
+	am := manager.AccountManager{}
+
+	actor, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsDelete)
+	if err != nil {
+		return "", err
+	}
+
+	account, err := am.GetDeletedAccount(request.ID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if account == nil {
+		return "", errors.New("No deleted account found: " + request.ID)
+	}
+	if orgID != "" {
+		member, err := manager.OrgManager{}.GetMember(orgID, account.ID, auth.DB)
+		if err != nil {
+			return "", err
+		}
+		if member == nil {
+			return "", errors.New("No deleted account found: " + request.ID)
+		}
+	}
+	if time.Since(*account.DeletedAt) > time.Duration(auth.Config.Deletion.RestoreDays)*24*time.Hour {
+		return "Restore window has passed", nil
+	}
+	//Service accounts cannot be used without their owner
+	if account.IsService() {
+		owner, err := am.GetAccountByID(account.OwnerID, auth.DB)
+		if err != nil {
+			return "", err
+		}
+		if owner == nil {
+			return "Restore the owner of the service account first", nil
+		}
+	}
+
+	account.ActiveOrg = orgID
+	account.OrgUnique = auth.Config.Orgs.UniquePerOrg
+	res, err := am.RestoreAccount(account, auth.DB)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	auth.audit(account.ID, actor.ID, types.AuditAccountRestored, "", session)
+	return "", nil
+}
+
+//PurgeDeleted - removes accounts deleted longer ago than the restore window
+func (auth Authenticate) PurgeDeleted() {
+	err := manager.AccountManager{}.PurgeDeleted(auth.Config.Deletion.RestoreDays, auth.DB)
+	if err != nil {
+		fmt.Println("Failed purging deleted accounts: " + err.Error())
+	}
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"types"
+)
+
+//ldapLogin - checks the login against the ldap directory. Returns the account linked to the directory user
+func (auth Authenticate) ldapLogin(login *types.Login) (*types.Account, error) {
+	if auth.Directory == nil {
+		return nil, errors.New("LDAP login is not configured")
+	}
+
+	user, err := auth.Directory.Authenticate(login.UserName, login.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.directoryAccount(user)
+}
+
+//directoryAccount - returns the account linked to a directory user.
+//On first login the user is linked to the account with the same email or a new account is created.
+//Accounts with permissions are only linked by email if they already have a directory identity.
+//Name, email, phone and role are kept in sync with the directory
+func (auth Authenticate) directoryAccount(user *types.DirectoryUser) (*types.Account, error) {
+	am := manager.AccountManager{}
+	im := manager.IdentityManager{}
+
+	//Only let the directory manage roles if groups are mapped
+	role := -1
+	if len(auth.Directory.Config.GroupRoles) > 0 {
+		role = auth.Directory.Role(user.Groups)
+	}
+
+	identity, err := im.GetIdentity(types.LDAPProvider, user.ID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity == nil {
+		var account *types.Account
+		if user.Email != "" {
+			account, err = am.GetAccountByEmail(user.Email, auth.DB)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		//Privileged accounts are only linked by email if the directory already manages them.
+		//Otherwise they must link the directory user with LinkIdentity
+		if account != nil {
+			linked, err := auth.directoryLinkable(account)
+			if err != nil {
+				return nil, err
+			}
+			if !linked {
+				return nil, errors.New("Directory user must be linked from a logged in session: " + account.Name)
+			}
+		}
+
+		if account == nil {
+			//Directory phones that cannot be used are left out
+			phone, err := auth.syncedPhone(&types.Account{}, user.Phone)
+			if err != nil {
+				return nil, err
+			}
+			account = &types.Account{UserName: user.UserName, Name: user.Name, Email: user.Email, Phone: phone, Role: auth.Directory.Role(user.Groups)}
+			err = am.CreateExternalAccount(account, auth.DB)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = im.CreateIdentity(&types.Identity{AccountID: account.ID, Type: types.IdentityDirectory, Provider: types.LDAPProvider, Subject: user.ID, Email: user.Email}, auth.DB)
+		if err != nil {
+			return nil, err
+		}
+		identity = &types.Identity{AccountID: account.ID}
+	}
+
+	account, err := am.GetAccountByID(identity.AccountID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("Linked account no longer exists: " + identity.AccountID)
+	}
+
+	//Keep the account in sync with the directory
+	if role == -1 {
+		role = account.Role
+	}
+	phone, err := auth.syncedPhone(account, user.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if account.Name != user.Name || (user.Email != "" && account.Email != user.Email) || account.Phone != phone || account.Role != role {
+		account.Name = user.Name
+		account.ChangePhone(phone)
+		account.Role = role
+		if user.Email != "" {
+			account.Email = user.Email
+		}
+		err = am.SyncAccount(account, auth.DB)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return account, nil
+}
+
+//directoryLinkable - checks if a directory user can be linked to an account by email
+func (auth Authenticate) directoryLinkable(account *types.Account) (bool, error) {
+	err := manager.RoleManager{}.LoadAccountRoles(account, auth.DB)
+	if err != nil || !account.IsPrivileged() {
+		return err == nil, err
+	}
+
+	identities, err := manager.IdentityManager{}.GetAccountIdentities(account, auth.DB)
+	if err != nil {
+		return false, err
+	}
+	for _, identity := range *identities {
+		if identity.Type == types.IdentityDirectory {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+//linkDirectoryIdentity - links the directory user of the login given to an account. Returns a reason if it cannot be linked
+func (auth Authenticate) linkDirectoryIdentity(account *types.Account, login string, password string) (string, error) {
+	im := manager.IdentityManager{}
+
+	if auth.Directory == nil {
+		return "LDAP login is not configured", nil
+	}
+
+	user, err := auth.Directory.Authenticate(login, password)
+	if err != nil {
+		return "", err
+	}
+
+	identity, err := im.GetIdentity(types.LDAPProvider, user.ID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if identity != nil {
+		if identity.AccountID != account.ID {
+			return "Directory user is already linked to another account", nil
+		}
+		return "", nil
+	}
+
+	return "", im.CreateIdentity(&types.Identity{AccountID: account.ID, Type: types.IdentityDirectory, Provider: types.LDAPProvider, Subject: user.ID, Email: user.Email}, auth.DB)
+}
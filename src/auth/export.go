@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"manager"
+	"os"
+	"path/filepath"
+	"time"
+	"types"
+	"utils"
+)
+
+//Where export files are written
+const exportDir = "./exports"
+
+//How long an export can be downloaded for
+const exportTimeout = 7 * 24 * time.Hour
+
+//createExport - stores a pending export of an account. The link is sent to the requester
+func (auth Authenticate) createExport(account *types.Account, requester *types.Account, request *types.ExportRequest, session *types.Session) (string, *types.Export, string, error) {
+	format := request.Format
+	if format == "" {
+		format = types.ExportJSON
+	}
+	if format != types.ExportJSON && format != types.ExportZIP {
+		return "Invalid format: " + format, nil, "", nil
+	}
+	if requester.Email == "" {
+		return "An email is needed to send the download link", nil, "", nil
+	}
+
+	export := types.Export{AccountID: account.ID, RequestedBy: requester.ID, Email: requester.Email, Format: format}
+	token, err := manager.ExportManager{}.CreateExport(&export, auth.DB)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	auth.audit(account.ID, requester.ID, types.AuditExportRequested, format, session)
+
+	return "", &export, token, nil
+}
+
+//ExportOwnData - starts an export of the data held about the session account. Returns a reason if the request is invalid
+func (auth Authenticate) ExportOwnData(session *types.Session, request *types.ExportRequest) (string, *types.Export, string, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	return auth.createExport(account, account, request, session)
+}
+
+//ExportAccount - starts an export of another account for an admin. The link is sent to the admin
+func (auth Authenticate) ExportAccount(session *types.Session, request *types.ExportRequest) (string, *types.Export, string, error) {
+	actor, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsRead)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByID(request.AccountID, auth.DB)
+	if err != nil {
+		return "", nil, "", err
+	}
+	if account == nil {
+		return "", nil, "", errors.New("No account found: " + request.AccountID)
+	}
+	if orgID != "" {
+		member, err := manager.OrgManager{}.GetMember(orgID, account.ID, auth.DB)
+		if err != nil {
+			return "", nil, "", err
+		}
+		if member == nil {
+			return "Account is not in your organization", nil, "", nil
+		}
+	}
+
+	return auth.createExport(account, actor, request, session)
+}
+
+//BuildExport - collects the data of an export and writes its file
+func (auth Authenticate) BuildExport(export *types.Export) error {
+	em := manager.ExportManager{}
+
+	err := auth.writeExport(export)
+	if err != nil {
+		export.Status = types.ExportFailed
+		em.FinishExport(export, auth.DB)
+		return err
+	}
+
+	export.Status = types.ExportReady
+	return em.FinishExport(export, auth.DB)
+}
+
+//writeExport - writes the data of an account to a json file or a zip holding it
+func (auth Authenticate) writeExport(export *types.Export) error {
+	account, err := manager.AccountManager{}.GetAccountByID(export.AccountID, auth.DB)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return errors.New("No account found for export: " + export.AccountID)
+	}
+
+	bundle, err := manager.ExportManager{}.Collect(account, auth.DB)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(exportDir, os.ModePerm); err != nil {
+		return err
+	}
+	//File names must not be guessable
+	name, err := utils.SecureString()
+	if err != nil {
+		return err
+	}
+	export.File = filepath.Join(exportDir, name+"."+export.Format)
+
+	if export.Format == types.ExportJSON {
+		return ioutil.WriteFile(export.File, data, 0600)
+	}
+
+	file, err := os.OpenFile(export.File, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	entry, err := archive.Create("account.json")
+	if err != nil {
+		return err
+	}
+	if _, err := entry.Write(data); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+//GetExportDownload - returns a finished export from the token of its link
+func (auth Authenticate) GetExportDownload(token string) (*types.Export, error) {
+	export, err := manager.ExportManager{}.GetExport(token, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || time.Since(export.Created) > exportTimeout {
+		return nil, errors.New("Invalid or expired export link")
+	}
+	if export.Status != types.ExportReady {
+		return nil, errors.New("Export is " + export.Status)
+	}
+
+	//Exports of deleted accounts cannot be downloaded
+	account, err := manager.AccountManager{}.GetAccountByID(export.AccountID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("Invalid or expired export link")
+	}
+	return export, nil
+}
+
+//CleanExports - removes exports and their files once they can no longer be downloaded
+func (auth Authenticate) CleanExports() {
+	em := manager.ExportManager{}
+
+	exports, err := em.GetExpiredExports(time.Now().Add(-exportTimeout), auth.DB)
+	if err != nil {
+		fmt.Println("Failed cleaning exports: " + err.Error())
+		return
+	}
+	for _, export := range *exports {
+		if export.File != "" {
+			os.Remove(export.File)
+		}
+		if err := em.DeleteExport(&export, auth.DB); err != nil {
+			fmt.Println("Failed cleaning exports: " + err.Error())
+			return
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"strings"
+	"time"
+	"types"
+	"utils"
+)
+
+//How long a user has to finish logging in with an external provider
+const federatedStateTimeout = 10 * time.Minute
+
+//FederatedLoginURL - starts a login with an external provider. Returns the url to send the user to.
+//If accountID is set the identity is linked to that account instead of starting a session
+func (auth Authenticate) FederatedLoginURL(name string, continueURL string, accountID string) (string, error) {
+	provider, ok := auth.Providers[name]
+	if !ok {
+		return "", errors.New("Unknown identity provider: " + name)
+	}
+
+	//State, nonce and PKCE verifier must not be guessable
+	var secrets [3]string
+	for i := range secrets {
+		secret, err := utils.SecureString()
+		if err != nil {
+			return "", err
+		}
+		secrets[i] = secret
+	}
+	state := types.FederatedState{
+		ID:        secrets[0],
+		Provider:  name,
+		Nonce:     secrets[1],
+		Verifier:  secrets[2],
+		Continue:  continueURL,
+		AccountID: accountID,
+		Created:   time.Now(),
+	}
+	err := manager.IdentityManager{}.CreateState(&state, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return provider.AuthURL(state.ID, state.Nonce, state.Verifier)
+}
+
+//FederatedLogin - finishes a login with an external provider and starts a session.
+//Returns the account, its device if one needs verifying and where the user wanted to go.
+//When the login was started to link an identity the current session is kept instead
+func (auth Authenticate) FederatedLogin(name string, stateID string, code string, session *types.Session) (*types.Account, *types.Device, string, error) {
+	provider, ok := auth.Providers[name]
+	if !ok {
+		return nil, nil, "", errors.New("Unknown identity provider: " + name)
+	}
+
+	state, err := manager.IdentityManager{}.ConsumeState(stateID, auth.DB)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if state == nil || state.Provider != name || time.Since(state.Created) > federatedStateTimeout {
+		return nil, nil, "", errors.New("Invalid or expired login state: " + name)
+	}
+
+	claims, err := provider.Exchange(code, state.Verifier, state.Nonce)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if state.AccountID != "" {
+		account, err := auth.linkFederatedIdentity(name, state.AccountID, claims, session)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return account, nil, state.Continue, nil
+	}
+
+	account, err := auth.federatedAccount(name, provider.Config.DefaultRole, claims)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	account, device, err := auth.startSession(account, session)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return account, device, state.Continue, nil
+}
+
+//federatedAccount - returns the account linked to an external identity.
+//On first login the identity is linked to the account with the same verified email or a new account is created.
+//Accounts with permissions are never linked by email
+func (auth Authenticate) federatedAccount(name string, role int, claims map[string]interface{}) (*types.Account, error) {
+	am := manager.AccountManager{}
+	im := manager.IdentityManager{}
+
+	subject, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	emailVerified, _ := claims["email_verified"].(bool)
+
+	identity, err := im.GetIdentity(name, subject, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		account, err := am.GetAccountByID(identity.AccountID, auth.DB)
+		if err != nil {
+			return nil, err
+		}
+		if account == nil {
+			return nil, errors.New("Linked account no longer exists: " + identity.AccountID)
+		}
+		return account, nil
+	}
+
+	if email == "" {
+		return nil, errors.New("Identity provider did not return an email: " + name)
+	}
+
+	//Only trust the email to find an existing account if the provider verified it
+	var account *types.Account
+	if emailVerified {
+		account, err = am.GetAccountByEmail(email, auth.DB)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//Privileged accounts must link identities from a logged in session so a provider cannot take them over
+	if account != nil {
+		err = manager.RoleManager{}.LoadAccountRoles(account, auth.DB)
+		if err != nil {
+			return nil, err
+		}
+		if account.IsPrivileged() {
+			return nil, errors.New("Identity must be linked from a logged in session: " + account.Name)
+		}
+	}
+
+	if account == nil {
+		userName, _ := claims["preferred_username"].(string)
+		if userName == "" {
+			userName = strings.Split(email, "@")[0]
+		}
+		fullName, _ := claims["name"].(string)
+		account = &types.Account{UserName: userName, Name: fullName, Email: email, Role: role}
+		err = am.CreateExternalAccount(account, auth.DB)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = im.CreateIdentity(&types.Identity{AccountID: account.ID, Type: types.IdentityFederated, Provider: name, Subject: subject, Email: email}, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+//linkFederatedIdentity - links an external identity to the account that started the link
+func (auth Authenticate) linkFederatedIdentity(name string, accountID string, claims map[string]interface{}, session *types.Session) (*types.Account, error) {
+	im := manager.IdentityManager{}
+
+	//The link must be finished from the same account that started it
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return nil, err
+	}
+	if account.ID != accountID {
+		return nil, errors.New("Identity link started by another account: " + account.Name)
+	}
+
+	subject, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+
+	identity, err := im.GetIdentity(name, subject, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		if identity.AccountID != account.ID {
+			return nil, errors.New("Identity is already linked to another account: " + name)
+		}
+		return account, nil
+	}
+
+	err = im.CreateIdentity(&types.Identity{AccountID: account.ID, Type: types.IdentityFederated, Provider: name, Subject: subject, Email: email}, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
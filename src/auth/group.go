@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"regexp"
+	"types"
+	"utils"
+)
+
+//checkGroup - returns a reason if the name or roles of a group are invalid or rank above the account changing it
+func (auth Authenticate) checkGroup(admin *types.Account, group *types.Group) (string, error) {
+	if !regexp.MustCompile(`^[A-Za-z0-9_ -]{2,50}$`).MatchString(group.Name) {
+		return "Group name must be 2 to 50 letters, digits, spaces, _ or -", nil
+	}
+
+	roles, err := manager.RoleManager{}.GetRoles(auth.DB)
+	if err != nil {
+		return "", err
+	}
+	known := []string{}
+	for _, role := range *roles {
+		known = append(known, role.Name)
+	}
+	for _, name := range group.Roles {
+		if !utils.Contains(name, known) {
+			return "Invalid role: " + name, nil
+		}
+	}
+
+	res, err := auth.checkGroupRank(admin, group.Roles)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	return manager.GroupManager{}.CheckDuplicateGroup(group, auth.DB)
+}
+
+//checkGroupRank - returns a reason if a group gives roles ranking above the account changing it. Machine clients are not ranked
+func (auth Authenticate) checkGroupRank(admin *types.Account, roles []string) (string, error) {
+	if admin.Type == types.AccountClient {
+		return "", nil
+	}
+	rank, err := manager.RoleManager{}.RolesRank(roles, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if rank > admin.Rank {
+		return "Cannot give a higher role than your own", nil
+	}
+	return "", nil
+}
+
+//checkGroupMember - runs the role policies for adding an account to a group or removing it.
+//The roles given are every role the group gives its members
+func (auth Authenticate) checkGroupMember(admin *types.Account, groupID string, accountID string) (string, error) {
+	if admin.ID == accountID {
+		return "Cannot change your own groups", nil
+	}
+	account, err := manager.AccountManager{}.GetAccountByID(accountID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if account == nil {
+		return "", errors.New("No account found: " + accountID)
+	}
+
+	roles, err := manager.GroupManager{}.EffectiveRoles(groupID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	return auth.checkPolicy(types.ScopeRolesWrite, admin, account, &types.Account{ID: account.ID, Roles: roles}, "")
+}
+
+//getGroup - returns a group by id. Errors if it does not exist
+func (auth Authenticate) getGroup(id string) (*types.Group, error) {
+	group, err := manager.GroupManager{}.GetGroup(id, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, errors.New("No group found: " + id)
+	}
+	return group, nil
+}
+
+//GetGroups - returns every group with its roles and members
+func (auth Authenticate) GetGroups(session *types.Session) (*[]types.Group, error) {
+	_, err := auth.checkPermission(session, types.ScopeRolesRead)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.GroupManager{}.GetGroups(auth.DB)
+}
+
+//CreateGroup - creates a group with its roles. Returns a reason if the group is invalid
+func (auth Authenticate) CreateGroup(session *types.Session, group *types.Group) (string, error) {
+	admin, err := auth.checkPermission(session, types.ScopeRolesWrite)
+	if err != nil {
+		return "", err
+	}
+
+	group.ID = ""
+	res, err := auth.checkGroup(admin, group)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	err = manager.GroupManager{}.CreateGroup(group, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//UpdateGroup - updates the name, description and roles of a group
+func (auth Authenticate) UpdateGroup(session *types.Session, group *types.Group) (string, error) {
+	admin, err := auth.checkPermission(session, types.ScopeRolesWrite)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := auth.getGroup(group.ID); err != nil {
+		return "", err
+	}
+
+	res, err := auth.checkGroup(admin, group)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	err = manager.GroupManager{}.UpdateGroup(group, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//DeleteGroup - deletes a group. Its members lose the roles it gave
+func (auth Authenticate) DeleteGroup(session *types.Session, request *types.GroupRequest) error {
+	_, err := auth.checkPermission(session, types.ScopeRolesWrite)
+	if err != nil {
+		return err
+	}
+
+	group, err := auth.getGroup(request.ID)
+	if err != nil {
+		return err
+	}
+
+	return manager.GroupManager{}.DeleteGroup(group, auth.DB)
+}
+
+//AddGroupMember - adds an account or a nested group to a group. Accounts cannot add themselves.
+//The roles the group gives cannot rank above the account adding the member
+func (auth Authenticate) AddGroupMember(session *types.Session, request *types.GroupMemberRequest) (string, error) {
+	gm := manager.GroupManager{}
+
+	admin, err := auth.checkPermission(session, types.ScopeRolesWrite)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := auth.getGroup(request.GroupID); err != nil {
+		return "", err
+	}
+
+	if request.MemberGroupID != "" {
+		if _, err := auth.getGroup(request.MemberGroupID); err != nil {
+			return "", err
+		}
+		//Members of the nested group get every role of the group
+		roles, err := gm.EffectiveRoles(request.GroupID, auth.DB)
+		if err != nil {
+			return "", err
+		}
+		res, err := auth.checkGroupRank(admin, roles)
+		if err != nil || res != "" {
+			return res, err
+		}
+		return gm.AddGroup(request.GroupID, request.MemberGroupID, auth.DB)
+	}
+
+	res, err := auth.checkGroupMember(admin, request.GroupID, request.AccountID)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	err = gm.AddMember(request.GroupID, request.AccountID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//RemoveGroupMember - removes an account or a nested group from a group. Accounts cannot remove themselves.
+//The roles the group gives cannot rank above the account removing the member
+func (auth Authenticate) RemoveGroupMember(session *types.Session, request *types.GroupMemberRequest) (string, error) {
+	gm := manager.GroupManager{}
+
+	admin, err := auth.checkPermission(session, types.ScopeRolesWrite)
+	if err != nil {
+		return "", err
+	}
+
+	if request.MemberGroupID != "" {
+		roles, err := gm.EffectiveRoles(request.GroupID, auth.DB)
+		if err != nil {
+			return "", err
+		}
+		res, err := auth.checkGroupRank(admin, roles)
+		if err != nil || res != "" {
+			return res, err
+		}
+		return "", gm.RemoveGroup(request.GroupID, request.MemberGroupID, auth.DB)
+	}
+
+	res, err := auth.checkGroupMember(admin, request.GroupID, request.AccountID)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	return "", gm.RemoveMember(request.GroupID, request.AccountID, auth.DB)
+}
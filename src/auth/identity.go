@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"time"
+	"types"
+	"utils"
+)
+
+//How recently an account without a password must have logged in to change its identities
+const reauthTimeout = 10 * time.Minute
+
+//reauthenticate - makes sure the user of the session is the account owner before changing how it logs in.
+//Accounts with a password must provide it, other accounts must have logged in recently
+func (auth Authenticate) reauthenticate(account *types.Account, password string) error {
+	if account.Password != "" {
+		if !utils.CheckPasswordHash(password, account.Password) {
+			return errors.New("Invalid Password Attempt: " + account.Name)
+		}
+		return nil
+	}
+	if account.LastLogin == nil || time.Since(*account.LastLogin) > reauthTimeout {
+		return errors.New("Login required to change identities: " + account.Name)
+	}
+	return nil
+}
+
+//GetIdentities - returns the login identities of the session account
+func (auth Authenticate) GetIdentities(session *types.Session) (*[]types.Identity, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := manager.IdentityManager{}.GetAccountIdentities(account, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+//LinkIdentity - adds a login identity to the session account.
+//Returns a reason if the request is invalid and the url to send the user to when linking an identity provider
+func (auth Authenticate) LinkIdentity(session *types.Session, request *types.LinkIdentityRequest) (string, string, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := auth.reauthenticate(account, request.Password); err != nil {
+		return "", "", err
+	}
+
+	//Add a password to the account
+	if request.Provider == types.LocalProvider {
+		if account.Password != "" {
+			return "Account already has a password", "", nil
+		}
+
+		if err := (types.Account{Password: request.NewPassword}).CheckPassword(); err != nil {
+			return err.Error(), "", nil
+		}
+
+		hash, err := utils.HashPassword(request.NewPassword)
+		if err != nil {
+			return "", "", err
+		}
+
+		err = manager.AccountManager{}.SetPassword(account, hash, auth.DB, auth.Cache)
+		if err != nil {
+			return "", "", err
+		}
+
+		err = manager.IdentityManager{}.CreatePasswordIdentity(account, auth.DB)
+		if err != nil {
+			return "", "", err
+		}
+
+		return "", "", nil
+	}
+
+	//Directory users are linked with their directory login
+	if request.Provider == types.LDAPProvider {
+		res, err := auth.linkDirectoryIdentity(account, request.Login, request.LoginPassword)
+		return res, "", err
+	}
+
+	//Identity provider identities are linked once the user returns from the provider
+	url, err := auth.FederatedLoginURL(request.Provider, request.Continue, account.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return "", url, nil
+}
+
+//UnlinkIdentity - removes a login identity from the session account. The last identity cannot be removed
+func (auth Authenticate) UnlinkIdentity(session *types.Session, request *types.UnlinkIdentityRequest) (string, error) {
+	im := manager.IdentityManager{}
+
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", err
+	}
+
+	if err := auth.reauthenticate(account, request.Password); err != nil {
+		return "", err
+	}
+
+	identity, err := im.GetIdentityByID(request.ID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if identity == nil || identity.AccountID != account.ID {
+		return "", errors.New("No identity found: " + request.ID)
+	}
+
+	identities, err := im.GetAccountIdentities(account, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if len(*identities) <= 1 {
+		return "Cannot remove the last way to login", nil
+	}
+
+	err = im.DeleteIdentity(identity, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	//Password login is removed with its identity
+	if identity.Type == types.IdentityPassword {
+		err = manager.AccountManager{}.SetPassword(account, "", auth.DB, auth.Cache)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"time"
+	"types"
+)
+
+//inviteTTL - how long an invite link can be used for
+func (auth Authenticate) inviteTTL() time.Duration {
+	if auth.Config.InviteTTL <= 0 {
+		return 72 * time.Hour
+	}
+	return time.Duration(auth.Config.InviteTTL) * time.Hour
+}
+
+//signInvite - returns the signed token sent in an invite link
+func (auth Authenticate) signInvite(invite *types.Invite) (string, error) {
+	return auth.Signer.Sign(map[string]interface{}{
+		"iss":   auth.Config.OAuth.Issuer,
+		"typ":   "invite",
+		"jti":   invite.ID,
+		"email": invite.Email,
+		"exp":   invite.Expires.Unix(),
+	})
+}
+
+//checkInviteAccess - returns an invite the session can manage. Sessions in an organization only manage its invites
+func (auth Authenticate) checkInviteAccess(session *types.Session, id string) (*types.Invite, error) {
+	_, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	invite, err := manager.InviteManager{}.GetInvite(id, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil || (orgID != "" && invite.OrgID != orgID) {
+		return nil, errors.New("No invite found: " + id)
+	}
+	return invite, nil
+}
+
+//GetInvites - returns the pending invites. Sessions in an organization only see its invites
+func (auth Authenticate) GetInvites(session *types.Session) (*[]types.Invite, error) {
+	_, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.InviteManager{}.GetInvites(orgID, auth.DB)
+}
+
+//CreateInvite - stores an invite for the email given. Returns the invite and the token to send, or a reason if it is invalid.
+//Sessions in an organization invite to it and the role given is the role there
+func (auth Authenticate) CreateInvite(session *types.Session, request *types.InviteRequest) (string, *types.Invite, string, error) {
+	im := manager.InviteManager{}
+
+	actor, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	account := types.Account{Email: request.Email, ActiveOrg: orgID, OrgUnique: auth.Config.Orgs.UniquePerOrg}
+	if err := account.CheckEmail(); err != nil {
+		return err.Error(), nil, "", nil
+	}
+	if request.Name == "" {
+		return "Name is required", nil, "", nil
+	}
+	if orgID != "" && request.Role > actor.OrgRole {
+		return "Cannot give a higher role than your own", nil, "", nil
+	}
+
+	res, err := manager.AccountManager{}.CheckDuplicates(&account, auth.DB)
+	if err != nil || res != "" {
+		return res, nil, "", err
+	}
+	existing, err := im.GetInviteByEmail(request.Email, orgID, auth.DB)
+	if err != nil {
+		return "", nil, "", err
+	}
+	if existing != nil {
+		return "An invite was already sent to: " + request.Email, nil, "", nil
+	}
+
+	invite := types.Invite{Email: request.Email, Name: request.Name, Role: request.Role, OrgID: orgID, InvitedBy: actor.ID}
+	err = im.CreateInvite(&invite, auth.inviteTTL(), auth.DB)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	token, err := auth.signInvite(&invite)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	return "", &invite, token, nil
+}
+
+//ResendInvite - renews an invite and returns the new token to send. Links sent before stop working
+func (auth Authenticate) ResendInvite(session *types.Session, request *types.InviteRequest) (*types.Invite, string, error) {
+	invite, err := auth.checkInviteAccess(session, request.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = manager.InviteManager{}.RenewInvite(invite, auth.inviteTTL(), auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := auth.signInvite(invite)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return invite, token, nil
+}
+
+//RevokeInvite - removes a pending invite so its link stops working
+func (auth Authenticate) RevokeInvite(session *types.Session, request *types.InviteRequest) error {
+	invite, err := auth.checkInviteAccess(session, request.ID)
+	if err != nil {
+		return err
+	}
+
+	return manager.InviteManager{}.DeleteInvite(invite.ID, auth.DB)
+}
+
+//AcceptInvite - creates the invited account with the username and password chosen. Returns a reason if they are invalid
+func (auth Authenticate) AcceptInvite(request *types.InviteRequest) (string, error) {
+	im := manager.InviteManager{}
+
+	claims, err := auth.Signer.Verify(request.Token)
+	if err != nil {
+		return "", err
+	}
+	id, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	if claims["typ"] != "invite" || id == "" || time.Now().Unix() > int64(exp) {
+		return "", errors.New("Invalid or expired invite")
+	}
+
+	invite, err := im.GetInvite(id, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if invite == nil || time.Now().After(invite.Expires) || claims["email"] != invite.Email {
+		return "", errors.New("Invalid or expired invite")
+	}
+
+	account := types.Account{
+		UserName:  request.UserName,
+		Password:  request.Password,
+		Phone:     request.Phone,
+		Name:      invite.Name,
+		Email:     invite.Email,
+		Role:      invite.Role,
+		Type:      types.AccountUser,
+		ActiveOrg: invite.OrgID,
+		OrgUnique: auth.Config.Orgs.UniquePerOrg,
+	}
+	if invite.OrgID != "" {
+		//The role is only given in the organization
+		account.Role = types.LevelDefault
+	}
+
+	res, err := auth.checkPhone(&account)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	res, err = manager.AccountManager{}.CreateAccount(&account, nil, auth.DB)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	if invite.OrgID != "" {
+		err = manager.OrgManager{}.AddMember(&types.OrgMember{OrgID: invite.OrgID, AccountID: account.ID, Role: invite.Role}, auth.DB)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "", im.DeleteInvite(invite.ID, auth.DB)
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"time"
+	"types"
+)
+
+//How long a magic link can be used for
+const magicLinkTimeout = 15 * time.Minute
+
+//RequestMagicLink - creates a login link for the account with the email given. Returns the link and its plain token
+func (auth Authenticate) RequestMagicLink(request *types.MagicLinkRequest) (*types.MagicLink, string, error) {
+	account, err := manager.AccountManager{}.GetAccountByEmail(request.Email, auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+	if account == nil {
+		return nil, "", errors.New("Account not found for magic link: " + request.Email)
+	}
+
+	token, link, err := manager.MagicLinkManager{}.CreateMagicLink(account, auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return link, token, nil
+}
+
+//VerifyMagicLink - uses a magic link and starts a session. Accounts with permissions or 2FA still need to verify their device
+func (auth Authenticate) VerifyMagicLink(request *types.MagicLinkRequest, session *types.Session) (*types.Account, *types.Device, error) {
+	link, err := manager.MagicLinkManager{}.ConsumeMagicLink(request.Token, auth.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+	if link == nil || time.Since(link.Created) > magicLinkTimeout {
+		return nil, nil, errors.New("Invalid or expired magic link")
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByID(link.AccountID, auth.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+	//The link was sent to an email the account no longer has
+	if account == nil || account.Email != link.Email {
+		return nil, nil, errors.New("No account was found for magic link: " + link.AccountID)
+	}
+
+	return auth.startSession(account, session)
+}
@@ -0,0 +1,573 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"manager"
+	"strings"
+	"time"
+	"types"
+	"utils"
+)
+
+//How long an authorization code can be exchanged for
+const codeTimeout = 10 * time.Minute
+
+//GetAuthorizeClient - returns the client of an authorization request if the redirect uri is registered to it
+func (auth Authenticate) GetAuthorizeClient(request *types.AuthorizeRequest) (*types.OAuthClient, error) {
+	client, err := manager.OAuthManager{}.GetClient(request.ClientID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || client.Revoked {
+		return nil, errors.New("Unknown client: " + request.ClientID)
+	}
+	if !client.HasGrantType("authorization_code") {
+		return nil, errors.New("Client cannot use the authorization code grant: " + request.ClientID)
+	}
+	if !client.HasRedirectURI(request.RedirectURI) {
+		return nil, errors.New("Redirect uri not registered: " + request.RedirectURI)
+	}
+	return client, nil
+}
+
+//Authorize - issues an authorization code for the account of the session
+func (auth Authenticate) Authorize(session *types.Session, request *types.AuthorizeRequest) (*types.OAuthCode, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := auth.GetAuthorizeClient(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ResponseType != "code" {
+		return nil, types.OAuthError{Code: "unsupported_response_type", Description: "Only the code response type is supported"}
+	}
+
+	//Public clients cannot keep a secret so they must prove the code with PKCE
+	if request.CodeChallenge == "" && client.IsPublic() {
+		return nil, types.OAuthError{Code: "invalid_request", Description: "code_challenge is required"}
+	}
+	if request.CodeChallenge != "" && request.CodeChallengeMethod != "S256" {
+		return nil, types.OAuthError{Code: "invalid_request", Description: "Only the S256 code_challenge_method is supported"}
+	}
+
+	id, err := utils.SecureString()
+	if err != nil {
+		return nil, err
+	}
+	code := types.OAuthCode{
+		ID:            id,
+		ClientID:      client.ID,
+		AccountID:     account.ID,
+		RedirectURI:   request.RedirectURI,
+		Scope:         request.Scope,
+		Nonce:         request.Nonce,
+		CodeChallenge: request.CodeChallenge,
+		Created:       time.Now(),
+	}
+	err = manager.OAuthManager{}.CreateCode(&code, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return &code, nil
+}
+
+//authenticateClient - returns the client if the credentials are valid. Public clients have no secret
+func (auth Authenticate) authenticateClient(clientID string, secret string) (*types.OAuthClient, error) {
+	client, err := manager.OAuthManager{}.GetClient(clientID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || client.Revoked {
+		return nil, types.OAuthError{Code: "invalid_client", Description: "Unknown client"}
+	}
+	if !client.IsPublic() && !utils.CheckPasswordHash(secret, client.Secret) {
+		return nil, types.OAuthError{Code: "invalid_client", Description: "Invalid client credentials"}
+	}
+	return client, nil
+}
+
+//Token - exchanges a grant for tokens
+func (auth Authenticate) Token(request *types.TokenRequest) (*types.TokenResponse, error) {
+	client, err := auth.authenticateClient(request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.HasGrantType(request.GrantType) {
+		return nil, types.OAuthError{Code: "unauthorized_client", Description: "Client cannot use grant: " + request.GrantType}
+	}
+
+	switch request.GrantType {
+	case "authorization_code":
+		return auth.exchangeCode(client, request)
+	case "refresh_token":
+		return auth.refreshToken(client, request)
+	case "client_credentials":
+		return auth.clientCredentials(client, request)
+	}
+	return nil, types.OAuthError{Code: "unsupported_grant_type", Description: request.GrantType}
+}
+
+//clientCredentials - issues an access token to a machine client for the scopes it was granted
+func (auth Authenticate) clientCredentials(client *types.OAuthClient, request *types.TokenRequest) (*types.TokenResponse, error) {
+	if client.IsPublic() {
+		return nil, types.OAuthError{Code: "unauthorized_client", Description: "Public clients cannot use client credentials"}
+	}
+
+	//Default to every scope the client was granted
+	scope := client.Scopes
+	if request.Scope != "" {
+		for _, s := range strings.Fields(request.Scope) {
+			if !client.HasScope(s) {
+				return nil, types.OAuthError{Code: "invalid_scope", Description: s}
+			}
+		}
+		scope = request.Scope
+	}
+
+	access, _, err := manager.OAuthManager{}.CreateToken("access", client.ID, "", scope, auth.Config.OAuth.AccessTokenTTL, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.TokenResponse{AccessToken: access, TokenType: "Bearer", ExpiresIn: auth.Config.OAuth.AccessTokenTTL, Scope: scope}, nil
+}
+
+//checkClientScope - returns the machine client of the access token if it was granted the scope
+func (auth Authenticate) checkClientScope(accessToken string, scope string) (*types.OAuthClient, error) {
+	om := manager.OAuthManager{}
+
+	token, err := om.GetToken(accessToken, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.Type != "access" || token.AccountID != "" {
+		return nil, errors.New("Invalid client access token")
+	}
+	if !token.HasScope(scope) {
+		return nil, errors.New("Client token missing scope: " + scope)
+	}
+
+	client, err := om.GetClient(token.ClientID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || client.Revoked {
+		return nil, errors.New("Client revoked: " + token.ClientID)
+	}
+	return client, nil
+}
+
+//exchangeCode - exchanges an authorization code for tokens
+func (auth Authenticate) exchangeCode(client *types.OAuthClient, request *types.TokenRequest) (*types.TokenResponse, error) {
+	code, err := manager.OAuthManager{}.ConsumeCode(request.Code, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if code == nil || time.Since(code.Created) > codeTimeout {
+		return nil, types.OAuthError{Code: "invalid_grant", Description: "Invalid or expired code"}
+	}
+	if code.ClientID != client.ID || code.RedirectURI != request.RedirectURI {
+		return nil, types.OAuthError{Code: "invalid_grant", Description: "Code was not issued to this client"}
+	}
+
+	//Verify PKCE
+	if code.CodeChallenge != "" {
+		sum := sha256.Sum256([]byte(request.CodeVerifier))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != code.CodeChallenge {
+			return nil, types.OAuthError{Code: "invalid_grant", Description: "Invalid code_verifier"}
+		}
+	}
+
+	account, err := auth.grantAccount(code.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.issueTokens(client, account, code.Scope, code.Nonce)
+}
+
+//refreshToken - exchanges a refresh token for new tokens. The refresh token is rotated
+func (auth Authenticate) refreshToken(client *types.OAuthClient, request *types.TokenRequest) (*types.TokenResponse, error) {
+	om := manager.OAuthManager{}
+
+	token, err := om.ConsumeToken(request.RefreshToken, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.Type != "refresh" || token.ClientID != client.ID {
+		return nil, types.OAuthError{Code: "invalid_grant", Description: "Invalid or expired refresh token"}
+	}
+
+	account, err := auth.grantAccount(token.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.issueTokens(client, account, token.Scope, "")
+}
+
+//grantAccount - returns the account tokens are issued for. Accounts that cannot login get no tokens
+func (auth Authenticate) grantAccount(id string) (*types.Account, error) {
+	account, err := manager.AccountManager{}.GetAccountByID(id, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, types.OAuthError{Code: "invalid_grant", Description: "Account no longer exists"}
+	}
+	if account.IsBlocked() || account.PendingVerification {
+		return nil, types.OAuthError{Code: "invalid_grant", Description: "Account cannot login"}
+	}
+	return account, nil
+}
+
+//issueTokens - creates access, refresh and id tokens for an account
+func (auth Authenticate) issueTokens(client *types.OAuthClient, account *types.Account, scope string, nonce string) (*types.TokenResponse, error) {
+	om := manager.OAuthManager{}
+
+	access, _, err := om.CreateToken("access", client.ID, account.ID, scope, auth.Config.OAuth.AccessTokenTTL, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	refresh, _, err := om.CreateToken("refresh", client.ID, account.ID, scope, auth.Config.OAuth.RefreshTokenTTL, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	response := types.TokenResponse{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    auth.Config.OAuth.AccessTokenTTL,
+		RefreshToken: refresh,
+		Scope:        scope,
+	}
+
+	//Only openid requests get an id token
+	if utils.Contains("openid", strings.Fields(scope)) {
+		now := time.Now()
+		claims := auth.accountClaims(account, scope)
+		claims["iss"] = auth.Config.OAuth.Issuer
+		claims["aud"] = client.ID
+		claims["iat"] = now.Unix()
+		claims["exp"] = now.Add(time.Duration(auth.Config.OAuth.AccessTokenTTL) * time.Second).Unix()
+		if nonce != "" {
+			claims["nonce"] = nonce
+		}
+		response.IDToken, err = auth.Signer.Sign(claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &response, nil
+}
+
+//accountClaims - returns the account claims allowed by the scope
+func (auth Authenticate) accountClaims(account *types.Account, scope string) map[string]interface{} {
+	scopes := strings.Fields(scope)
+	claims := map[string]interface{}{"sub": account.ID}
+	if utils.Contains("profile", scopes) {
+		claims["name"] = account.Name
+		claims["preferred_username"] = account.UserName
+	}
+	if utils.Contains("email", scopes) {
+		claims["email"] = account.Email
+	}
+	if utils.Contains("phone", scopes) {
+		claims["phone_number"] = account.Phone
+	}
+	return claims
+}
+
+//UserInfo - returns the claims of the account the access token was issued for
+func (auth Authenticate) UserInfo(accessToken string) (map[string]interface{}, error) {
+	token, err := manager.OAuthManager{}.GetToken(accessToken, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.Type != "access" || !token.HasScope("openid") {
+		return nil, types.OAuthError{Code: "invalid_token", Description: "Invalid or expired access token"}
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByID(token.AccountID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, types.OAuthError{Code: "invalid_token", Description: "Account no longer exists"}
+	}
+	if account.IsBlocked() {
+		return nil, types.OAuthError{Code: "invalid_token", Description: "Account is " + account.Status}
+	}
+
+	return auth.accountClaims(account, token.Scope), nil
+}
+
+//Introspect - returns the state of an oauth or session token to a confidential client
+func (auth Authenticate) Introspect(request *types.TokenLookupRequest) (*types.IntrospectionResponse, error) {
+	client, err := auth.authenticateClient(request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if client.IsPublic() {
+		return nil, types.OAuthError{Code: "invalid_client", Description: "Public clients cannot introspect tokens"}
+	}
+
+	inactive := &types.IntrospectionResponse{Active: false}
+	if request.Token == "" {
+		return inactive, nil
+	}
+
+	token, err := manager.OAuthManager{}.GetToken(request.Token, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if token != nil {
+		response := types.IntrospectionResponse{
+			Active:    true,
+			Scope:     token.Scope,
+			ClientID:  token.ClientID,
+			TokenType: token.Type,
+			Exp:       token.Expires.Unix(),
+			Iat:       token.Created.Unix(),
+			Iss:       auth.Config.OAuth.Issuer,
+			Sub:       token.ClientID,
+		}
+		//Tokens from the client credentials grant have no account
+		if token.AccountID != "" {
+			account, err := manager.AccountManager{}.GetAccountByID(token.AccountID, auth.DB)
+			if err != nil {
+				return nil, err
+			}
+			if account == nil || account.IsBlocked() {
+				return inactive, nil
+			}
+			err = manager.RoleManager{}.LoadAccountRoles(account, auth.DB)
+			if err != nil {
+				return nil, err
+			}
+			response.Sub = account.ID
+			response.UserName = account.UserName
+			response.Roles = account.Roles
+		}
+		return &response, nil
+	}
+
+	//Not an oauth token, check it the same way a session cookie is checked
+	account, err := auth.CheckAccountSession(&types.Session{Token: request.Token, Device: request.DeviceID})
+	if err != nil {
+		return inactive, nil
+	}
+
+	return &types.IntrospectionResponse{
+		Active:    true,
+		TokenType: "session",
+		Iss:       auth.Config.OAuth.Issuer,
+		Sub:       account.ID,
+		UserName:  account.UserName,
+		Roles:     account.Roles,
+	}, nil
+}
+
+//Revoke - revokes an oauth or session token. Unknown tokens are ignored
+func (auth Authenticate) Revoke(request *types.TokenLookupRequest) error {
+	client, err := auth.authenticateClient(request.ClientID, request.ClientSecret)
+	if err != nil {
+		return err
+	}
+	if request.Token == "" {
+		return nil
+	}
+
+	om := manager.OAuthManager{}
+	token, err := om.GetToken(request.Token, auth.DB)
+	if err != nil {
+		return err
+	}
+	if token != nil {
+		if token.ClientID != client.ID {
+			return types.OAuthError{Code: "unauthorized_client", Description: "Token was not issued to this client"}
+		}
+		//Revoking a refresh token also revokes the access tokens from the same grant
+		if token.Type == "refresh" {
+			return om.DeleteAccountTokens(client.ID, token.AccountID, auth.DB)
+		}
+		return om.DeleteToken(token, auth.DB)
+	}
+
+	//Session tokens belong to the user so only trusted clients can revoke them
+	if !client.HasScope(types.ScopeSessionsRevoke) {
+		return types.OAuthError{Code: "unsupported_token_type", Description: "Client cannot revoke session tokens"}
+	}
+
+	am := manager.AccountManager{}
+	account, err := am.GetAccountSession(&types.Session{Token: request.Token}, auth.DB, auth.Cache)
+	if err != nil {
+		return nil
+	}
+
+	return am.RevokeSession(account, auth.DB, auth.Cache)
+}
+
+//EndSession - logs out the session for a relying party. Returns where to send the user afterwards
+func (auth Authenticate) EndSession(session *types.Session, request *types.EndSessionRequest) (string, error) {
+	redirect := ""
+
+	if request.PostLogoutRedirectURI != "" {
+		//A redirect back to the client is only allowed if the client can be identified
+		claims, err := auth.Signer.Verify(request.IDTokenHint)
+		if err != nil {
+			return "", err
+		}
+		if claims["iss"] != auth.Config.OAuth.Issuer {
+			return "", errors.New("id_token_hint was not issued by this provider")
+		}
+		clientID, _ := claims["aud"].(string)
+		client, err := manager.OAuthManager{}.GetClient(clientID, auth.DB)
+		if err != nil {
+			return "", err
+		}
+		if client == nil || !client.HasLogoutURI(request.PostLogoutRedirectURI) {
+			return "", errors.New("Post logout redirect uri not registered: " + request.PostLogoutRedirectURI)
+		}
+		redirect = request.PostLogoutRedirectURI
+	}
+
+	if err := auth.Logout(session); err != nil {
+		return "", err
+	}
+
+	return redirect, nil
+}
+
+//OpenIDConfiguration - returns the discovery document
+func (auth Authenticate) OpenIDConfiguration() *types.OpenIDConfiguration {
+	issuer := auth.Config.OAuth.Issuer
+	return &types.OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserInfoEndpoint:                  issuer + "/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		EndSessionEndpoint:                issuer + "/oauth/logout",
+		IntrospectionEndpoint:             issuer + "/oauth/introspect",
+		RevocationEndpoint:                issuer + "/oauth/revoke",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		ScopesSupported:                   []string{"openid", "profile", "email", "phone"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "nonce", "name", "preferred_username", "email", "phone_number"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	}
+}
+
+//CreateClient - registers a new oauth client (clients:write)
+func (auth Authenticate) CreateClient(session *types.Session, request *types.CreateClientRequest) (*types.OAuthClient, string, error) {
+	_, err := auth.checkPermission(session, types.ScopeClientsWrite)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if request.Name == "" {
+		return nil, "", errors.New("Client requires a name")
+	}
+
+	//Default to a relying party
+	if len(request.GrantTypes) == 0 {
+		request.GrantTypes = []string{"authorization_code", "refresh_token"}
+	}
+	for _, grantType := range request.GrantTypes {
+		switch grantType {
+		case "authorization_code":
+			if len(request.RedirectURIs) == 0 {
+				return nil, "", errors.New("Client requires a redirect uri: " + request.Name)
+			}
+		case "client_credentials":
+			if request.Public {
+				return nil, "", errors.New("Machine clients cannot be public: " + request.Name)
+			}
+		case "refresh_token":
+		default:
+			return nil, "", errors.New("Unsupported grant type: " + grantType)
+		}
+	}
+	for _, scope := range request.Scopes {
+		if !utils.Contains(scope, types.AdminScopes) {
+			return nil, "", errors.New("Unknown scope: " + scope)
+		}
+	}
+
+	return manager.OAuthManager{}.CreateClient(request, auth.DB)
+}
+
+//RotateClientSecret - replaces the secret of a client and removes its tokens (clients:write)
+func (auth Authenticate) RotateClientSecret(session *types.Session, request *types.ClientRequest) (*types.OAuthClient, string, error) {
+	_, err := auth.checkPermission(session, types.ScopeClientsWrite)
+	if err != nil {
+		return nil, "", err
+	}
+
+	om := manager.OAuthManager{}
+	client, err := om.GetClient(request.ID, auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+	if client == nil || client.Revoked {
+		return nil, "", errors.New("No client was found: " + request.ID)
+	}
+	if client.IsPublic() {
+		return nil, "", errors.New("Public clients have no secret: " + client.Name)
+	}
+
+	secret, err := om.RotateSecret(client, auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+
+	//Tokens issued with the old secret are no longer trusted
+	err = om.DeleteClientTokens(client, auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return client, secret, nil
+}
+
+//RevokeClient - permanently disables a client (clients:write)
+func (auth Authenticate) RevokeClient(session *types.Session, request *types.ClientRequest) error {
+	_, err := auth.checkPermission(session, types.ScopeClientsWrite)
+	if err != nil {
+		return err
+	}
+
+	om := manager.OAuthManager{}
+	client, err := om.GetClient(request.ID, auth.DB)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return errors.New("No client was found: " + request.ID)
+	}
+
+	return om.RevokeClient(client, auth.DB)
+}
+
+//GetAllClients - returns all oauth clients (clients:write)
+func (auth Authenticate) GetAllClients(session *types.Session) (*[]types.OAuthClient, error) {
+	_, err := auth.checkPermission(session, types.ScopeClientsWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.OAuthManager{}.GetAllClients(auth.DB)
+}
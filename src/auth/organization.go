@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"types"
+)
+
+//orgBySlug - returns an organization by slug. Errors if it does not exist
+func (auth Authenticate) orgBySlug(slug string) (*types.Organization, error) {
+	org, err := manager.OrgManager{}.GetOrgBySlug(slug, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, errors.New("No organization found: " + slug)
+	}
+	return org, nil
+}
+
+//enterOrg - makes an organization the active one of an account. The account must be a member. Empty leaves every organization
+func (auth Authenticate) enterOrg(account *types.Account, orgID string) error {
+	if orgID != "" {
+		member, err := manager.OrgManager{}.GetMember(orgID, account.ID, auth.DB)
+		if err != nil {
+			return err
+		}
+		if member == nil {
+			return errors.New("Not a member of organization: " + account.Name)
+		}
+	}
+	return manager.AccountManager{}.SetActiveOrg(account, orgID, auth.DB, auth.Cache)
+}
+
+//checkAccountsPermission - returns the account making an account admin request and the organization it is limited to.
+//Sessions with an active organization are limited to it and its admins can manage its accounts.
+//Machine clients, tokens and sessions without an organization need the permission and are not limited
+func (auth Authenticate) checkAccountsPermission(session *types.Session, scope string) (*types.Account, string, error) {
+	if session.Token == "" {
+		account, err := auth.checkPermission(session, scope)
+		return account, "", err
+	}
+
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if account.ActiveOrg == "" {
+		if !account.Can(scope) {
+			return nil, "", errors.New("Invalid Privilges: " + account.Name + " missing " + scope)
+		}
+		return account, "", nil
+	}
+
+	member, err := manager.OrgManager{}.GetMember(account.ActiveOrg, account.ID, auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+	if member == nil {
+		return nil, "", errors.New("Not a member of organization: " + account.Name)
+	}
+	if member.Role < types.LevelAdmin && !account.Can(scope) {
+		return nil, "", errors.New("Invalid Privilges: " + account.Name + " missing " + scope)
+	}
+	account.OrgRole = member.Role
+
+	return account, account.ActiveOrg, nil
+}
+
+//GetOrgs - returns the organizations of the session account
+func (auth Authenticate) GetOrgs(session *types.Session) (*[]types.Organization, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.OrgManager{}.GetAccountOrgs(account, auth.DB)
+}
+
+//CreateOrg - creates an organization with the session account as its admin
+func (auth Authenticate) CreateOrg(session *types.Session, request *types.OrgRequest) (string, *types.Organization, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", nil, err
+	}
+	if !account.Can(types.ScopeOrgsWrite) {
+		return "", nil, errors.New("Invalid Privilges: " + account.Name + " missing " + types.ScopeOrgsWrite)
+	}
+
+	org := types.Organization{Name: request.Name, Slug: request.Slug}
+	if org.Name == "" {
+		return "Organization name is required", nil, nil
+	}
+	if err := org.CheckSlug(); err != nil {
+		return err.Error(), nil, nil
+	}
+
+	res, err := manager.OrgManager{}.CreateOrg(&org, account, auth.DB)
+	if err != nil || res != "" {
+		return res, nil, err
+	}
+
+	return "", &org, nil
+}
+
+//SwitchOrg - changes the active organization of the session. An empty id leaves every organization
+func (auth Authenticate) SwitchOrg(session *types.Session, request *types.OrgRequest) error {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return err
+	}
+
+	return auth.enterOrg(account, request.ID)
+}
+
+//AddOrgMember - adds an account to the active organization or changes its role there
+func (auth Authenticate) AddOrgMember(session *types.Session, request *types.OrgMemberRequest) (string, error) {
+	actor, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return "", err
+	}
+	if orgID == "" {
+		return "Switch to an organization first", nil
+	}
+	if request.Role > actor.OrgRole {
+		return "Cannot give a higher role than your own", nil
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByID(request.AccountID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if account == nil || account.IsService() {
+		return "", errors.New("No account found: " + request.AccountID)
+	}
+
+	//Existing members must pass the policies before their role changes
+	member, err := manager.OrgManager{}.GetMember(orgID, account.ID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if member != nil {
+		res, err := auth.checkPolicy(types.ScopeAccountsWrite, actor, account, &types.Account{ID: account.ID, Role: request.Role}, orgID)
+		if err != nil || res != "" {
+			return res, err
+		}
+	}
+
+	//The username and email must be free in the organization
+	if member == nil && auth.Config.Orgs.UniquePerOrg {
+		account.OrgUnique = true
+		account.ActiveOrg = orgID
+		res, err := manager.AccountManager{}.CheckDuplicates(account, auth.DB)
+		if err != nil || res != "" {
+			return res, err
+		}
+	}
+
+	err = manager.OrgManager{}.AddMember(&types.OrgMember{OrgID: orgID, AccountID: account.ID, Role: request.Role}, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//RemoveOrgMember - removes an account from the active organization. The account is kept
+func (auth Authenticate) RemoveOrgMember(session *types.Session, request *types.OrgMemberRequest) (string, error) {
+	actor, orgID, err := auth.checkAccountsPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return "", err
+	}
+	if orgID == "" {
+		return "Switch to an organization first", nil
+	}
+	if request.AccountID == actor.ID {
+		return "Cannot remove yourself", nil
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByID(request.AccountID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if account == nil {
+		return "", errors.New("No account found: " + request.AccountID)
+	}
+
+	res, err := auth.checkPolicy(types.ScopeAccountsWrite, actor, account, nil, orgID)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	err = manager.OrgManager{}.RemoveMember(orgID, account.ID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
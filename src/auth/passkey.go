@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"manager"
+	"time"
+	"types"
+	"utils"
+	"webauthn"
+)
+
+//passkeyState - saves the challenge of a passkey registration or login as a pending login.
+//If accountID is set the passkey is registered to that account
+func (auth Authenticate) passkeyState(accountID string) (*types.FederatedState, error) {
+	if auth.Passkeys == nil {
+		return nil, errors.New("Passkeys are not enabled")
+	}
+
+	//The state and challenge must not be guessable
+	var secrets [2]string
+	for i := range secrets {
+		secret, err := utils.SecureString()
+		if err != nil {
+			return nil, err
+		}
+		secrets[i] = secret
+	}
+	state := types.FederatedState{
+		ID:        secrets[0],
+		Provider:  types.PasskeyProvider,
+		Nonce:     secrets[1],
+		AccountID: accountID,
+		Created:   time.Now(),
+	}
+	err := manager.IdentityManager{}.CreateState(&state, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+//consumePasskeyState - returns the pending passkey registration or login of a state and removes it
+func (auth Authenticate) consumePasskeyState(id string, accountID string) (*types.FederatedState, error) {
+	if auth.Passkeys == nil {
+		return nil, errors.New("Passkeys are not enabled")
+	}
+
+	state, err := manager.IdentityManager{}.ConsumeState(id, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.Provider != types.PasskeyProvider || state.AccountID != accountID || time.Since(state.Created) > federatedStateTimeout {
+		return nil, errors.New("Invalid or expired passkey state")
+	}
+	return state, nil
+}
+
+//PasskeyRegistrationOptions - starts adding a passkey to the session account. The account must re-authenticate first.
+//Returns the state to send back with the new credential and the options for the browser
+func (auth Authenticate) PasskeyRegistrationOptions(session *types.Session, request *types.LinkIdentityRequest) (string, *types.PasskeyOptions, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := auth.reauthenticate(account, request.Password); err != nil {
+		return "", nil, err
+	}
+
+	identities, err := manager.IdentityManager{}.GetAccountIdentities(account, auth.DB)
+	if err != nil {
+		return "", nil, err
+	}
+	exclude := []string{}
+	for _, identity := range *identities {
+		if identity.Type == types.IdentityPasskey {
+			exclude = append(exclude, identity.Subject)
+		}
+	}
+
+	state, err := auth.passkeyState(account.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return state.ID, auth.Passkeys.CreationOptions(state.Nonce, account, exclude), nil
+}
+
+//RegisterPasskey - links the passkey created with PasskeyRegistrationOptions to the session account
+func (auth Authenticate) RegisterPasskey(session *types.Session, credential *types.PasskeyCredential) (string, error) {
+	im := manager.IdentityManager{}
+
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", err
+	}
+
+	//The registration must be finished by the account that started it
+	state, err := auth.consumePasskeyState(credential.State, account.ID)
+	if err != nil {
+		return "", err
+	}
+
+	created, err := auth.Passkeys.VerifyRegistration(state.Nonce, credential)
+	if err != nil {
+		return "", err
+	}
+
+	identity, err := im.GetIdentity(types.PasskeyProvider, created.ID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if identity != nil {
+		return "Passkey is already linked", nil
+	}
+
+	err = im.CreateIdentity(&types.Identity{
+		AccountID: account.ID,
+		Type:      types.IdentityPasskey,
+		Provider:  types.PasskeyProvider,
+		Subject:   created.ID,
+		Email:     account.Email,
+		PublicKey: created.PublicKey,
+		SignCount: created.SignCount,
+	}, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//PasskeyLoginOptions - starts a login with a passkey.
+//Returns the state to send back with the signed credential and the options for the browser
+func (auth Authenticate) PasskeyLoginOptions() (string, *types.PasskeyOptions, error) {
+	state, err := auth.passkeyState("")
+	if err != nil {
+		return "", nil, err
+	}
+
+	return state.ID, auth.Passkeys.RequestOptions(state.Nonce), nil
+}
+
+//PasskeyLogin - finishes a login with a passkey and starts a session.
+//Returns the account and its device if one needs verifying
+func (auth Authenticate) PasskeyLogin(credential *types.PasskeyCredential, session *types.Session) (*types.Account, *types.Device, error) {
+	im := manager.IdentityManager{}
+
+	state, err := auth.consumePasskeyState(credential.State, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	identity, err := im.GetIdentity(types.PasskeyProvider, credential.ID, auth.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+	if identity == nil || identity.Type != types.IdentityPasskey {
+		return nil, nil, errors.New("Unknown passkey: " + credential.ID)
+	}
+
+	//The browser returns the user id the passkey was created with
+	if credential.UserHandle != "" && credential.UserHandle != base64.RawURLEncoding.EncodeToString([]byte(identity.AccountID)) {
+		return nil, nil, errors.New("Passkey belongs to another account: " + credential.ID)
+	}
+
+	stored := webauthn.Credential{ID: identity.Subject, PublicKey: identity.PublicKey, SignCount: identity.SignCount}
+	signCount, err := auth.Passkeys.VerifyAssertion(state.Nonce, &stored, credential)
+	if err != nil {
+		return nil, nil, err
+	}
+	identity.SignCount = signCount
+	err = im.UpdateSignCount(identity, auth.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByID(identity.AccountID, auth.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+	if account == nil {
+		return nil, nil, errors.New("Linked account no longer exists: " + identity.AccountID)
+	}
+
+	return auth.startSession(account, session)
+}
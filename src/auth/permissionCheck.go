@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"types"
+)
+
+//CheckPermission - decides if a session can do a permission on a resource. Rules are checked in order:
+//machine clients need the scope, resources owned by the account are allowed,
+//personal access tokens need the scope and otherwise a role must give the permission
+func (auth Authenticate) CheckPermission(session *types.Session, request *types.PermissionCheckRequest) (*types.PermissionDecision, error) {
+	if request.Permission == "" {
+		return nil, errors.New("Permission is required")
+	}
+
+	//Machine clients have no account so only their scopes count
+	if session.Token == "" && session.Bearer != "" && !types.IsPersonalToken(session.Bearer) {
+		client, err := auth.checkClientScope(session.Bearer, request.Permission)
+		if err != nil {
+			return &types.PermissionDecision{Rule: types.RuleSession}, nil
+		}
+		return &types.PermissionDecision{Allowed: true, Rule: types.RuleClient, AccountID: client.ID}, nil
+	}
+
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return &types.PermissionDecision{Rule: types.RuleSession}, nil
+	}
+
+	if ownerID, ok := request.Resource["ownerId"]; ok && ownerID == account.ID {
+		return &types.PermissionDecision{Allowed: true, Rule: types.RuleOwner, AccountID: account.ID}, nil
+	}
+
+	//Personal access tokens only get the permissions they were given
+	if session.Token == "" {
+		token, err := manager.PersonalTokenManager{}.GetToken(session.Bearer, auth.DB)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil || !token.HasScope(request.Permission) {
+			return &types.PermissionDecision{Rule: types.RuleScope, AccountID: account.ID}, nil
+		}
+	}
+
+	roles, err := manager.RoleManager{}.GetPermissionRoles(account, request.Permission, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) > 0 {
+		return &types.PermissionDecision{Allowed: true, Rule: types.RuleRole + ":" + roles[0], AccountID: account.ID}, nil
+	}
+
+	return &types.PermissionDecision{Rule: types.RuleDefault, AccountID: account.ID}, nil
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"types"
+	"utils"
+)
+
+//Longest a personal access token can last (Days)
+const maxPersonalTokenDays = 365
+
+//accountScopes - returns the scopes an account can give its personal access tokens. Its roles must be loaded first
+func accountScopes(account *types.Account) []string {
+	return append([]string{types.ScopeAccount}, account.Permissions...)
+}
+
+//checkPersonalToken - returns the account of a personal access token that was granted the scope
+func (auth Authenticate) checkPersonalToken(plain string, scope string) (*types.Account, error) {
+	pm := manager.PersonalTokenManager{}
+
+	token, err := pm.GetToken(plain, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, errors.New("Invalid personal access token")
+	}
+	if !token.HasScope(scope) {
+		return nil, errors.New("Personal access token missing scope: " + scope)
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByID(token.AccountID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("No account was found for personal access token: " + token.AccountID)
+	}
+	if account.IsBlocked() {
+		return nil, errors.New("Account is " + account.Status + ": " + account.Name)
+	}
+	err = manager.RoleManager{}.LoadAccountRoles(account, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	//Admin scopes stop working if the account loses the permission
+	if !utils.Contains(scope, accountScopes(account)) {
+		return nil, errors.New("Account can no longer use scope: " + scope)
+	}
+
+	//If this fails the token still works
+	_ = pm.UpdateLastUsed(token, auth.DB)
+
+	return account, nil
+}
+
+//checkTokenSession - returns the account of a session that can manage personal access tokens.
+//Tokens cannot be used to manage tokens
+func (auth Authenticate) checkTokenSession(session *types.Session) (*types.Account, error) {
+	if session.Token == "" {
+		return nil, errors.New("Personal access tokens must be managed with a session")
+	}
+	return auth.CheckAccountSession(session)
+}
+
+//tokenAccount - returns the account whose tokens are being managed.
+//Owners and accounts that can write accounts can manage the tokens of a service account
+func (auth Authenticate) tokenAccount(account *types.Account, accountID string) (*types.Account, error) {
+	if accountID == "" || accountID == account.ID {
+		return account, nil
+	}
+
+	service, err := manager.AccountManager{}.GetAccountByID(accountID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+	if service == nil || !service.IsService() {
+		return nil, errors.New("No service account found: " + accountID)
+	}
+	if service.OwnerID != account.ID && !account.Can(types.ScopeAccountsWrite) {
+		return nil, errors.New("Not the owner of service account: " + service.Name)
+	}
+	err = manager.RoleManager{}.LoadAccountRoles(service, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+//GetPersonalTokens - returns the personal access tokens of the session account or a service account it owns
+func (auth Authenticate) GetPersonalTokens(session *types.Session, request *types.PersonalTokenRequest) (*[]types.PersonalToken, error) {
+	account, err := auth.checkTokenSession(session)
+	if err != nil {
+		return nil, err
+	}
+	account, err = auth.tokenAccount(account, request.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := manager.PersonalTokenManager{}.GetAccountTokens(account, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+//CreatePersonalToken - creates a personal access token for the session account or a service account it owns.
+//Returns a reason if the request is invalid, the plain token and the saved token
+func (auth Authenticate) CreatePersonalToken(session *types.Session, request *types.PersonalTokenRequest) (string, string, *types.PersonalToken, error) {
+	account, err := auth.checkTokenSession(session)
+	if err != nil {
+		return "", "", nil, err
+	}
+	account, err = auth.tokenAccount(account, request.AccountID)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if request.Name == "" {
+		return "Token name is required", "", nil, nil
+	}
+	if request.ExpiresIn < 1 || request.ExpiresIn > maxPersonalTokenDays {
+		return "Token must expire within 1 to 365 days", "", nil, nil
+	}
+	if len(request.Scopes) == 0 {
+		return "Token needs at least one scope", "", nil, nil
+	}
+	allowed := accountScopes(account)
+	for _, scope := range request.Scopes {
+		if !utils.Contains(scope, allowed) {
+			return "Invalid scope: " + scope, "", nil, nil
+		}
+	}
+
+	plain, token, err := manager.PersonalTokenManager{}.CreateToken(account, request, auth.DB)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	return "", plain, token, nil
+}
+
+//RevokePersonalToken - removes a personal access token of the session account or a service account it owns
+func (auth Authenticate) RevokePersonalToken(session *types.Session, request *types.PersonalTokenRequest) error {
+	account, err := auth.checkTokenSession(session)
+	if err != nil {
+		return err
+	}
+	account, err = auth.tokenAccount(account, request.AccountID)
+	if err != nil {
+		return err
+	}
+
+	found, err := manager.PersonalTokenManager{}.DeleteToken(account, request.ID, auth.DB)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("No personal access token found: " + request.ID)
+	}
+
+	return nil
+}
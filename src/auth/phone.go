@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"manager"
+	"time"
+	"types"
+)
+
+//How long a phone verification code can be used for and how many wrong codes are allowed
+const (
+	phoneVerificationTimeout  = 10 * time.Minute
+	phoneVerificationAttempts = 5
+)
+
+//phoneResendWait - how long an account waits before another phone code is sent. Defaults to a minute
+func (auth Authenticate) phoneResendWait() time.Duration {
+	if auth.Config.Phones.ResendWait <= 0 {
+		return time.Minute
+	}
+	return time.Duration(auth.Config.Phones.ResendWait) * time.Minute
+}
+
+//phoneCodeSentRecently - checks if a phone code was sent to the account within the resend wait
+func (auth Authenticate) phoneCodeSentRecently(account *types.Account) (bool, error) {
+	last, err := manager.PhoneManager{}.GetVerification(account, auth.DB)
+	if err != nil {
+		return false, err
+	}
+	return last != nil && time.Since(last.Created) < auth.phoneResendWait(), nil
+}
+
+//checkPhone - normalizes the phone of an account to E.164. Returns a reason if it is invalid or another account has it when phones are unique
+func (auth Authenticate) checkPhone(account *types.Account) (string, error) {
+	phone, err := types.NormalizePhone(account.Phone, auth.Config.Phones.CountryCode)
+	if err != nil {
+		return err.Error(), nil
+	}
+	account.Phone = phone
+
+	return auth.checkPhoneTaken(account.Phone, account.ID)
+}
+
+//checkPhoneTaken - returns a reason if phones are unique and another account has the phone
+func (auth Authenticate) checkPhoneTaken(phone string, accountID string) (string, error) {
+	if !auth.Config.Phones.Unique || phone == "" {
+		return "", nil
+	}
+
+	taken, err := manager.PhoneManager{}.PhoneTaken(phone, accountID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "Phone is taken: " + phone, nil
+	}
+	return "", nil
+}
+
+//syncedPhone - returns the phone an account gets from a directory or identity provider, normalized to E.164.
+//Numbers that cannot be normalized or that another account has when phones are unique keep the current phone
+func (auth Authenticate) syncedPhone(account *types.Account, phone string) (string, error) {
+	normalized, err := types.NormalizePhone(phone, auth.Config.Phones.CountryCode)
+	if err != nil {
+		return account.Phone, nil
+	}
+	if normalized == "" || normalized == account.Phone {
+		return normalized, nil
+	}
+
+	res, err := auth.checkPhoneTaken(normalized, account.ID)
+	if err != nil {
+		return "", err
+	}
+	if res != "" {
+		return account.Phone, nil
+	}
+	return normalized, nil
+}
+
+//changePhone - keeps a new phone pending until the code sent to it is confirmed.
+//Returns the verification to send, nil if the phone did not change or a code was sent within the resend wait
+func (auth Authenticate) changePhone(account *types.Account, phone string) (*types.PhoneVerification, error) {
+	pm := manager.PhoneManager{}
+
+	if phone == account.PendingPhone {
+		return nil, nil
+	}
+	//Going back to the current phone cancels the change
+	if phone == account.Phone {
+		return nil, pm.SetPendingPhone(account, "", auth.DB, auth.Cache)
+	}
+
+	err := pm.SetPendingPhone(account, phone, auth.DB, auth.Cache)
+	if err != nil {
+		return nil, err
+	}
+
+	//The code for the new phone can be requested once the wait is over
+	recent, err := auth.phoneCodeSentRecently(account)
+	if err != nil || recent {
+		return nil, err
+	}
+	return pm.CreateVerification(account, phone, auth.DB)
+}
+
+//RequestPhoneVerification - creates a code to verify the pending or current phone of the session account. Returns the account and the code to send.
+//Another code can only be requested once the resend wait is over
+func (auth Authenticate) RequestPhoneVerification(session *types.Session) (*types.Account, *types.PhoneVerification, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//A new phone is confirmed before it replaces the current one
+	phone := account.PendingPhone
+	if phone == "" {
+		if account.Phone == "" {
+			return nil, nil, errors.New("Account has no phone: " + account.Name)
+		}
+		if account.PhoneVerified {
+			return nil, nil, errors.New("Phone already verified: " + account.Name)
+		}
+		phone = account.Phone
+	}
+
+	recent, err := auth.phoneCodeSentRecently(account)
+	if err != nil {
+		return nil, nil, err
+	}
+	if recent {
+		return nil, nil, errors.New("Phone code sent too recently: " + account.Name)
+	}
+
+	verification, err := manager.PhoneManager{}.CreateVerification(account, phone, auth.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return account, verification, nil
+}
+
+//VerifyPhone - checks the code sent to the phone of the session account. A pending phone replaces the current one once confirmed.
+//Returns a reason if the code is wrong
+func (auth Authenticate) VerifyPhone(session *types.Session, request *types.PhoneVerificationRequest) (string, error) {
+	pm := manager.PhoneManager{}
+
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", err
+	}
+
+	verification, err := pm.GetVerification(account, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if verification == nil || time.Since(verification.Created) > phoneVerificationTimeout {
+		return "Invalid or expired code", nil
+	}
+
+	phone := account.Phone
+	if account.PendingPhone != "" {
+		phone = account.PendingPhone
+	}
+
+	//The phone was changed after the code was sent
+	if verification.Phone != phone {
+		if err := pm.DeleteVerification(verification, auth.DB); err != nil {
+			return "", err
+		}
+		return "Invalid or expired code", nil
+	}
+	//Too many wrong codes were tried. The code is kept so a new one still waits for the resend wait
+	if verification.Attempts >= phoneVerificationAttempts {
+		return "Invalid or expired code", nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(verification.Code), []byte(request.Code)) != 1 {
+		if err := pm.AddAttempt(verification, auth.DB); err != nil {
+			return "", err
+		}
+		return "Invalid code", nil
+	}
+
+	if account.PendingPhone != "" {
+		//Another account may have taken the phone since the code was sent
+		res, err := auth.checkPhoneTaken(account.PendingPhone, account.ID)
+		if err != nil || res != "" {
+			return res, err
+		}
+		err = pm.ConfirmPendingPhone(account, auth.DB, auth.Cache)
+	} else {
+		err = manager.AccountManager{}.SetPhoneVerified(account, auth.DB, auth.Cache)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	//If this fails it will expire. The phone is already verified.
+	_ = pm.DeleteVerification(verification, auth.DB)
+
+	return "", nil
+}
+
+//SetDeliveryChannel - sets where device codes are sent for the session account.
+//Returns a reason if the channel cannot be used
+func (auth Authenticate) SetDeliveryChannel(session *types.Session, request *types.DeliveryChannelRequest) (string, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", err
+	}
+
+	switch request.Channel {
+	case types.ChannelEmail:
+	case types.ChannelSMS:
+		if !account.PhoneVerified {
+			return "Phone must be verified to receive codes by SMS", nil
+		}
+	default:
+		return "Invalid delivery channel: " + request.Channel, nil
+	}
+
+	err = manager.AccountManager{}.SetDeliveryChannel(account, request.Channel, auth.DB, auth.Cache)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//NormalizePhones - converts phones stored before they were kept in E.164 format. Prints the phones that cannot be converted
+func (auth Authenticate) NormalizePhones() error {
+	failed, err := manager.PhoneManager{}.NormalizePhones(auth.Config.Phones.CountryCode, auth.DB, auth.Cache)
+	if err != nil {
+		return err
+	}
+	for id, phone := range failed {
+		fmt.Println("Cannot normalize phone of account " + id + ": " + phone)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"regexp"
+	"strings"
+	"types"
+	"utils"
+)
+
+//checkRole - returns a reason if the name, rank or permissions of a role are invalid
+func (auth Authenticate) checkRole(role *types.Role) (string, error) {
+	rm := manager.RoleManager{}
+
+	if !regexp.MustCompile(`^[A-Za-z0-9_-]{2,50}$`).MatchString(role.Name) {
+		return "Role name must be 2 to 50 letters, digits, _ or -", nil
+	}
+	if role.Rank < types.LevelDefault || role.Rank > types.LevelAdmin {
+		return "Role rank must be 0 to 999", nil
+	}
+
+	permissions, err := rm.GetPermissions(auth.DB)
+	if err != nil {
+		return "", err
+	}
+	known := []string{}
+	for _, permission := range *permissions {
+		known = append(known, permission.ID)
+	}
+	for _, permission := range role.Permissions {
+		if !utils.Contains(permission, known) {
+			return "Invalid permission: " + permission, nil
+		}
+	}
+
+	return rm.CheckDuplicateRole(role, auth.DB)
+}
+
+//GetRoles - returns every role with its permissions
+func (auth Authenticate) GetRoles(session *types.Session) (*[]types.Role, error) {
+	_, err := auth.checkPermission(session, types.ScopeRolesRead)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.RoleManager{}.GetRoles(auth.DB)
+}
+
+//GetPermissions - returns every permission a role can give
+func (auth Authenticate) GetPermissions(session *types.Session) (*[]types.Permission, error) {
+	_, err := auth.checkPermission(session, types.ScopeRolesRead)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.RoleManager{}.GetPermissions(auth.DB)
+}
+
+//CreateRole - creates a role. Returns a reason if the role is invalid
+func (auth Authenticate) CreateRole(session *types.Session, role *types.Role) (string, error) {
+	_, err := auth.checkPermission(session, types.ScopeRolesWrite)
+	if err != nil {
+		return "", err
+	}
+
+	role.ID = ""
+	res, err := auth.checkRole(role)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	err = manager.RoleManager{}.CreateRole(role, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//UpdateRole - updates the name, description, rank and permissions of a role. Built in roles cannot be renamed or ranked
+func (auth Authenticate) UpdateRole(session *types.Session, role *types.Role) (string, error) {
+	rm := manager.RoleManager{}
+
+	_, err := auth.checkPermission(session, types.ScopeRolesWrite)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := rm.GetRole(role.ID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if existing == nil {
+		return "", errors.New("No role found: " + role.ID)
+	}
+	if existing.IsBuiltIn() && existing.Name != role.Name {
+		return "Built in roles cannot be renamed", nil
+	}
+	if existing.IsBuiltIn() && existing.Rank != role.Rank {
+		return "Built in roles cannot be ranked", nil
+	}
+
+	res, err := auth.checkRole(role)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	err = rm.UpdateRole(role, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//DeleteRole - deletes a role and removes it from every account. Built in roles cannot be deleted
+func (auth Authenticate) DeleteRole(session *types.Session, request *types.RoleRequest) (string, error) {
+	rm := manager.RoleManager{}
+
+	_, err := auth.checkPermission(session, types.ScopeRolesWrite)
+	if err != nil {
+		return "", err
+	}
+
+	role, err := rm.GetRole(request.ID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if role == nil {
+		return "", errors.New("No role found: " + request.ID)
+	}
+	if role.IsBuiltIn() {
+		return "Built in roles cannot be deleted", nil
+	}
+
+	err = rm.DeleteRole(role, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//SetAccountRoles - replaces the roles of an account. Accounts cannot change their own roles.
+//The policies decide if the account and the roles given rank low enough for the actor
+func (auth Authenticate) SetAccountRoles(session *types.Session, request *types.AccountRolesRequest) (string, error) {
+	rm := manager.RoleManager{}
+
+	admin, err := auth.checkPermission(session, types.ScopeRolesWrite)
+	if err != nil {
+		return "", err
+	}
+	if admin.ID == request.AccountID {
+		return "Cannot change your own roles", nil
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByID(request.AccountID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if account == nil {
+		return "", errors.New("No account found: " + request.AccountID)
+	}
+
+	roles, err := rm.GetRoles(auth.DB)
+	if err != nil {
+		return "", err
+	}
+	known := []string{}
+	for _, role := range *roles {
+		known = append(known, role.Name)
+	}
+	for _, name := range request.Roles {
+		if !utils.Contains(name, known) {
+			return "Invalid role: " + name, nil
+		}
+	}
+
+	updated := &types.Account{ID: account.ID, Role: types.LegacyLevel(request.Roles), Roles: append([]string{}, request.Roles...)}
+	res, err := auth.checkPolicy(types.ScopeRolesWrite, admin, account, updated, "")
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	err = rm.SetAccountRoles(account, request.Roles, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	auth.audit(account.ID, admin.ID, types.AuditRolesChanged, strings.Join(request.Roles, " "), session)
+
+	return "", nil
+}
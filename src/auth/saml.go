@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"saml"
+	"time"
+	"types"
+	"utils"
+)
+
+//SAMLEntityID - entity id of this service. Also the url of its metadata
+func (auth Authenticate) SAMLEntityID() string {
+	return auth.Config.OAuth.Issuer + "/api/auth/saml/metadata"
+}
+
+//SAMLMetadata - returns the metadata saml identity providers use to register this service
+func (auth Authenticate) SAMLMetadata() ([]byte, error) {
+	return saml.Metadata(auth.SAMLEntityID(), auth.Config.OAuth.Issuer+"/api/auth/saml/acs")
+}
+
+//SAMLLoginRequest - starts a login with a saml identity provider. Returns the request to send the user with
+func (auth Authenticate) SAMLLoginRequest(name string, continueURL string) (*types.SAMLRequest, error) {
+	provider, ok := auth.SAML[name]
+	if !ok {
+		return nil, errors.New("Unknown identity provider: " + name)
+	}
+
+	//Request ids are also the relay state and must not be guessable. They must not start with a digit
+	secret, err := utils.SecureString()
+	if err != nil {
+		return nil, err
+	}
+	state := types.FederatedState{
+		ID:       "_" + secret,
+		Provider: name,
+		Continue: continueURL,
+		Created:  time.Now(),
+	}
+	err = manager.IdentityManager{}.CreateState(&state, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.Request(state.ID, state.ID)
+}
+
+//SAMLLogin - finishes a login with a saml identity provider and starts a session.
+//Returns the account, its device if one needs verifying and where the user wanted to go
+func (auth Authenticate) SAMLLogin(relayState string, response string, session *types.Session) (*types.Account, *types.Device, string, error) {
+	state, err := manager.IdentityManager{}.ConsumeState(relayState, auth.DB)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if state == nil || time.Since(state.Created) > federatedStateTimeout {
+		return nil, nil, "", errors.New("Invalid or expired login state: " + relayState)
+	}
+	provider, ok := auth.SAML[state.Provider]
+	if !ok {
+		return nil, nil, "", errors.New("Unknown identity provider: " + state.Provider)
+	}
+
+	assertion, err := provider.ParseResponse(response, state.ID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	//Map the attributes onto the claims used by openid connect providers
+	claims := map[string]interface{}{
+		"sub":                assertion.Subject,
+		"email":              assertion.Attribute(provider.Attribute("email")),
+		"email_verified":     provider.Config.TrustEmail,
+		"preferred_username": assertion.Attribute(provider.Attribute("userName")),
+		"name":               assertion.Attribute(provider.Attribute("name")),
+	}
+	role := provider.Role(assertion.Attributes[provider.Attribute("groups")])
+
+	account, err := auth.federatedAccount(state.Provider, role, claims)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	//Keep the account in sync with the provider. A missing phone keeps the current one
+	phone := account.Phone
+	if attribute := assertion.Attribute(provider.Attribute("phone")); attribute != "" {
+		phone, err = auth.syncedPhone(account, attribute)
+		if err != nil {
+			return nil, nil, "", err
+		}
+	}
+	if len(provider.Config.GroupRoles) == 0 {
+		role = account.Role
+	}
+	if account.Phone != phone || account.Role != role {
+		account.ChangePhone(phone)
+		account.Role = role
+		err = manager.AccountManager{}.SyncAccount(account, auth.DB)
+		if err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	account, device, err := auth.startSession(account, session)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return account, device, state.Continue, nil
+}
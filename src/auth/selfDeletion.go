@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"manager"
+	"time"
+	"types"
+)
+
+//deletionGrace - how long after a request an account deletes itself
+func (auth Authenticate) deletionGrace() time.Duration {
+	if auth.Config.Deletion.GraceDays <= 0 {
+		return 14 * 24 * time.Hour
+	}
+	return time.Duration(auth.Config.Deletion.GraceDays) * 24 * time.Hour
+}
+
+//confirmsWithCode - checks if an account confirms its deletion with a one-time code instead of its password
+func (auth Authenticate) confirmsWithCode(account *types.Account) bool {
+	return account.Password == "" && account.TwoFA
+}
+
+//deletionDevice - returns the active device of the session. Deletion codes are sent for it
+func (auth Authenticate) deletionDevice(session *types.Session, account *types.Account) (*types.Device, error) {
+	device, err := manager.DeviceManager{}.GetDevice(session, auth.DB, auth.Cache)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil || !device.Active || device.AccountID != account.ID {
+		return nil, errors.New("Deletion requires an active device: " + account.Name)
+	}
+	return device, nil
+}
+
+//confirmOwner - re-authenticates the account before deleting it.
+//Accounts with 2FA and no password give the one-time code sent by SendDeletionCode, others follow reauthenticate
+func (auth Authenticate) confirmOwner(session *types.Session, account *types.Account, request *types.SelfDeletionRequest) error {
+	if !auth.confirmsWithCode(account) {
+		return auth.reauthenticate(account, request.Password)
+	}
+
+	device, err := auth.deletionDevice(session, account)
+	if err != nil {
+		return err
+	}
+	valid, err := manager.DeviceManager{}.ConsumeCode(device, request.Code, auth.DB, auth.Cache)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("Invalid deletion code: " + account.Name)
+	}
+	return nil
+}
+
+//SendDeletionCode - gives the session device a fresh one-time code to confirm a deletion request.
+//Returns the account and device to send it to, or a reason if the account confirms with its password
+func (auth Authenticate) SendDeletionCode(session *types.Session) (string, *types.Account, *types.Device, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if session.Token == "" {
+		return "", nil, nil, errors.New("Account deletion requires a session: " + account.Name)
+	}
+
+	//The session account is cached without its password
+	full, err := manager.AccountManager{}.GetAccountByID(account.ID, auth.DB)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if full == nil {
+		return "", nil, nil, errors.New("No account found: " + account.ID)
+	}
+	if !auth.confirmsWithCode(full) {
+		return "Confirm the deletion with your password", nil, nil, nil
+	}
+
+	device, err := auth.deletionDevice(session, full)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	err = manager.DeviceManager{}.NewCode(device, auth.DB, auth.Cache)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return "", full, device, nil
+}
+
+//RequestDeletion - schedules the deletion of the requesting account after the grace period.
+//Returns the request and the plain token for its cancel link, or a reason if it cannot be requested
+func (auth Authenticate) RequestDeletion(session *types.Session, request *types.SelfDeletionRequest) (string, *types.DeletionRequest, string, error) {
+	drm := manager.DeletionRequestManager{}
+
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", nil, "", err
+	}
+	if session.Token == "" {
+		return "", nil, "", errors.New("Account deletion requires a session: " + account.Name)
+	}
+
+	//The session account is cached without its password
+	full, err := manager.AccountManager{}.GetAccountByID(account.ID, auth.DB)
+	if err != nil {
+		return "", nil, "", err
+	}
+	if full == nil {
+		return "", nil, "", errors.New("No account found: " + account.ID)
+	}
+	if err := auth.confirmOwner(session, full, request); err != nil {
+		auth.audit(account.ID, account.ID, types.AuditLoginFailed, "deletion request", session)
+		return "", nil, "", err
+	}
+
+	existing, err := drm.GetRequest(account.ID, auth.DB)
+	if err != nil {
+		return "", nil, "", err
+	}
+	if existing != nil {
+		return "Account deletion is already scheduled for " + existing.ScheduledFor.Format("January 2, 2006"), nil, "", nil
+	}
+
+	token, deletion, err := drm.CreateRequest(full, auth.deletionGrace(), auth.DB)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	auth.audit(account.ID, account.ID, types.AuditDeletionRequested, "scheduled for "+deletion.ScheduledFor.Format(time.RFC3339), session)
+	return "", deletion, token, nil
+}
+
+//CancelDeletion - cancels a scheduled deletion with the token from its cancel link
+func (auth Authenticate) CancelDeletion(session *types.Session, request *types.SelfDeletionRequest) error {
+	deletion, err := manager.DeletionRequestManager{}.ConsumeRequest(request.Token, auth.DB)
+	if err != nil {
+		return err
+	}
+	if deletion == nil {
+		return errors.New("Invalid or used cancel link")
+	}
+
+	auth.audit(deletion.AccountID, deletion.AccountID, types.AuditDeletionCancelled, "", session)
+	return nil
+}
+
+//ProcessDeletions - deletes the accounts whose deletion request grace period has passed
+func (auth Authenticate) ProcessDeletions() {
+	drm := manager.DeletionRequestManager{}
+	am := manager.AccountManager{}
+
+	requests, err := drm.GetDueRequests(auth.DB)
+	if err != nil {
+		fmt.Println("Failed getting deletion requests: " + err.Error())
+		return
+	}
+
+	for _, request := range requests {
+		account, err := am.GetAccountByID(request.AccountID, auth.DB)
+		if err != nil {
+			fmt.Println("Failed getting account for deletion: " + err.Error())
+			continue
+		}
+		//Already deleted by an admin
+		if account == nil {
+			if err := drm.DeleteRequest(request.AccountID, auth.DB); err != nil {
+				fmt.Println("Failed removing deletion request: " + err.Error())
+			}
+			continue
+		}
+
+		//Removes the request with the rest of the account data
+		err = am.DeleteAccount(account, auth.Config.Deletion.ReserveNames, auth.DB, auth.Cache)
+		if err != nil {
+			fmt.Println("Failed deleting account: " + err.Error())
+			continue
+		}
+		auth.audit(account.ID, account.ID, types.AuditAccountDeleted, "self-service", nil)
+	}
+}
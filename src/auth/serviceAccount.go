@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"manager"
+	"types"
+)
+
+//CreateServiceAccount - creates a service account owned by a human account (ADMINS ONLY).
+//The owner defaults to the admin making the request. Service accounts always get the default role
+func (auth Authenticate) CreateServiceAccount(session *types.Session, newAccount *types.Account) (string, error) {
+	am := manager.AccountManager{}
+
+	account, err := auth.checkPermission(session, types.ScopeAccountsWrite)
+	if err != nil {
+		return "", err
+	}
+
+	if newAccount.OwnerID == "" {
+		newAccount.OwnerID = account.ID
+	}
+	owner, err := am.GetAccountByID(newAccount.OwnerID, auth.DB)
+	if err != nil {
+		return "", err
+	}
+	if owner == nil || owner.IsService() {
+		return "Owner must be a user account", nil
+	}
+
+	newAccount.Role = types.LevelDefault
+	res, err := am.CreateServiceAccount(newAccount, auth.DB)
+	if err != nil {
+		return "", err
+	}
+
+	return res, nil
+}
+
+//GetServiceAccounts - returns the service accounts owned by the session account.
+//Accounts that can read accounts get every service account
+func (auth Authenticate) GetServiceAccounts(session *types.Session) (*[]types.Account, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerID := account.ID
+	if account.Can(types.ScopeAccountsRead) {
+		ownerID = ""
+	}
+
+	accounts, err := manager.AccountManager{}.GetServiceAccounts(ownerID, auth.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
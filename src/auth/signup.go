@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"manager"
+	"time"
+	"types"
+)
+
+//How long an email verification link can be used for
+const verificationTimeout = 24 * time.Hour
+
+//How many days signed up accounts have to verify their email before they are purged
+const unverifiedDays = 7
+
+//Signup - creates an account that must verify its email before it can login.
+//Returns the verification and its plain token to email, or a reason if the details are invalid
+func (auth Authenticate) Signup(request *types.SignupRequest) (string, *types.EmailVerification, string, error) {
+	if !auth.Config.Signup.Enabled {
+		return "", nil, "", errors.New("Sign up is disabled")
+	}
+
+	account := types.Account{
+		UserName: request.UserName,
+		Password: request.Password,
+		Name:     request.Name,
+		Email:    request.Email,
+		Phone:    request.Phone,
+		Role:     types.LevelDefault,
+		Type:     types.AccountUser,
+	}
+	//Cannot login until the email is verified
+	account.PendingVerification = true
+	if account.Name == "" {
+		return "Name is required", nil, "", nil
+	}
+
+	res, err := auth.checkPhone(&account)
+	if err != nil || res != "" {
+		return res, nil, "", err
+	}
+
+	//CreateAccount validates the details with the account Check methods
+	res, err = manager.AccountManager{}.CreateAccount(&account, nil, auth.DB)
+	if err != nil || res != "" {
+		return res, nil, "", err
+	}
+
+	token, verification, err := manager.SignupManager{}.CreateVerification(&account, auth.DB)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	return "", verification, token, nil
+}
+
+//VerifySignup - uses a verification link so the account can login
+func (auth Authenticate) VerifySignup(request *types.SignupRequest) error {
+	sm := manager.SignupManager{}
+
+	verification, err := sm.ConsumeVerification(request.Token, auth.DB)
+	if err != nil {
+		return err
+	}
+	if verification == nil || time.Since(verification.Created) > verificationTimeout {
+		return errors.New("Invalid or expired verification link")
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByID(verification.AccountID, auth.DB)
+	if err != nil {
+		return err
+	}
+	//The link was sent to an email the account no longer has
+	if account == nil || account.Email != verification.Email {
+		return errors.New("No account was found for verification: " + verification.AccountID)
+	}
+
+	return sm.SetPendingVerification(account, false, auth.DB)
+}
+
+//ResendVerification - creates a new verification link for an unverified account.
+//Only one link is sent every few minutes. Returns the verification and its plain token to email.
+//Nothing is returned if there is no unverified account or a link was sent recently, so emails cannot be checked for accounts
+func (auth Authenticate) ResendVerification(request *types.SignupRequest) (*types.EmailVerification, string, error) {
+	sm := manager.SignupManager{}
+
+	if !auth.Config.Signup.Enabled {
+		return nil, "", errors.New("Sign up is disabled")
+	}
+
+	account, err := manager.AccountManager{}.GetAccountByEmail(request.Email, auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+	if account == nil || !account.PendingVerification {
+		return nil, "", nil
+	}
+
+	last, err := sm.GetLatestVerification(account.ID, auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+	if last != nil && time.Since(last.Created) < time.Duration(auth.Config.Signup.ResendWait)*time.Minute {
+		return nil, "", nil
+	}
+
+	token, verification, err := sm.CreateVerification(account, auth.DB)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return verification, token, nil
+}
+
+//PurgeUnverified - removes signed up accounts that never verified their email
+func (auth Authenticate) PurgeUnverified() {
+	err := manager.SignupManager{}.PurgeUnverified(unverifiedDays, auth.DB)
+	if err != nil {
+		fmt.Println("Failed purging unverified accounts: " + err.Error())
+	}
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"errors"
+	"manager"
+	"time"
+	"types"
+)
+
+//userNameHold - how long an old username stays with its account
+func (auth Authenticate) userNameHold() time.Duration {
+	return time.Duration(auth.Config.UserNames.HoldDays) * 24 * time.Hour
+}
+
+//ChangeUserName - changes the username of the session account. Returns a reason if it cannot be changed yet or the name is not available
+func (auth Authenticate) ChangeUserName(session *types.Session, request *types.ChangeUserNameRequest) (string, error) {
+	account, err := auth.CheckAccountSession(session)
+	if err != nil {
+		return "", err
+	}
+	if session.Token == "" {
+		return "", errors.New("Changing the username requires a session: " + account.Name)
+	}
+
+	if err := auth.reauthenticate(account, request.Password); err != nil {
+		return "", err
+	}
+
+	if request.UserName == account.UserName {
+		return "UserName is unchanged", nil
+	}
+	cooldown := time.Duration(auth.Config.UserNames.CooldownDays) * 24 * time.Hour
+	if account.UserNameChanged != nil && time.Since(*account.UserNameChanged) < cooldown {
+		return "UserName can be changed again on " + account.UserNameChanged.Add(cooldown).Format("January 2, 2006"), nil
+	}
+
+	updated := *account
+	updated.UserName = request.UserName
+	updated.OrgUnique = auth.Config.Orgs.UniquePerOrg
+	if err := updated.CheckUserName(); err != nil {
+		return err.Error(), nil
+	}
+	res, err := manager.AccountManager{}.CheckDuplicates(&updated, auth.DB)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	oldUserName := account.UserName
+	err = manager.UserNameManager{}.ChangeUserName(account, request.UserName, auth.userNameHold(), auth.DB, auth.Cache)
+	if err != nil {
+		return "", err
+	}
+
+	auth.audit(account.ID, account.ID, types.AuditUserNameChanged, oldUserName+" -> "+account.UserName, session)
+	return "", nil
+}
+
+//ReserveUserNames - stores the configured reserved usernames so no account can take them
+func (auth Authenticate) ReserveUserNames() error {
+	return manager.UserNameManager{}.ReserveUserNames(auth.Config.UserNames.Reserved, auth.DB)
+}
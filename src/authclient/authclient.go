@@ -0,0 +1,68 @@
+package authclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"types"
+)
+
+//Client - lets other go services ask the auth server what a session can do
+type Client struct {
+	URL  string //Address of the auth server. e.g https://auth.example.com
+	HTTP *http.Client
+}
+
+//Init - setup a client for the auth server at the url given
+func (client Client) Init(url string) *Client {
+	client.URL = url
+	client.HTTP = &http.Client{Timeout: 5 * time.Second}
+	return &client
+}
+
+//Authorize - checks if the session of an incoming request can do a permission on a resource.
+//The session cookies and bearer token of the request are passed on. Resource can be nil
+func (client Client) Authorize(r *http.Request, permission string, resource map[string]string) (*types.PermissionDecision, error) {
+	body, err := json.Marshal(types.PermissionCheckRequest{Permission: permission, Resource: resource})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", client.URL+"/api/auth/authorize", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	for _, name := range []string{"sessionId", "deviceId"} {
+		if cookie, err := r.Cookie(name); err == nil {
+			req.AddCookie(cookie)
+		}
+	}
+
+	res, err := client.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var response types.PermissionDecisionResponse
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	if !response.Response || response.Data == nil {
+		return nil, errors.New("Permission check failed: " + permission)
+	}
+
+	return response.Data, nil
+}
+
+//Allowed - same as Authorize but any error is a deny
+func (client Client) Allowed(r *http.Request, permission string, resource map[string]string) bool {
+	decision, err := client.Authorize(r, permission, resource)
+	return err == nil && decision.Allowed
+}
@@ -20,11 +20,17 @@ func (db MySQL) Init(config *types.Config) *MySQL {
 	if pool.Ping() != nil {
 		return nil
 	}
-	db.sql = pool
+	mysql := db.InitPool(pool)
 
 	//Setup interval to remove expired data
-	utils.Schedule(db.DeleteExpired, 1*time.Hour)
+	utils.Schedule(mysql.DeleteExpired, 1*time.Hour)
+
+	return mysql
+}
 
+//InitPool - uses a connection pool that is already open
+func (db MySQL) InitPool(pool *sql.DB) *MySQL {
+	db.sql = pool
 	return &db
 }
 
@@ -46,9 +52,29 @@ func (db MySQL) SimpleQuery(query string) (*sql.Rows, error) {
 	return q, nil
 }
 
-//DeleteExpired - removes all expired recoveries or devices
+//Transaction - runs fn in a transaction. Commits if it returns nil, otherwise rolls back
+func (db MySQL) Transaction(fn func(tx *sql.Tx) error) error {
+	tx, err := db.sql.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+//DeleteExpired - removes all expired recoveries, devices, links and tokens
 func (db MySQL) DeleteExpired() {
 	_, _ = db.SimpleQuery("DELETE FROM recover WHERE created < (NOW() - INTERVAL 1 HOUR)")
 	_, _ = db.SimpleQuery("DELETE FROM emailChange WHERE created < (NOW() - INTERVAL 1 HOUR)")
+	_, _ = db.SimpleQuery("DELETE FROM phoneVerifications WHERE created < (NOW() - INTERVAL 1 HOUR)")
+	_, _ = db.SimpleQuery("DELETE FROM magicLinks WHERE created < (NOW() - INTERVAL 1 HOUR)")
 	_, _ = db.SimpleQuery("DELETE FROM devices WHERE created < (NOW() - INTERVAL 60 DAY)")
+	_, _ = db.SimpleQuery("DELETE FROM oauthCodes WHERE created < (NOW() - INTERVAL 1 HOUR)")
+	_, _ = db.SimpleQuery("DELETE FROM oauthTokens WHERE expires < NOW()")
+	_, _ = db.SimpleQuery("DELETE FROM personalTokens WHERE expires < NOW()")
+	_, _ = db.SimpleQuery("DELETE FROM federatedStates WHERE created < (NOW() - INTERVAL 1 HOUR)")
+	_, _ = db.SimpleQuery("DELETE FROM emailVerifications WHERE created < (NOW() - INTERVAL 1 DAY)")
 }
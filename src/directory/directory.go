@@ -0,0 +1,139 @@
+package directory
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+	"types"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+//Directory - ldap / active directory server
+type Directory struct {
+	Config  types.LDAPConfig
+	Timeout time.Duration
+}
+
+//Init - setup a directory. Returns nil if ldap login is disabled
+func (directory Directory) Init(config types.LDAPConfig) *Directory {
+	if config.URL == "" {
+		return nil
+	}
+	directory.Config = config
+	directory.Timeout = 10 * time.Second
+	return &directory
+}
+
+//Authenticate - finds the user with the service account then binds as the user to check the password
+func (directory Directory) Authenticate(login string, password string) (*types.DirectoryUser, error) {
+	//An empty password would be an unauthenticated bind which always succeeds
+	if login == "" || password == "" {
+		return nil, errors.New("Invalid LDAP login: " + login)
+	}
+
+	conn, err := directory.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if directory.Config.BindDN != "" {
+		if err := conn.Bind(directory.Config.BindDN, directory.Config.BindPassword); err != nil {
+			return nil, err
+		}
+	}
+
+	idAttribute := directory.Config.IDAttribute
+	attributes := []string{"sAMAccountName", "uid", "displayName", "cn", "mail", "telephoneNumber", "memberOf"}
+	if idAttribute != "" {
+		attributes = append(attributes, idAttribute)
+	}
+	filter := strings.Replace(directory.Config.UserFilter, "%s", ldap.EscapeFilter(login), -1)
+	search := ldap.NewSearchRequest(
+		directory.Config.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(directory.Timeout.Seconds()), false,
+		filter,
+		attributes,
+		nil,
+	)
+	result, err := conn.Search(search)
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, err
+	}
+	if result == nil || len(result.Entries) != 1 {
+		return nil, errors.New("LDAP user not found or not unique: " + login)
+	}
+	entry := result.Entries[0]
+
+	//Check the password by binding as the user
+	if err := conn.Bind(entry.DN, password); err != nil {
+		return nil, errors.New("Invalid LDAP Password Attempt: " + login)
+	}
+
+	user := types.DirectoryUser{
+		ID:       entry.DN,
+		DN:       entry.DN,
+		UserName: first(entry.GetAttributeValue("sAMAccountName"), entry.GetAttributeValue("uid"), login),
+		Name:     first(entry.GetAttributeValue("displayName"), entry.GetAttributeValue("cn")),
+		Email:    entry.GetAttributeValue("mail"),
+		Phone:    entry.GetAttributeValue("telephoneNumber"),
+		Groups:   entry.GetAttributeValues("memberOf"),
+	}
+
+	//Binary ids like objectGUID are stored as hex
+	if idAttribute != "" {
+		id := entry.GetRawAttributeValue(idAttribute)
+		if len(id) == 0 {
+			return nil, errors.New("LDAP user has no " + idAttribute + ": " + entry.DN)
+		}
+		user.ID = string(id)
+		if !utf8.Valid(id) {
+			user.ID = hex.EncodeToString(id)
+		}
+	}
+
+	return &user, nil
+}
+
+//Role - returns the highest role of the groups given. Returns the default role if none are mapped
+func (directory Directory) Role(groups []string) int {
+	return types.GetGroupRole(groups, directory.Config.GroupRoles, directory.Config.DefaultRole)
+}
+
+//dial - connects to the directory, upgrading to tls if configured
+func (directory Directory) dial() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(directory.Config.URL)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(directory.Timeout)
+
+	if directory.Config.StartTLS {
+		u, err := url.Parse(directory.Config.URL)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: u.Hostname()}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+//first - returns the first value that is not empty
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,267 @@
+package directory
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+	"types"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+//entry - user stored in the test directory
+type entry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+//server - minimal ldap server supporting simple binds and searches by uid
+type server struct {
+	listener net.Listener
+	bindDN   string //Service account allowed to search
+	entries  []entry
+}
+
+//newServer - starts a directory on a local port
+func newServer(t *testing.T, entries ...entry) *server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &server{listener: listener, bindDN: "cn=service,dc=example,dc=com", entries: append(entries, entry{dn: "cn=service,dc=example,dc=com", password: "service"})}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+	return srv
+}
+
+//config - returns a directory config for the server
+func (srv *server) config() types.LDAPConfig {
+	return types.LDAPConfig{
+		URL:          "ldap://" + srv.listener.Addr().String(),
+		BindDN:       srv.bindDN,
+		BindPassword: "service",
+		BaseDN:       "dc=example,dc=com",
+		UserFilter:   "(uid=%s)",
+	}
+}
+
+//serve - answers the requests of a connection until it unbinds
+func (srv *server) serve(conn net.Conn) {
+	defer conn.Close()
+	bound := ""
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id := packet.Children[0].Value
+		request := packet.Children[1]
+
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := fmt.Sprint(request.Children[1].Value)
+			password := request.Children[2].Data.String()
+			code := ldap.LDAPResultInvalidCredentials
+			for _, e := range srv.entries {
+				if e.dn == dn && e.password == password && password != "" {
+					code, bound = ldap.LDAPResultSuccess, dn
+				}
+			}
+			conn.Write(response(id, ldap.ApplicationBindResponse, code).Bytes())
+
+		case ldap.ApplicationSearchRequest:
+			if bound != srv.bindDN {
+				conn.Write(response(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultInsufficientAccessRights).Bytes())
+				continue
+			}
+			filter, err := ldap.DecompileFilter(request.Children[6])
+			if err != nil {
+				conn.Write(response(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultOperationsError).Bytes())
+				continue
+			}
+			for _, e := range srv.entries {
+				if uid := e.attributes["uid"]; len(uid) > 0 && filter == "(uid="+uid[0]+")" {
+					conn.Write(searchEntry(id, e).Bytes())
+				}
+			}
+			conn.Write(response(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess).Bytes())
+
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+//response - returns an ldap result message
+func response(id interface{}, tag ber.Tag, code int) *ber.Packet {
+	message := ber.NewSequence("LDAP Response")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	message.AppendChild(result)
+	return message
+}
+
+//searchEntry - returns a search result entry message
+func searchEntry(id interface{}, e entry) *ber.Packet {
+	message := ber.NewSequence("LDAP Response")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, "objectName"))
+	attributes := ber.NewSequence("attributes")
+	for name, values := range e.attributes {
+		attribute := ber.NewSequence("attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	result.AppendChild(attributes)
+	message.AppendChild(result)
+	return message
+}
+
+var alice = entry{
+	dn:       "uid=alice,ou=people,dc=example,dc=com",
+	password: "secret",
+	attributes: map[string][]string{
+		"uid":             {"alice"},
+		"cn":              {"Alice Smith"},
+		"mail":            {"alice@example.com"},
+		"telephoneNumber": {"555-123-4567"},
+		"memberOf":        {"cn=admins,ou=groups,dc=example,dc=com", "cn=staff,ou=groups,dc=example,dc=com"},
+		"objectGUID":      {"\x01\xff\x02\xfe"},
+	},
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv := newServer(t, alice)
+	defer srv.listener.Close()
+	directory := Directory{}.Init(srv.config())
+
+	user, err := directory.Authenticate("alice", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != alice.dn || user.DN != alice.dn || user.UserName != "alice" || user.Name != "Alice Smith" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+	if user.Email != "alice@example.com" || user.Phone != "555-123-4567" || len(user.Groups) != 2 {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	tests := []struct {
+		name     string
+		login    string
+		password string
+	}{
+		{"wrong password", "alice", "wrong"},
+		{"empty password", "alice", ""},
+		{"empty login", "", "secret"},
+		{"unknown user", "bob", "secret"},
+		{"wildcard login", "*", "secret"},
+		{"filter injection", "alice)(uid=*", "secret"},
+	}
+	for _, test := range tests {
+		if user, err := directory.Authenticate(test.login, test.password); err == nil {
+			t.Errorf("%s: logged in as %+v", test.name, user)
+		}
+	}
+}
+
+func TestAuthenticateServiceBind(t *testing.T) {
+	srv := newServer(t, alice)
+	defer srv.listener.Close()
+	config := srv.config()
+	config.BindPassword = "wrong"
+
+	if _, err := (Directory{}).Init(config).Authenticate("alice", "secret"); err == nil {
+		t.Error("logged in with an invalid service account")
+	}
+}
+
+func TestAuthenticateIDAttribute(t *testing.T) {
+	srv := newServer(t, alice)
+	defer srv.listener.Close()
+	config := srv.config()
+
+	//Binary ids are stored as hex
+	config.IDAttribute = "objectGUID"
+	user, err := Directory{}.Init(config).Authenticate("alice", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != "01ff02fe" {
+		t.Errorf("id %q; want 01ff02fe", user.ID)
+	}
+
+	config.IDAttribute = "uid"
+	user, err = Directory{}.Init(config).Authenticate("alice", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != "alice" {
+		t.Errorf("id %q; want alice", user.ID)
+	}
+
+	config.IDAttribute = "employeeNumber"
+	if _, err := (Directory{}).Init(config).Authenticate("alice", "secret"); err == nil {
+		t.Error("logged in without an id")
+	}
+}
+
+func TestInitDisabled(t *testing.T) {
+	if directory := (Directory{}).Init(types.LDAPConfig{}); directory != nil {
+		t.Error("directory setup without a url")
+	}
+}
+
+func TestRole(t *testing.T) {
+	directory := Directory{Config: types.LDAPConfig{
+		DefaultRole: types.LevelDefault,
+		GroupRoles:  map[string]int{"CN=Admins,OU=Groups,DC=example,DC=com": types.LevelAdmin},
+	}}
+	if role := directory.Role(alice.attributes["memberOf"]); role != types.LevelAdmin {
+		t.Errorf("admin groups role %d; want %d", role, types.LevelAdmin)
+	}
+	if role := directory.Role([]string{"cn=staff,ou=groups,dc=example,dc=com"}); role != types.LevelDefault {
+		t.Errorf("staff role %d; want %d", role, types.LevelDefault)
+	}
+}
+
+func TestAuthenticateTimeout(t *testing.T) {
+	//A directory that never answers must not hang logins
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	directory := Directory{}.Init(types.LDAPConfig{URL: "ldap://" + listener.Addr().String(), BindDN: "cn=service", BindPassword: "service"})
+	directory.Timeout = 100 * time.Millisecond
+	if _, err := directory.Authenticate("alice", "secret"); err == nil {
+		t.Error("logged in without an answer")
+	}
+}
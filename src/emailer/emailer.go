@@ -1,6 +1,8 @@
 package emailer
 
 import (
+	"html"
+	"strings"
 	"types"
 
 	"gopkg.in/gomail.v2"
@@ -76,6 +78,136 @@ func (e Emailer) ChangeEmail(emailRequest *types.EmailChange) error {
 	return nil
 }
 
+//MagicLinkEmail - send a login link to the given account
+func (e Emailer) MagicLinkEmail(link *types.MagicLink, token string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.Email)
+	m.SetHeader("To", link.Email)
+	m.SetHeader("Subject", "Login Link")
+	m.SetBody("text/html", e.getTemplate("To login <a href='"+e.Host+"/magicLink?token="+token+"'>Click Here</a><br/><br/>The link can only be used once and expires in 15 minutes.", "Login Link", e.Host))
+
+	d := gomail.NewDialer(e.SMTPAddress, e.SMTPPort, e.Email, e.Password)
+
+	if err := d.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//InviteEmail - send an invitation to create an account
+func (e Emailer) InviteEmail(invite *types.Invite, token string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.Email)
+	m.SetHeader("To", invite.Email)
+	m.SetHeader("Subject", "Account Invitation")
+	m.SetBody("text/html", e.getTemplate("Hi "+invite.Name+", you have been invited to create an account.<br/><br/>To choose your username and password <a href='"+e.Host+"/acceptInvite?token="+token+"'>Click Here</a><br/><br/>The link expires on "+invite.Expires.Format("January 2, 2006 15:04 MST")+".", "Account Invitation", e.Host))
+
+	d := gomail.NewDialer(e.SMTPAddress, e.SMTPPort, e.Email, e.Password)
+
+	if err := d.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//VerifyEmail - send a link to verify the email of a new account
+func (e Emailer) VerifyEmail(verification *types.EmailVerification, token string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.Email)
+	m.SetHeader("To", verification.Email)
+	m.SetHeader("Subject", "Verify Your Email")
+	m.SetBody("text/html", e.getTemplate("To verify your email and activate your account <a href='"+e.Host+"/verifyEmail?token="+token+"'>Click Here</a><br/><br/>The link can only be used once and expires in 24 hours.", "Verify Your Email", e.Host))
+
+	d := gomail.NewDialer(e.SMTPAddress, e.SMTPPort, e.Email, e.Password)
+
+	if err := d.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//AccountStatusEmail - tell an account it was suspended or reactivated
+func (e Emailer) AccountStatusEmail(account *types.Account) error {
+	title, body := "Account Reactivated", "Your account is active again and you can login."
+	if account.IsBlocked() {
+		title, body = "Account "+strings.Title(account.Status), "Your account is "+account.Status+" and you cannot login."
+		if account.StatusReason != "" {
+			body += "<br/><br/>Reason: " + html.EscapeString(account.StatusReason)
+		}
+		if account.StatusUntil != nil {
+			body += "<br/><br/>This ends on " + account.StatusUntil.Format("January 2, 2006 15:04 MST") + "."
+		}
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.Email)
+	m.SetHeader("To", account.Email)
+	m.SetHeader("Subject", title)
+	m.SetBody("text/html", e.getTemplate(body, title, e.Host))
+
+	d := gomail.NewDialer(e.SMTPAddress, e.SMTPPort, e.Email, e.Password)
+
+	if err := d.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//ExportEmail - send the link to download a data export
+func (e Emailer) ExportEmail(export *types.Export, token string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.Email)
+	m.SetHeader("To", export.Email)
+	m.SetHeader("Subject", "Your Data Export")
+	m.SetBody("text/html", e.getTemplate("The account data export you requested is ready.<br/><br/>To download it <a href='"+e.Host+"/downloadExport?token="+token+"'>Click Here</a><br/><br/>The link expires in 7 days.", "Your Data Export", e.Host))
+
+	d := gomail.NewDialer(e.SMTPAddress, e.SMTPPort, e.Email, e.Password)
+
+	if err := d.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//DeletionCodeEmail - send the account the one-time code confirming its deletion request
+func (e Emailer) DeletionCodeEmail(account *types.Account, device *types.Device) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.Email)
+	m.SetHeader("To", account.Email)
+	m.SetHeader("Subject", "Confirm Account Deletion")
+	m.SetBody("text/html", e.getTemplate("Your account deletion code is: <b>"+device.Code+"</b><br/><br/>If you did not request this you can ignore this email.", "Confirm Account Deletion", e.Host))
+
+	d := gomail.NewDialer(e.SMTPAddress, e.SMTPPort, e.Email, e.Password)
+
+	if err := d.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//DeletionRequestEmail - send the confirmation of a scheduled account deletion with a link to cancel it
+func (e Emailer) DeletionRequestEmail(deletion *types.DeletionRequest, token string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.Email)
+	m.SetHeader("To", deletion.Email)
+	m.SetHeader("Subject", "Account Deletion Requested")
+	m.SetBody("text/html", e.getTemplate("Your account is scheduled to be deleted on "+deletion.ScheduledFor.Format("January 2, 2006 15:04 MST")+". You can keep using it until then.<br/><br/>If you did not request this or changed your mind <a href='"+e.Host+"/cancelDeletion?token="+token+"'>Click Here</a> to cancel.", "Account Deletion Requested", e.Host))
+
+	d := gomail.NewDialer(e.SMTPAddress, e.SMTPPort, e.Email, e.Password)
+
+	if err := d.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (e Emailer) getTemplate(body string, title string, domain string) string {
 	return `
 	<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
@@ -0,0 +1,182 @@
+package federation
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"jwt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"types"
+)
+
+//Provider - external openid connect provider
+type Provider struct {
+	Config      types.IdentityProviderConfig
+	RedirectURI string
+	Client      *http.Client
+}
+
+//Init - setup a provider. The redirect uri must be registered with the provider
+func (provider Provider) Init(config types.IdentityProviderConfig, redirectURI string) *Provider {
+	provider.Config = config
+	provider.RedirectURI = redirectURI
+	provider.Client = &http.Client{Timeout: 10 * time.Second}
+	return &provider
+}
+
+//Discover - returns the discovery document of the provider
+func (provider Provider) Discover() (*types.OpenIDConfiguration, error) {
+	var discovery types.OpenIDConfiguration
+	err := provider.getJSON(strings.TrimSuffix(provider.Config.Issuer, "/")+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != provider.Config.Issuer {
+		return nil, errors.New("Provider issuer mismatch: " + discovery.Issuer)
+	}
+	return &discovery, nil
+}
+
+//AuthURL - returns the url to send the user to for login
+func (provider Provider) AuthURL(state string, nonce string, verifier string) (string, error) {
+	discovery, err := provider.Discover()
+	if err != nil {
+		return "", err
+	}
+
+	scopes := provider.Config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.Config.ClientID},
+		"redirect_uri":          {provider.RedirectURI},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+//Exchange - exchanges an authorization code and returns the verified id token claims
+func (provider Provider) Exchange(code string, verifier string, nonce string) (map[string]interface{}, error) {
+	discovery, err := provider.Discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.RedirectURI},
+		"code_verifier": {verifier},
+		"client_id":     {provider.Config.ClientID},
+		"client_secret": {provider.Config.ClientSecret},
+	}
+	res, err := provider.Client.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var token types.TokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK || token.IDToken == "" {
+		return nil, errors.New("Provider token exchange failed: " + res.Status)
+	}
+
+	return provider.VerifyIDToken(token.IDToken, nonce, discovery)
+}
+
+//VerifyIDToken - verifies the signature and claims of an id token from the provider
+func (provider Provider) VerifyIDToken(idToken string, nonce string, discovery *types.OpenIDConfiguration) (map[string]interface{}, error) {
+	var jwks types.JWKS
+	if err := provider.getJSON(discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	kid := jwt.KeyID(idToken)
+	var claims map[string]interface{}
+	for _, jwk := range jwks.Keys {
+		if kid != "" && jwk.Kid != kid {
+			continue
+		}
+		key, err := jwt.PublicKey(jwk)
+		if err != nil {
+			continue
+		}
+		if claims, err = jwt.Verify(idToken, key); err == nil {
+			break
+		}
+	}
+	if claims == nil {
+		return nil, errors.New("Invalid id token signature")
+	}
+
+	if claims["iss"] != provider.Config.Issuer {
+		return nil, errors.New("Invalid id token issuer")
+	}
+	if !audienceContains(claims["aud"], provider.Config.ClientID) {
+		return nil, errors.New("Invalid id token audience")
+	}
+	exp, _ := claims["exp"].(float64)
+	if time.Now().Unix() > int64(exp) {
+		return nil, errors.New("Id token expired")
+	}
+	if claims["nonce"] != nonce {
+		return nil, errors.New("Invalid id token nonce")
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, errors.New("Id token has no subject")
+	}
+
+	return claims, nil
+}
+
+//Challenge - returns the S256 PKCE challenge of a verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+//audienceContains - aud can be a single string or a list
+func audienceContains(aud interface{}, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []interface{}:
+		for _, a := range v {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (provider Provider) getJSON(uri string, v interface{}) error {
+	res, err := provider.Client.Get(uri)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.New("Provider request failed: " + uri + " " + res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
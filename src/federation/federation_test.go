@@ -0,0 +1,174 @@
+package federation
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"jwt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"types"
+)
+
+//issuer - openid connect provider running in the test
+type issuer struct {
+	server    *httptest.Server
+	signer    *jwt.Signer
+	challenge string                 //PKCE challenge of the last login
+	claims    map[string]interface{} //Claims of the next id token
+}
+
+//newIssuer - starts a provider signing id tokens with a generated key
+func newIssuer(t *testing.T) *issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &issuer{signer: &jwt.Signer{Key: key, KeyID: "test"}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(types.OpenIDConfiguration{
+			Issuer:                iss.server.URL,
+			AuthorizationEndpoint: iss.server.URL + "/authorize",
+			TokenEndpoint:         iss.server.URL + "/token",
+			JWKSURI:               iss.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(iss.signer.JWKS())
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "code" || r.Form.Get("client_secret") != "secret" || Challenge(r.Form.Get("code_verifier")) != iss.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		idToken, err := iss.signer.Sign(iss.claims)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(types.TokenResponse{IDToken: idToken})
+	})
+	iss.server = httptest.NewServer(mux)
+	return iss
+}
+
+//provider - returns a provider configured for the issuer
+func (iss *issuer) provider() *Provider {
+	return Provider{}.Init(types.IdentityProviderConfig{Name: "test", Issuer: iss.server.URL, ClientID: "client", ClientSecret: "secret"}, "https://auth.example.com/callback")
+}
+
+//idClaims - returns valid id token claims for the nonce
+func (iss *issuer) idClaims(nonce string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":            iss.server.URL,
+		"aud":            "client",
+		"sub":            "user-1",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"nonce":          nonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+	}
+}
+
+func TestLogin(t *testing.T) {
+	iss := newIssuer(t)
+	defer iss.server.Close()
+	provider := iss.provider()
+
+	authURL, err := provider.AuthURL("state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if !strings.HasPrefix(authURL, iss.server.URL+"/authorize?") || query.Get("state") != "state" || query.Get("nonce") != "nonce" || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected auth url: %s", authURL)
+	}
+	if query.Get("client_id") != "client" || query.Get("redirect_uri") != provider.RedirectURI || query.Get("scope") != "openid profile email" {
+		t.Fatalf("unexpected auth url: %s", authURL)
+	}
+	iss.challenge = query.Get("code_challenge")
+	iss.claims = iss.idClaims("nonce")
+
+	claims, err := provider.Exchange("code", "verifier", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["sub"] != "user-1" || claims["email"] != "user@example.com" {
+		t.Errorf("unexpected claims: %v", claims)
+	}
+
+	//The code verifier must match the challenge sent with the login
+	if _, err := provider.Exchange("code", "other", "nonce"); err == nil {
+		t.Error("exchange with the wrong verifier succeeded")
+	}
+	if _, err := provider.Exchange("wrong", "verifier", "nonce"); err == nil {
+		t.Error("exchange with the wrong code succeeded")
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	iss := newIssuer(t)
+	defer iss.server.Close()
+	provider := iss.provider()
+	discovery, err := provider.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := &jwt.Signer{Key: otherKey, KeyID: "test"}
+
+	tests := []struct {
+		name    string
+		change  func(claims map[string]interface{})
+		signer  *jwt.Signer
+		wantErr bool
+	}{
+		{"valid", func(claims map[string]interface{}) {}, iss.signer, false},
+		{"audience list", func(claims map[string]interface{}) { claims["aud"] = []string{"other", "client"} }, iss.signer, false},
+		{"other key", func(claims map[string]interface{}) {}, other, true},
+		{"other issuer", func(claims map[string]interface{}) { claims["iss"] = "https://evil.example.com" }, iss.signer, true},
+		{"other audience", func(claims map[string]interface{}) { claims["aud"] = "other" }, iss.signer, true},
+		{"expired", func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }, iss.signer, true},
+		{"other nonce", func(claims map[string]interface{}) { claims["nonce"] = "replayed" }, iss.signer, true},
+		{"no subject", func(claims map[string]interface{}) { delete(claims, "sub") }, iss.signer, true},
+	}
+	for _, test := range tests {
+		claims := iss.idClaims("nonce")
+		test.change(claims)
+		idToken, err := test.signer.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = provider.VerifyIDToken(idToken, "nonce", discovery)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v; want error %v", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestDiscoverIssuerMismatch(t *testing.T) {
+	iss := newIssuer(t)
+	defer iss.server.Close()
+
+	provider := iss.provider()
+	provider.Config.Issuer = iss.server.URL + "/"
+	if _, err := provider.Discover(); err == nil {
+		t.Error("discovery with another issuer succeeded")
+	}
+}
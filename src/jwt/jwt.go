@@ -0,0 +1,171 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"types"
+)
+
+//Signer - signs and verifies RS256 json web tokens
+type Signer struct {
+	Key   *rsa.PrivateKey
+	KeyID string
+}
+
+//Init - loads the signing key from the key file. Generates and saves a new key if none exists.
+//Returns an error if the key file cannot be read or a new key cannot be saved so issued tokens never stop verifying
+func (signer Signer) Init(config *types.Config) (*Signer, error) {
+	key, err := loadKey(config.OAuth.KeyFile)
+	if os.IsNotExist(err) {
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		if err := saveKey(config.OAuth.KeyFile, key); err != nil {
+			return nil, errors.New("Failed saving signing key: " + err.Error())
+		}
+	}
+	if err != nil {
+		return nil, errors.New("Failed loading signing key: " + err.Error())
+	}
+	signer.Key = key
+	signer.KeyID = keyID(&key.PublicKey)
+	return &signer, nil
+}
+
+//loadKey - reads a PEM encoded rsa key from disk
+func loadKey(path string) (*rsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("Invalid key file: " + path)
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+//saveKey - writes a PEM encoded rsa key to disk
+func saveKey(path string, key *rsa.PrivateKey) error {
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+//keyID - returns a stable id for a public key
+func keyID(key *rsa.PublicKey) string {
+	sum := sha256.Sum256(key.N.Bytes())
+	return base64.RawURLEncoding.EncodeToString(sum[:])[:16]
+}
+
+//Sign - returns a signed token with the claims given
+func (signer Signer) Sign(claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": signer.KeyID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, signer.Key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+//Verify - verifies a token was signed by this signer and returns its claims. **DOES NOT CHECK EXPIRY
+func (signer Signer) Verify(token string) (map[string]interface{}, error) {
+	return Verify(token, &signer.Key.PublicKey)
+}
+
+//JWKS - returns the public key as a json web key set
+func (signer Signer) JWKS() *types.JWKS {
+	return &types.JWKS{Keys: []types.JWK{{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: signer.KeyID,
+		N:   base64.RawURLEncoding.EncodeToString(signer.Key.PublicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(signer.Key.PublicKey.E)).Bytes()),
+	}}}
+}
+
+//Verify - verifies a RS256 token with the key given and returns its claims. **DOES NOT CHECK EXPIRY
+func Verify(token string, key *rsa.PublicKey) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("Malformed token")
+	}
+	var header map[string]interface{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header["alg"] != "RS256" {
+		return nil, errors.New("Unsupported token algorithm")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig); err != nil {
+		return nil, errors.New("Invalid token signature")
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+//KeyID - returns the kid header of a token without verifying it
+func KeyID(token string) string {
+	parts := strings.Split(token, ".")
+	var header map[string]interface{}
+	if decodeSegment(parts[0], &header) != nil {
+		return ""
+	}
+	kid, _ := header["kid"].(string)
+	return kid
+}
+
+//PublicKey - converts a json web key to an rsa public key
+func PublicKey(jwk types.JWK) (*rsa.PublicKey, error) {
+	if jwk.Kty != "RSA" {
+		return nil, errors.New("Unsupported key type: " + jwk.Kty)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
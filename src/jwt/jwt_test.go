@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"types"
+)
+
+func TestInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := &types.Config{}
+
+	//A missing key is generated and reused on the next start
+	config.OAuth.KeyFile = filepath.Join(dir, "keys", "signing.pem")
+	signer, err := Signer{}.Init(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := signer.Sign(map[string]interface{}{"sub": "account"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted, err := Signer{}.Init(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restarted.KeyID != signer.KeyID {
+		t.Errorf("key id %s after restart; want %s", restarted.KeyID, signer.KeyID)
+	}
+	if claims, err := restarted.Verify(token); err != nil || claims["sub"] != "account" {
+		t.Errorf("token issued before restart: %v, %v", claims, err)
+	}
+
+	//An unreadable key must not be replaced
+	config.OAuth.KeyFile = filepath.Join(dir, "invalid.pem")
+	if err := ioutil.WriteFile(config.OAuth.KeyFile, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if signer, err := (Signer{}).Init(config); err == nil || signer != nil {
+		t.Error("started with an invalid key file")
+	}
+	if data, _ := ioutil.ReadFile(config.OAuth.KeyFile); string(data) != "not a key" {
+		t.Error("invalid key file was overwritten")
+	}
+
+	//A new key that cannot be saved would invalidate every token on restart
+	blocked := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(blocked, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	config.OAuth.KeyFile = filepath.Join(blocked, "signing.pem")
+	if signer, err := (Signer{}).Init(config); err == nil || signer != nil {
+		t.Error("started without saving the key")
+	}
+}
@@ -2,11 +2,14 @@ package manager
 
 import (
 	"cache"
+	"database/sql"
 	"db"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
+	"os"
+	"regexp"
+	"strings"
 	"time"
 	"types"
 	"utils"
@@ -32,13 +35,21 @@ func (am AccountManager) SaveToCache(account *types.Account, cache *cache.Cache)
 
 //CheckDuplicates - checks if account info already exists.
 //Returns empty string and no error if no duplicates are found
-//Returns string with an error message if duplicates are found
+//Returns string with an error message if duplicates are found.
+//OrgUnique accounts are only checked against accounts sharing an organization or in their active organization
 func (am AccountManager) CheckDuplicates(account *types.Account, db *db.MySQL) (string, error) {
-	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE (userName = ? AND id <> ?) OR (email = ? AND id <> ?)")
+	query := "SELECT * FROM users WHERE (userName = ? OR (email = ? AND email <> '')) AND id <> ?"
+	args := []interface{}{account.UserName, account.Email, account.ID}
+	if account.OrgUnique {
+		query += " AND id IN (SELECT accountId FROM orgMembers WHERE orgId = ? OR orgId IN (SELECT orgId FROM orgMembers WHERE accountId = ?))"
+		args = append(args, account.ActiveOrg, account.ID)
+	}
+
+	stmt, err := db.PreparedQuery(query)
 	if err != nil {
 		return "", err
 	}
-	rows, err := stmt.Query(account.UserName, account.ID, account.Email, account.ID)
+	rows, err := stmt.Query(args...)
 	if err != nil {
 		return "", err
 	}
@@ -58,6 +69,15 @@ func (am AccountManager) CheckDuplicates(account *types.Account, db *db.MySQL) (
 		return "", nil
 	}
 
+	//Reserved usernames and old usernames held for another account
+	held, err := UserNameManager{}.UserNameHeld(account, db)
+	if err != nil {
+		return "", err
+	}
+	if held {
+		return "UserName is not available: " + account.UserName, nil
+	}
+
 	return "", nil
 }
 
@@ -68,12 +88,36 @@ func (am AccountManager) RemoveSession(session *types.Session, cache *cache.Cach
 	return nil
 }
 
-//GetAllAccounts - returns all account from db
-func (am AccountManager) GetAllAccounts(db *db.MySQL) (*[]types.Account, error) {
-	rows, err := db.SimpleQuery("SELECT * FROM users ORDER BY name ASC")
+//RevokeSession - replaces the session token of the account so the current session stops working
+func (am AccountManager) RevokeSession(account *types.Account, db *db.MySQL, cache *cache.Cache) error {
+	oldToken := account.Token
+	account.Token = utils.RandomString()
+
+	err := am.UpdateAccountToken(account, db)
+	if err != nil {
+		return err
+	}
+	cache.Del(oldToken)
+	return nil
+}
+
+//orgFilter - joins the members of an organization onto users. Every user is kept if the organization is empty
+const orgFilter = " LEFT JOIN orgMembers m ON m.accountId = u.id AND m.orgId = ? WHERE (? = '' OR m.orgId IS NOT NULL) AND "
+
+//notDeleted - leaves out deleted accounts waiting to be purged
+const notDeleted = " AND deletedAt IS NULL"
+
+//GetAllAccounts - returns all accounts from db. Only members of the organization if one is given
+func (am AccountManager) GetAllAccounts(orgID string, db *db.MySQL) (*[]types.Account, error) {
+	stmt, err := db.PreparedQuery("SELECT u.*, COALESCE(m.role, 0) AS orgRole FROM users u" + orgFilter + "u.type = 'user'" + notDeleted + " ORDER BY u.name ASC")
 	if err != nil {
 		return nil, err
 	}
+	rows, err := stmt.Query(orgID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
 	accounts := []types.Account{}
 	defer rows.Close()
 	for rows.Next() {
@@ -83,43 +127,36 @@ func (am AccountManager) GetAllAccounts(db *db.MySQL) (*[]types.Account, error)
 			//fmt.Println(err) -> fails to convert NULL to datatype
 		}
 		account = account.HideInfo()
-		account = account.GetPermissions()
 		accounts = append(accounts, account)
 	}
+	if err := (RoleManager{}).LoadAccountsRoles(accounts, db); err != nil {
+		return nil, err
+	}
+	if err := (AttributeManager{}).LoadAccountsAttributes(accounts, true, db); err != nil {
+		return nil, err
+	}
 	return &accounts, nil
 }
 
-//GetAccounts - returns all accounts with the role given
-func (am AccountManager) GetAccounts(roles []int, db *db.MySQL) (*[]types.Account, error) {
+//GetAccounts - returns all accounts with one of the role names given, directly or through a group. Only members of the organization if one is given
+func (am AccountManager) GetAccounts(roles []string, orgID string, db *db.MySQL) (*[]types.Account, error) {
 
 	if len(roles) <= 0 {
 		return nil, errors.New("Roles array is empty")
 	}
 
-	query := "SELECT * FROM users WHERE role = '" + strconv.Itoa(roles[0]) + "'"
-
-	for i, r := range roles {
-		if i == 0 {
-			continue
-		}
-		query += " OR role = '" + strconv.Itoa(r) + "'"
-	}
-
-	rows, err := db.SimpleQuery(query + " ORDER BY name ASC")
+	all, err := am.GetAllAccounts(orgID, db)
 	if err != nil {
 		return nil, err
 	}
 	accounts := []types.Account{}
-	defer rows.Close()
-	for rows.Next() {
-		account := types.Account{}
-		err := sqlstruct.Scan(&account, rows)
-		if err != nil {
-			//fmt.Println(err) -> fails to convert NULL to datatype
+	for _, account := range *all {
+		for _, role := range roles {
+			if utils.Contains(role, account.Roles) {
+				accounts = append(accounts, account)
+				break
+			}
 		}
-		account = account.HideInfo()
-		account = account.GetPermissions()
-		accounts = append(accounts, account)
 	}
 	return &accounts, nil
 }
@@ -133,7 +170,7 @@ func (am AccountManager) GetAccountSession(session *types.Session, db *db.MySQL,
 			return account, nil
 		}
 	}
-	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE token = ?")
+	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE token = ?" + notDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -169,6 +206,58 @@ func (am AccountManager) UpdateAccountToken(account *types.Account, db *db.MySQL
 	return nil
 }
 
+//SetActiveOrg - sets the organization the session of an account works in
+func (am AccountManager) SetActiveOrg(account *types.Account, orgID string, db *db.MySQL, cache *cache.Cache) error {
+	stmt, err := db.PreparedQuery("UPDATE users SET activeOrg = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(orgID, account.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+
+	account.ActiveOrg = orgID
+	am.SaveToCache(account, cache)
+	return nil
+}
+
+//UpdateLastLogin - records that the account just logged in
+func (am AccountManager) UpdateLastLogin(account *types.Account, db *db.MySQL) error {
+	now := time.Now()
+	account.LastLogin = &now
+
+	stmt, err := db.PreparedQuery("UPDATE users SET lastLogin = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Query(account.LastLogin, account.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	return nil
+}
+
+//SetPassword - sets the hashed password of an account. An empty hash removes password login
+func (am AccountManager) SetPassword(account *types.Account, hash string, db *db.MySQL, cache *cache.Cache) error {
+	account.Password = hash
+
+	stmt, err := db.PreparedQuery("UPDATE users SET password = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Query(account.Password, account.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+
+	am.SaveToCache(account, cache)
+	return nil
+}
+
 //CreateAccount - verifies and creates a new account
 func (am AccountManager) CreateAccount(account *types.Account, authedAccount *types.Account, db *db.MySQL) (string, error) {
 
@@ -204,52 +293,392 @@ func (am AccountManager) CreateAccount(account *types.Account, authedAccount *ty
 		return "", err
 	}
 	//Insert into database
-	stmt, err := db.PreparedQuery("INSERT INTO users (id, userName, password, token, role, name, phone, email, created) VALUES(?,?,?,?,?,?,?,?,?)")
+	stmt, err := db.PreparedQuery("INSERT INTO users (id, userName, password, token, role, name, phone, email, pendingVerification, created) VALUES(?,?,?,?,?,?,?,?,?,?)")
 	if err != nil {
 		return "", err
 	}
-	_, err = stmt.Query(account.ID, account.UserName, account.Password, account.Token, account.Role, account.Name, account.Phone, account.Email, account.Created)
+	_, err = stmt.Query(account.ID, account.UserName, account.Password, account.Token, account.Role, account.Name, account.Phone, account.Email, account.PendingVerification, account.Created)
+	if err != nil {
+		return "", err
+	}
+	stmt.Close()
+
+	err = IdentityManager{}.CreatePasswordIdentity(account, db)
+	if err != nil {
+		return "", err
+	}
+
+	err = RoleManager{}.SetLegacyRoles(account, db)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//CreateServiceAccount - verifies and creates a new service account. It has no password or email
+func (am AccountManager) CreateServiceAccount(account *types.Account, db *db.MySQL) (string, error) {
+
+	if err := account.CheckUserName(); err != nil {
+		return err.Error(), nil
+	}
+
+	account.Type = types.AccountService
+	account.Password = ""
+	account.Email = ""
+	account.Phone = ""
+
+	//Check if account details already exist with another account
+	isDuplicate, err := am.CheckDuplicates(account, db)
+	if err != nil {
+		return "", err
+	}
+	if isDuplicate != "" {
+		return isDuplicate, nil
+	}
+
+	//Setup account details
+	account.ID = utils.RandomString()
+	account.Token = utils.RandomString()
+	account.Created = time.Now()
+
+	stmt, err := db.PreparedQuery("INSERT INTO users (id, userName, password, token, role, name, phone, email, type, ownerId, created) VALUES(?,?,?,?,?,?,?,?,?,?,?)")
+	if err != nil {
+		return "", err
+	}
+	_, err = stmt.Query(account.ID, account.UserName, account.Password, account.Token, account.Role, account.Name, account.Phone, account.Email, account.Type, account.OwnerID, account.Created)
 	if err != nil {
 		return "", err
 	}
 	stmt.Close()
 
+	err = RoleManager{}.SetLegacyRoles(account, db)
+	if err != nil {
+		return "", err
+	}
+
 	return "", nil
 }
 
-//DeleteAccount - deletes account from DB and cache.
-func (am AccountManager) DeleteAccount(account *types.Account, db *db.MySQL, cache *cache.Cache) error {
+//GetServiceAccounts - returns the service accounts of an owner. Returns all service accounts if owner is empty
+func (am AccountManager) GetServiceAccounts(ownerID string, db *db.MySQL) (*[]types.Account, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE type = 'service' AND (ownerId = ? OR ? = '')" + notDeleted + " ORDER BY name ASC")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(ownerID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	accounts := []types.Account{}
+	defer rows.Close()
+	for rows.Next() {
+		account := types.Account{}
+		err := sqlstruct.Scan(&account, rows)
+		if err != nil {
+			return nil, err
+		}
+		account = account.HideInfo()
+		accounts = append(accounts, account)
+	}
+	if err := (RoleManager{}).LoadAccountsRoles(accounts, db); err != nil {
+		return nil, err
+	}
+	return &accounts, nil
+}
+
+//CreateExternalAccount - creates an account for a user of an external identity provider. The account has no password
+func (am AccountManager) CreateExternalAccount(account *types.Account, db *db.MySQL) error {
 
-	stmt, err := db.PreparedQuery("DELETE FROM users WHERE id = ?")
+	if err := account.CheckEmail(); err != nil {
+		return err
+	}
+
+	//Build a valid username and add digits until it is free
+	base := regexp.MustCompile(`[^a-zA-Z0-9._-]`).ReplaceAllString(account.UserName, "")
+	for len(base) < 6 {
+		base += "0"
+	}
+	account.UserName = base
+	for i := 0; ; i++ {
+		isDuplicate, err := am.CheckDuplicates(account, db)
+		if err != nil {
+			return err
+		}
+		if isDuplicate == "" {
+			break
+		}
+		if strings.HasPrefix(isDuplicate, "Email") || i == 10 {
+			return errors.New(isDuplicate)
+		}
+		account.UserName = base + utils.RandomCode()[:4]
+	}
+
+	//Setup account details
+	account.ID = utils.RandomString()
+	account.Token = utils.RandomString()
+	account.Created = time.Now()
+	account.Password = ""
+
+	stmt, err := db.PreparedQuery("INSERT INTO users (id, userName, password, token, role, name, phone, email, created) VALUES(?,?,?,?,?,?,?,?,?)")
 	if err != nil {
 		return err
 	}
-	_, err = stmt.Query(account.ID)
+	_, err = stmt.Query(account.ID, account.UserName, account.Password, account.Token, account.Role, account.Name, account.Phone, account.Email, account.Created)
 	if err != nil {
 		return err
 	}
+	stmt.Close()
+
+	return RoleManager{}.SetLegacyRoles(account, db)
+}
+
+//SyncAccount - updates the details of an account managed by an external directory **DOES NOT USE CACHE
+func (am AccountManager) SyncAccount(account *types.Account, db *db.MySQL) error {
 
-	cache.Del(account.Token)
+	//Check if account details already exist with another account
+	isDuplicate, err := am.CheckDuplicates(account, db)
+	if err != nil {
+		return err
+	}
+	if isDuplicate != "" {
+		return errors.New(isDuplicate)
+	}
 
+	stmt, err := db.PreparedQuery("UPDATE users SET name = ?, email = ?, phone = ?, phoneVerified = ?, deliveryChannel = ?, role = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Query(account.Name, account.Email, account.Phone, account.PhoneVerified, account.DeliveryChannel, account.Role, account.ID)
+	if err != nil {
+		return err
+	}
 	stmt.Close()
+
+	return RoleManager{}.SetLegacyRoles(account, db)
+}
+
+//DeleteAccount - marks an account deleted and ends its sessions, devices and tokens. Roles and memberships are kept so it can be restored.
+//Unless the names are reserved the username and email are moved aside so other accounts can use them.
+//Service accounts of the account are deleted with it so their tokens stop working
+func (am AccountManager) DeleteAccount(account *types.Account, reserveNames bool, db *db.MySQL, cache *cache.Cache) error {
+	userName, email := account.UserName, account.Email
+	if !reserveNames {
+		userName, email = "deleted_"+account.ID, ""
+	}
+	err := exec(db, "UPDATE users SET deletedAt = ?, deletedUserName = userName, deletedEmail = email, userName = ?, email = ? WHERE id = ?", time.Now(), userName, email, account.ID)
+	if err != nil {
+		return err
+	}
+
+	err = am.RevokeSession(account, db, cache)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []string{"devices", "recover", "emailChange", "magicLinks", "phoneVerifications", "emailVerifications", "personalTokens", "oauthCodes", "oauthTokens", "deletionRequests"} {
+		err = exec(db, "DELETE FROM "+table+" WHERE accountId = ?", account.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	services, err := pairs(db, "SELECT id, token FROM users WHERE ownerId = ? AND type = ?"+notDeleted, account.ID, types.AccountService)
+	if err != nil {
+		return err
+	}
+	for _, service := range services {
+		err = am.DeleteAccount(&types.Account{ID: service[0], Token: service[1], Type: types.AccountService, OwnerID: account.ID}, reserveNames, db, cache)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
+}
 
+//GetDeletedAccounts - returns the deleted accounts that have not been purged. Only members of the organization if one is given
+func (am AccountManager) GetDeletedAccounts(orgID string, db *db.MySQL) (*[]types.Account, error) {
+	stmt, err := db.PreparedQuery("SELECT u.*, COALESCE(m.role, 0) AS orgRole FROM users u" + orgFilter + "u.deletedAt IS NOT NULL ORDER BY u.deletedAt DESC")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(orgID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	accounts := []types.Account{}
+	defer rows.Close()
+	for rows.Next() {
+		account := types.Account{}
+		err := sqlstruct.Scan(&account, rows)
+		if err != nil {
+			return nil, err
+		}
+		account = account.HideInfo()
+		accounts = append(accounts, account)
+	}
+	return &accounts, nil
 }
 
-//UpdateAccountSettings - updates the given account settings
-func (am AccountManager) UpdateAccountSettings(updatedAccount *types.Account, account *types.Account, db *db.MySQL, cache *cache.Cache) (string, error) {
+//GetDeletedAccount - returns a deleted account that has not been purged
+func (am AccountManager) GetDeletedAccount(id string, db *db.MySQL) (*types.Account, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE id = ? AND deletedAt IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		account := types.Account{}
+		err = sqlstruct.Scan(&account, rows)
+		if err != nil {
+			return nil, err
+		}
+		return &account, nil
+	}
+	return nil, nil
+}
 
-	if err := updatedAccount.CheckPhone(); err != nil {
-		return err.Error(), nil
+//RestoreAccount - undoes a delete and gives the account back its username and email.
+//Returns a reason if another account took them
+func (am AccountManager) RestoreAccount(account *types.Account, db *db.MySQL) (string, error) {
+	account.UserName = account.DeletedUserName
+	account.Email = account.DeletedEmail
+	res, err := am.CheckDuplicates(account, db)
+	if err != nil || res != "" {
+		return res, err
+	}
+
+	err = exec(db, "UPDATE users SET deletedAt = NULL, userName = deletedUserName, email = deletedEmail, deletedUserName = '', deletedEmail = '' WHERE id = ?", account.ID)
+	if err != nil {
+		return "", err
+	}
+	account.DeletedAt = nil
+	account.DeletedUserName = ""
+	account.DeletedEmail = ""
+	return "", nil
+}
+
+//PurgeAccount - removes a deleted account, its service accounts and every row connected to them in one transaction.
+//Their export files are removed once the transaction is done.
+//Audit events are kept as the record of what happened but lose the ip and details of the purged accounts.
+//Pending invites they sent are removed, nobody is left to vouch for them
+func (am AccountManager) PurgeAccount(id string, db *db.MySQL) error {
+	files := []string{}
+	err := db.Transaction(func(tx *sql.Tx) error {
+		ids := []string{id}
+		rows, err := tx.Query("SELECT id FROM users WHERE ownerId = ? AND type = ?", id, types.AccountService)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var serviceID string
+			if err := rows.Scan(&serviceID); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, serviceID)
+		}
+		rows.Close()
+
+		for _, accountID := range ids {
+			rows, err := tx.Query("SELECT file FROM exports WHERE accountId = ? AND file <> ''", accountID)
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				var file string
+				if err := rows.Scan(&file); err != nil {
+					rows.Close()
+					return err
+				}
+				files = append(files, file)
+			}
+			rows.Close()
+		}
+
+		tables := []string{"devices", "recover", "emailChange", "magicLinks", "phoneVerifications", "emailVerifications", "personalTokens",
+			"oauthCodes", "oauthTokens", "identities", "federatedStates", "accountRoles", "groupMembers", "orgMembers", "deletionRequests", "accountAttributes", "userNameHolds", "exports"}
+		for _, accountID := range ids {
+			for _, table := range tables {
+				if _, err := tx.Exec("DELETE FROM "+table+" WHERE accountId = ?", accountID); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.Exec("UPDATE auditEvents SET ip = '', detail = '' WHERE accountId = ?", accountID); err != nil {
+				return err
+			}
+			if _, err := tx.Exec("UPDATE auditEvents SET ip = '' WHERE actorId = ?", accountID); err != nil {
+				return err
+			}
+			if _, err := tx.Exec("DELETE FROM invites WHERE invitedBy = ?", accountID); err != nil {
+				return err
+			}
+		}
+		//Service accounts go with their owner even if they were restored on their own
+		if _, err := tx.Exec("DELETE FROM users WHERE ownerId = ? AND type = ?", id, types.AccountService); err != nil {
+			return err
+		}
+		_, err = tx.Exec("DELETE FROM users WHERE id = ? AND deletedAt IS NOT NULL", id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+//PurgeDeleted - purges the accounts deleted more than the days given ago
+func (am AccountManager) PurgeDeleted(days int, db *db.MySQL) error {
+	stmt, err := db.PreparedQuery("SELECT id FROM users WHERE deletedAt < (NOW() - INTERVAL ? DAY)")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(days)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := am.PurgeAccount(id, db); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+//UpdateAccountSettings - updates the given account settings. A new phone goes through PhoneManager.SetPendingPhone instead
+func (am AccountManager) UpdateAccountSettings(updatedAccount *types.Account, account *types.Account, db *db.MySQL, cache *cache.Cache) (string, error) {
 	account.Name = updatedAccount.Name
-	account.Phone = updatedAccount.Phone
 
-	stmt, err := db.PreparedQuery("UPDATE users SET name = ?, phone = ? WHERE id = ?")
+	stmt, err := db.PreparedQuery("UPDATE users SET name = ? WHERE id = ?")
 	if err != nil {
 		return "", err
 	}
-	_, err = stmt.Query(account.Name, account.Phone, account.ID)
+	_, err = stmt.Query(account.Name, account.ID)
 	if err != nil {
 		return "", err
 	}
@@ -260,14 +689,50 @@ func (am AccountManager) UpdateAccountSettings(updatedAccount *types.Account, ac
 	return "", nil
 }
 
+//SetPhoneVerified - marks the phone of an account as verified
+func (am AccountManager) SetPhoneVerified(account *types.Account, db *db.MySQL, cache *cache.Cache) error {
+	account.PhoneVerified = true
+
+	stmt, err := db.PreparedQuery("UPDATE users SET phoneVerified = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Query(account.PhoneVerified, account.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+
+	am.SaveToCache(account, cache)
+	return nil
+}
+
+//SetDeliveryChannel - sets where device codes are sent for an account
+func (am AccountManager) SetDeliveryChannel(account *types.Account, channel string, db *db.MySQL, cache *cache.Cache) error {
+	account.DeliveryChannel = channel
+
+	stmt, err := db.PreparedQuery("UPDATE users SET deliveryChannel = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Query(account.DeliveryChannel, account.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+
+	am.SaveToCache(account, cache)
+	return nil
+}
+
 //UpdateOtherAccountSettings - updates the another users account settings (ADMINS ONLY)
 func (am AccountManager) UpdateOtherAccountSettings(updatedAccount *types.Account, db *db.MySQL, cache *cache.Cache) (string, error) {
 
-	if err := updatedAccount.CheckPhone(); err != nil {
-		return err.Error(), nil
-	}
-	if err := updatedAccount.CheckEmail(); err != nil {
-		return err.Error(), nil
+	//Service accounts do not need an email. A new phone is checked by the caller and confirmed through PhoneManager
+	if !updatedAccount.IsService() || updatedAccount.Email != "" {
+		if err := updatedAccount.CheckEmail(); err != nil {
+			return err.Error(), nil
+		}
 	}
 
 	//Check if account details already exist with another account
@@ -279,16 +744,21 @@ func (am AccountManager) UpdateOtherAccountSettings(updatedAccount *types.Accoun
 		return isDuplicate, nil
 	}
 
-	stmt, err := db.PreparedQuery("UPDATE users SET name = ?, userName = ?, email = ?, phone = ?, role = ? WHERE id = ?")
+	stmt, err := db.PreparedQuery("UPDATE users SET name = ?, userName = ?, email = ?, phone = ?, phoneVerified = ?, deliveryChannel = ?, role = ? WHERE id = ?")
 	if err != nil {
 		return "", err
 	}
-	_, err = stmt.Query(updatedAccount.Name, updatedAccount.UserName, updatedAccount.Email, updatedAccount.Phone, updatedAccount.Role, updatedAccount.ID)
+	_, err = stmt.Query(updatedAccount.Name, updatedAccount.UserName, updatedAccount.Email, updatedAccount.Phone, updatedAccount.PhoneVerified, updatedAccount.DeliveryChannel, updatedAccount.Role, updatedAccount.ID)
 	if err != nil {
 		return "", err
 	}
 	stmt.Close()
 
+	err = RoleManager{}.SetLegacyRoles(updatedAccount, db)
+	if err != nil {
+		return "", err
+	}
+
 	am.SaveToCache(updatedAccount, cache)
 
 	return "", nil
@@ -296,7 +766,11 @@ func (am AccountManager) UpdateOtherAccountSettings(updatedAccount *types.Accoun
 
 //GetAccountByEmail - returns an account by email
 func (am AccountManager) GetAccountByEmail(email string, db *db.MySQL) (*types.Account, error) {
-	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE email = ?")
+	//Service accounts have no email
+	if email == "" {
+		return nil, nil
+	}
+	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE email = ?" + notDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -318,31 +792,40 @@ func (am AccountManager) GetAccountByEmail(email string, db *db.MySQL) (*types.A
 }
 
 //GetAccountLoginDetails - returns the account by checking if username or email matches what the user inputed.
-func (am AccountManager) GetAccountLoginDetails(login string, db *db.MySQL) (*types.Account, error) {
-	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE email = ? OR username = ?")
+//Only members of the organization are checked if one is given
+func (am AccountManager) GetAccountLoginDetails(login string, orgID string, db *db.MySQL) (*types.Account, error) {
+	stmt, err := db.PreparedQuery("SELECT u.* FROM users u" + orgFilter + "(u.email = ? OR u.username = ?)" + notDeleted)
 	if err != nil {
 		return nil, err
 	}
-	rows, err := stmt.Query(login, login)
+	rows, err := stmt.Query(orgID, orgID, login, login)
 	if err != nil {
 		return nil, err
 	}
 	stmt.Close()
 	defer rows.Close()
+	var found *types.Account
 	for rows.Next() {
+		//Usernames unique per organization can match several accounts
+		if found != nil {
+			return nil, errors.New("Login matches accounts in several organizations: " + login)
+		}
 		account := types.Account{}
 		err = sqlstruct.Scan(&account, rows)
 		if err != nil {
 			return nil, err
 		}
-		return &account, nil
+		found = &account
 	}
-	return nil, errors.New("Invalid Username Or Email: " + login)
+	if found == nil {
+		return nil, errors.New("Invalid Username Or Email: " + login)
+	}
+	return found, nil
 }
 
 //GetAccountByID - returns an account by id
 func (am AccountManager) GetAccountByID(id string, db *db.MySQL) (*types.Account, error) {
-	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE id = ?")
+	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE id = ?" + notDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -365,7 +848,7 @@ func (am AccountManager) GetAccountByID(id string, db *db.MySQL) (*types.Account
 
 //GetAccountFromUserName - returns account from an id **DOES NOT USE CACHE
 func (am AccountManager) GetAccountFromUserName(userName string, db *db.MySQL) (*types.Account, error) {
-	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE username = ?")
+	stmt, err := db.PreparedQuery("SELECT * FROM users WHERE username = ?" + notDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -426,3 +909,26 @@ func (am AccountManager) DisableTwoFA(account *types.Account, db *db.MySQL, cach
 
 	return nil
 }
+
+//SetStatus - saves the status of an account. Blocking statuses end its session, oauth codes and tokens right away
+func (am AccountManager) SetStatus(account *types.Account, db *db.MySQL, cache *cache.Cache) error {
+	err := exec(db, "UPDATE users SET status = ?, statusReason = ?, statusUntil = ? WHERE id = ?", account.Status, account.StatusReason, account.StatusUntil, account.ID)
+	if err != nil {
+		return err
+	}
+	if !account.IsBlocked() {
+		return nil
+	}
+
+	err = am.RevokeSession(account, db, cache)
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{"oauthCodes", "oauthTokens"} {
+		err = exec(db, "DELETE FROM "+table+" WHERE accountId = ?", account.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package manager
+
+import (
+	"cache"
+	"database/sql/driver"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"types"
+)
+
+//serviceDB - fake database where the owner has one service account. Records the statements run
+func serviceDB(statements *[]string) func(query string, args []driver.Value) fakeTable {
+	return func(query string, args []driver.Value) fakeTable {
+		*statements = append(*statements, fmt.Sprint(query, args))
+		if strings.Contains(query, "FROM users WHERE ownerId = ?") && strings.HasPrefix(query, "SELECT") && args[0] == "owner" {
+			if strings.HasPrefix(query, "SELECT id, token") {
+				return fakeTable{columns: []string{"id", "token"}, rows: [][]driver.Value{{"service", "token"}}}
+			}
+			return fakeTable{columns: []string{"id"}, rows: [][]driver.Value{{"service"}}}
+		}
+		return fakeTable{}
+	}
+}
+
+//contains - checks if a statement starting with the prefix ran
+func contains(statements []string, prefix string) bool {
+	for _, statement := range statements {
+		if strings.HasPrefix(statement, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDeleteAccountDeletesServiceAccounts(t *testing.T) {
+	statements := []string{}
+	db := newFakeDB(t, serviceDB(&statements))
+
+	err := AccountManager{}.DeleteAccount(&types.Account{ID: "owner"}, false, db, &cache.Cache{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"DELETE FROM personalTokens WHERE accountId = ?[owner]",
+		"DELETE FROM personalTokens WHERE accountId = ?[service]",
+		"DELETE FROM oauthTokens WHERE accountId = ?[service]",
+		"UPDATE users SET deletedAt = ?, deletedUserName = userName, deletedEmail = email, userName = ?, email = ? WHERE id = ?",
+	} {
+		if !contains(statements, want) {
+			t.Errorf("missing statement %q", want)
+		}
+	}
+}
+
+func TestPurgeAccountPurgesServiceAccounts(t *testing.T) {
+	statements := []string{}
+	db := newFakeDB(t, serviceDB(&statements))
+
+	err := AccountManager{}.PurgeAccount("owner", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"DELETE FROM personalTokens WHERE accountId = ?[owner]",
+		"DELETE FROM personalTokens WHERE accountId = ?[service]",
+		"DELETE FROM identities WHERE accountId = ?[service]",
+		"DELETE FROM users WHERE ownerId = ? AND type = ?[owner service]",
+		"DELETE FROM users WHERE id = ? AND deletedAt IS NOT NULL[owner]",
+	} {
+		if !contains(statements, want) {
+			t.Errorf("missing statement %q", want)
+		}
+	}
+}
+
+func TestSetStatusRevokesOAuth(t *testing.T) {
+	statements := []string{}
+	db := newFakeDB(t, serviceDB(&statements))
+
+	err := AccountManager{}.SetStatus(&types.Account{ID: "owner", Status: types.StatusSuspended}, db, &cache.Cache{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"DELETE FROM oauthCodes WHERE accountId = ?[owner]",
+		"DELETE FROM oauthTokens WHERE accountId = ?[owner]",
+	} {
+		if !contains(statements, want) {
+			t.Errorf("missing statement %q", want)
+		}
+	}
+}
+
+func TestPurgeAccountRemovesExports(t *testing.T) {
+	file, err := ioutil.TempFile("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	statements := []string{}
+	services := serviceDB(&statements)
+	db := newFakeDB(t, func(query string, args []driver.Value) fakeTable {
+		if strings.HasPrefix(query, "SELECT file FROM exports") && args[0] == "owner" {
+			return fakeTable{columns: []string{"file"}, rows: [][]driver.Value{{file.Name()}}}
+		}
+		return services(query, args)
+	})
+
+	err = AccountManager{}.PurgeAccount("owner", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"DELETE FROM exports WHERE accountId = ?[owner]",
+		"DELETE FROM exports WHERE accountId = ?[service]",
+		"UPDATE auditEvents SET ip = '', detail = '' WHERE accountId = ?[owner]",
+		"DELETE FROM invites WHERE invitedBy = ?[owner]",
+	} {
+		if !contains(statements, want) {
+			t.Errorf("missing statement %q", want)
+		}
+	}
+	if _, err := os.Stat(file.Name()); !os.IsNotExist(err) {
+		t.Error("export file kept after the purge")
+	}
+}
@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"db"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//AttributeManager - custom profile attributes data access object
+type AttributeManager struct {
+}
+
+//GetDefinitions - returns every attribute definition
+func (atm AttributeManager) GetDefinitions(db *db.MySQL) (*[]types.AttributeDefinition, error) {
+	rows, err := db.SimpleQuery("SELECT * FROM attributeDefinitions ORDER BY name ASC")
+	if err != nil {
+		return nil, err
+	}
+	defs := []types.AttributeDefinition{}
+	defer rows.Close()
+	for rows.Next() {
+		def := types.AttributeDefinition{}
+		err := sqlstruct.Scan(&def, rows)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	return &defs, nil
+}
+
+//GetDefinition - returns an attribute definition by id
+func (atm AttributeManager) GetDefinition(id string, db *db.MySQL) (*types.AttributeDefinition, error) {
+	defs, err := atm.GetDefinitions(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, def := range *defs {
+		if def.ID == id {
+			return &def, nil
+		}
+	}
+	return nil, nil
+}
+
+//CheckDuplicateDefinition - returns a reason if another attribute has the same name
+func (atm AttributeManager) CheckDuplicateDefinition(def *types.AttributeDefinition, db *db.MySQL) (string, error) {
+	found, err := pairs(db, "SELECT id, name FROM attributeDefinitions WHERE name = ? AND id <> ?", def.Name, def.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(found) > 0 {
+		return "Attribute name already exists", nil
+	}
+	return "", nil
+}
+
+//CreateDefinition - creates an attribute definition
+func (atm AttributeManager) CreateDefinition(def *types.AttributeDefinition, db *db.MySQL) error {
+	def.ID = utils.RandomString()
+	def.Created = time.Now()
+
+	return exec(db, "INSERT INTO attributeDefinitions (id, name, label, type, required, pattern, minValue, maxValue, options, visibility, created) VALUES(?,?,?,?,?,?,?,?,?,?,?)",
+		def.ID, def.Name, def.Label, def.Type, def.Required, def.Pattern, def.Min, def.Max, def.Options, def.Visibility, def.Created)
+}
+
+//UpdateDefinition - updates an attribute definition. Values already stored are kept
+func (atm AttributeManager) UpdateDefinition(def *types.AttributeDefinition, db *db.MySQL) error {
+	return exec(db, "UPDATE attributeDefinitions SET name = ?, label = ?, type = ?, required = ?, pattern = ?, minValue = ?, maxValue = ?, options = ?, visibility = ? WHERE id = ?",
+		def.Name, def.Label, def.Type, def.Required, def.Pattern, def.Min, def.Max, def.Options, def.Visibility, def.ID)
+}
+
+//DeleteDefinition - deletes an attribute definition and every value of it
+func (atm AttributeManager) DeleteDefinition(id string, db *db.MySQL) error {
+	err := exec(db, "DELETE FROM accountAttributes WHERE attributeId = ?", id)
+	if err != nil {
+		return err
+	}
+	return exec(db, "DELETE FROM attributeDefinitions WHERE id = ?", id)
+}
+
+//GetValues - returns the attribute values of every account by account id and attribute id. Only one account if an id is given
+func (atm AttributeManager) GetValues(accountID string, db *db.MySQL) (map[string]map[string]string, error) {
+	query := "SELECT accountId, attributeId, value FROM accountAttributes"
+	args := []interface{}{}
+	if accountID != "" {
+		query += " WHERE accountId = ?"
+		args = append(args, accountID)
+	}
+	stmt, err := db.PreparedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+
+	values := map[string]map[string]string{}
+	for rows.Next() {
+		var account, attribute, value string
+		if err := rows.Scan(&account, &attribute, &value); err != nil {
+			return nil, err
+		}
+		if values[account] == nil {
+			values[account] = map[string]string{}
+		}
+		values[account][attribute] = value
+	}
+	return values, nil
+}
+
+//LoadAccountsAttributes - sets the attribute values of a list of accounts by name. Hidden attributes are only set for admins
+func (atm AttributeManager) LoadAccountsAttributes(accounts []types.Account, admin bool, db *db.MySQL) error {
+	accountID := ""
+	if len(accounts) == 1 {
+		accountID = accounts[0].ID
+	}
+	defs, err := atm.GetDefinitions(db)
+	if err != nil {
+		return err
+	}
+	values, err := atm.GetValues(accountID, db)
+	if err != nil {
+		return err
+	}
+
+	for i := range accounts {
+		accounts[i].Attributes = map[string]string{}
+		for _, def := range *defs {
+			if value, ok := values[accounts[i].ID][def.ID]; ok && def.VisibleTo(admin) {
+				accounts[i].Attributes[def.Name] = value
+			}
+		}
+	}
+	return nil
+}
+
+//LoadAccountAttributes - sets the attribute values of an account by name. Hidden attributes are only set for admins
+func (atm AttributeManager) LoadAccountAttributes(account *types.Account, admin bool, db *db.MySQL) error {
+	accounts := []types.Account{*account}
+	if err := atm.LoadAccountsAttributes(accounts, admin, db); err != nil {
+		return err
+	}
+	account.Attributes = accounts[0].Attributes
+	return nil
+}
+
+//SetValues - stores attribute values of an account by attribute id. Empty values are removed
+func (atm AttributeManager) SetValues(accountID string, values map[string]string, db *db.MySQL) error {
+	for id, value := range values {
+		var err error
+		if value == "" {
+			err = exec(db, "DELETE FROM accountAttributes WHERE accountId = ? AND attributeId = ?", accountID, id)
+		} else {
+			err = exec(db, "INSERT INTO accountAttributes (accountId, attributeId, value) VALUES(?,?,?) ON DUPLICATE KEY UPDATE value = ?", accountID, id, value, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
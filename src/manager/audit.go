@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"db"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//AuditManager - audit events data access object
+type AuditManager struct {
+}
+
+//Record - saves an audit event
+func (am AuditManager) Record(event *types.AuditEvent, db *db.MySQL) error {
+	event.ID = utils.RandomString()
+	event.Created = time.Now()
+	if len(event.IP) > 50 {
+		event.IP = event.IP[:50]
+	}
+	if len(event.Detail) > 255 {
+		event.Detail = event.Detail[:255]
+	}
+
+	return exec(db, "INSERT INTO auditEvents (id, accountId, actorId, event, detail, ip, created) VALUES(?,?,?,?,?,?,?)",
+		event.ID, event.AccountID, event.ActorID, event.Event, event.Detail, event.IP, event.Created)
+}
+
+//GetAccountEvents - returns the events of an account and the events it caused on other accounts
+func (am AuditManager) GetAccountEvents(accountID string, db *db.MySQL) (*[]types.AuditEvent, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM auditEvents WHERE accountId = ? OR actorId = ? ORDER BY created DESC")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(accountID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	events := []types.AuditEvent{}
+	for rows.Next() {
+		event := types.AuditEvent{}
+		err = sqlstruct.Scan(&event, rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return &events, nil
+}
@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"db"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//DeletionRequestManager - self-service account deletion data access object
+type DeletionRequestManager struct {
+}
+
+//CreateRequest - schedules the deletion of an account. Returns the plain token for the cancel link, only its hash is stored
+func (drm DeletionRequestManager) CreateRequest(account *types.Account, grace time.Duration, db *db.MySQL) (string, *types.DeletionRequest, error) {
+	token, err := utils.SecureString()
+	if err != nil {
+		return "", nil, err
+	}
+	request := types.DeletionRequest{ID: utils.HashToken(token), AccountID: account.ID, Email: account.Email, Created: time.Now()}
+	request.ScheduledFor = request.Created.Add(grace)
+
+	err = exec(db, "INSERT INTO deletionRequests (id, accountId, email, created, scheduledFor) VALUES(?,?,?,?,?)",
+		request.ID, request.AccountID, request.Email, request.Created, request.ScheduledFor)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, &request, nil
+}
+
+//getRequests - returns the deletion requests found by a query
+func (drm DeletionRequestManager) getRequests(db *db.MySQL, query string, args ...interface{}) ([]types.DeletionRequest, error) {
+	stmt, err := db.PreparedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	requests := []types.DeletionRequest{}
+	defer rows.Close()
+	for rows.Next() {
+		request := types.DeletionRequest{}
+		err = sqlstruct.Scan(&request, rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+//GetRequest - returns the pending deletion request of an account
+func (drm DeletionRequestManager) GetRequest(accountID string, db *db.MySQL) (*types.DeletionRequest, error) {
+	requests, err := drm.getRequests(db, "SELECT * FROM deletionRequests WHERE accountId = ?", accountID)
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+	return &requests[0], nil
+}
+
+//GetDueRequests - returns the deletion requests whose grace period has passed
+func (drm DeletionRequestManager) GetDueRequests(db *db.MySQL) ([]types.DeletionRequest, error) {
+	return drm.getRequests(db, "SELECT * FROM deletionRequests WHERE scheduledFor <= ?", time.Now())
+}
+
+//ConsumeRequest - returns a deletion request from the plain token of its cancel link and removes it so it can only be used once
+func (drm DeletionRequestManager) ConsumeRequest(token string, db *db.MySQL) (*types.DeletionRequest, error) {
+	requests, err := drm.getRequests(db, "SELECT * FROM deletionRequests WHERE id = ?", utils.HashToken(token))
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+
+	stmt, err := db.PreparedQuery("DELETE FROM deletionRequests WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	res, err := stmt.Exec(requests[0].ID)
+	stmt.Close()
+	if err != nil {
+		return nil, err
+	}
+	//Another request already used this link or the deletion was carried out
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, nil
+	}
+	return &requests[0], nil
+}
+
+//DeleteRequest - removes the deletion request of an account
+func (drm DeletionRequestManager) DeleteRequest(accountID string, db *db.MySQL) error {
+	return exec(db, "DELETE FROM deletionRequests WHERE accountId = ?", accountID)
+}
@@ -2,6 +2,7 @@ package manager
 
 import (
 	"cache"
+	"crypto/subtle"
 	"db"
 	"encoding/json"
 	"errors"
@@ -62,7 +63,11 @@ func (dm DeviceManager) GetDevice(session *types.Session, db *db.MySQL, cache *c
 
 //CreateDevice - creates a new device
 func (dm DeviceManager) CreateDevice(account *types.Account, db *db.MySQL) (*types.Device, error) {
-	device := types.Device{ID: utils.RandomString(), AccountID: account.ID, Created: time.Now(), Active: false, Code: utils.RandomCode()}
+	code, err := utils.SecureCode()
+	if err != nil {
+		return nil, err
+	}
+	device := types.Device{ID: utils.RandomString(), AccountID: account.ID, Created: time.Now(), Active: false, Code: code}
 
 	stmt, err := db.PreparedQuery("INSERT INTO devices (id, accountId, created, active, code) VALUES(?,?,?,?,?)")
 	if err != nil {
@@ -77,6 +82,48 @@ func (dm DeviceManager) CreateDevice(account *types.Account, db *db.MySQL) (*typ
 	return &device, nil
 }
 
+//NewCode - gives a device a fresh one-time code
+func (dm DeviceManager) NewCode(device *types.Device, db *db.MySQL, cache *cache.Cache) error {
+	code, err := utils.SecureCode()
+	if err != nil {
+		return err
+	}
+	err = exec(db, "UPDATE devices SET code = ? WHERE id = ?", code, device.ID)
+	if err != nil {
+		return err
+	}
+	device.Code = code
+	dm.SaveToCache(device, cache)
+	return nil
+}
+
+//ConsumeCode - checks the one-time code of a device. The code is replaced whether it matches or not so it can only be tried once
+func (dm DeviceManager) ConsumeCode(device *types.Device, code string, db *db.MySQL, cache *cache.Cache) (bool, error) {
+	valid := code != "" && subtle.ConstantTimeCompare([]byte(device.Code), []byte(code)) == 1
+
+	next, err := utils.SecureCode()
+	if err != nil {
+		return false, err
+	}
+	stmt, err := db.PreparedQuery("UPDATE devices SET code = ? WHERE id = ? AND code = ?")
+	if err != nil {
+		return false, err
+	}
+	res, err := stmt.Exec(next, device.ID, device.Code)
+	stmt.Close()
+	if err != nil {
+		return false, err
+	}
+	device.Code = next
+	dm.SaveToCache(device, cache)
+
+	//Another request already used this code
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	return valid, nil
+}
+
 //ActivateDevice - activates a device if the code is correct and the account matches the device
 func (dm DeviceManager) ActivateDevice(account *types.Account, deviceInfo *types.Device, db *db.MySQL, cache *cache.Cache) error {
 
@@ -0,0 +1,33 @@
+package manager
+
+import (
+	"cache"
+	"database/sql/driver"
+	"testing"
+	"types"
+)
+
+func TestConsumeCode(t *testing.T) {
+	db := newFakeDB(t, func(query string, args []driver.Value) fakeTable { return fakeTable{} })
+	dm := DeviceManager{}
+
+	device := &types.Device{ID: "device", Code: "123456"}
+	if valid, err := dm.ConsumeCode(device, "654321", db, &cache.Cache{}); err != nil || valid {
+		t.Errorf("wrong code: got %v, %v; want invalid", valid, err)
+	}
+	if device.Code == "123456" {
+		t.Error("code kept after a wrong attempt")
+	}
+
+	code := device.Code
+	if valid, err := dm.ConsumeCode(device, code, db, &cache.Cache{}); err != nil || !valid {
+		t.Errorf("right code: got %v, %v; want valid", valid, err)
+	}
+	if device.Code == code {
+		t.Error("code kept after it was used")
+	}
+
+	if valid, _ := dm.ConsumeCode(&types.Device{ID: "device"}, "", db, &cache.Cache{}); valid {
+		t.Error("empty code accepted")
+	}
+}
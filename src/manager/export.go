@@ -0,0 +1,180 @@
+package manager
+
+import (
+	"db"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//ExportManager - data exports data access object
+type ExportManager struct {
+}
+
+//CreateExport - stores a pending export. Returns the plain token for the download link, only its hash is stored
+func (em ExportManager) CreateExport(export *types.Export, db *db.MySQL) (string, error) {
+	token, err := utils.SecureString()
+	if err != nil {
+		return "", err
+	}
+	export.ID = utils.HashToken(token)
+	export.Status = types.ExportPending
+	export.Created = time.Now()
+
+	err = exec(db, "INSERT INTO exports (id, accountId, requestedBy, email, format, status, created) VALUES(?,?,?,?,?,?,?)",
+		export.ID, export.AccountID, export.RequestedBy, export.Email, export.Format, export.Status, export.Created)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+//getExports - returns the exports of a query
+func (em ExportManager) getExports(db *db.MySQL, query string, args ...interface{}) (*[]types.Export, error) {
+	stmt, err := db.PreparedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	exports := []types.Export{}
+	for rows.Next() {
+		export := types.Export{}
+		err = sqlstruct.Scan(&export, rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+	return &exports, nil
+}
+
+//GetExport - returns an export from the plain token of its link
+func (em ExportManager) GetExport(token string, db *db.MySQL) (*types.Export, error) {
+	exports, err := em.getExports(db, "SELECT * FROM exports WHERE id = ?", utils.HashToken(token))
+	if err != nil || len(*exports) == 0 {
+		return nil, err
+	}
+	return &(*exports)[0], nil
+}
+
+//GetExpiredExports - returns the exports created before the time given
+func (em ExportManager) GetExpiredExports(before time.Time, db *db.MySQL) (*[]types.Export, error) {
+	return em.getExports(db, "SELECT * FROM exports WHERE created < ?", before)
+}
+
+//FinishExport - saves the result of building an export
+func (em ExportManager) FinishExport(export *types.Export, db *db.MySQL) error {
+	now := time.Now()
+	export.Completed = &now
+
+	return exec(db, "UPDATE exports SET status = ?, file = ?, completed = ? WHERE id = ?", export.Status, export.File, export.Completed, export.ID)
+}
+
+//DeleteExport - removes an export
+func (em ExportManager) DeleteExport(export *types.Export, db *db.MySQL) error {
+	return exec(db, "DELETE FROM exports WHERE id = ?", export.ID)
+}
+
+//rows - returns every row of a query as column name to value. Hidden columns are left out
+func (em ExportManager) rows(db *db.MySQL, hidden []string, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	stmt, err := db.PreparedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := map[string]interface{}{}
+		for i, column := range columns {
+			if utils.Contains(column, hidden) {
+				continue
+			}
+			//Text columns are read as bytes
+			if b, ok := values[i].([]byte); ok {
+				row[column] = string(b)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+//Collect - returns the data held about an account. Passwords, tokens, codes and the ids that work as tokens are left out
+func (em ExportManager) Collect(account *types.Account, db *db.MySQL) (map[string]interface{}, error) {
+	sections := []struct {
+		name   string
+		hidden []string
+		query  string
+	}{
+		{"profile", []string{"password", "token"}, "SELECT * FROM users WHERE id = ?"},
+		{"identities", nil, "SELECT * FROM identities WHERE accountId = ?"},
+		{"devices", []string{"id", "code"}, "SELECT * FROM devices WHERE accountId = ?"},
+		{"personalTokens", []string{"tokenHash"}, "SELECT * FROM personalTokens WHERE accountId = ?"},
+		{"oauthTokens", []string{"id"}, "SELECT * FROM oauthTokens WHERE accountId = ?"},
+		{"roles", nil, "SELECT r.name, r.description FROM accountRoles ar JOIN roles r ON r.id = ar.roleId WHERE ar.accountId = ?"},
+		{"groups", nil, "SELECT g.name, g.description FROM groupMembers gm JOIN accountGroups g ON g.id = gm.groupId WHERE gm.accountId = ?"},
+		{"attributes", nil, "SELECT d.name, d.label, a.value FROM accountAttributes a JOIN attributeDefinitions d ON d.id = a.attributeId WHERE a.accountId = ?"},
+		{"organizations", nil, "SELECT o.name, o.slug, m.role, m.created FROM orgMembers m JOIN organizations o ON o.id = m.orgId WHERE m.accountId = ?"},
+		{"loginHistory", []string{"id"}, "SELECT * FROM auditEvents WHERE accountId = ? AND event IN ('" + types.AuditLogin + "','" + types.AuditLoginFailed + "') ORDER BY created DESC"},
+		{"auditEvents", []string{"id"}, "SELECT * FROM auditEvents WHERE accountId = ? ORDER BY created DESC"},
+		{"passwordRecoveries", []string{"id"}, "SELECT * FROM recover WHERE accountId = ?"},
+		{"emailChanges", []string{"id"}, "SELECT * FROM emailChange WHERE accountId = ?"},
+		{"magicLinks", []string{"id"}, "SELECT * FROM magicLinks WHERE accountId = ?"},
+		{"phoneVerifications", []string{"id", "code"}, "SELECT * FROM phoneVerifications WHERE accountId = ?"},
+		{"emailVerifications", []string{"id"}, "SELECT * FROM emailVerifications WHERE accountId = ?"},
+		{"exports", []string{"id", "file"}, "SELECT * FROM exports WHERE accountId = ?"},
+	}
+
+	bundle := map[string]interface{}{"generated": time.Now()}
+	pending := map[string]interface{}{}
+	sessions := map[string]interface{}{}
+	for _, section := range sections {
+		rows, err := em.rows(db, section.hidden, section.query, account.ID)
+		if err != nil {
+			return nil, err
+		}
+		switch section.name {
+		case "profile":
+			if len(rows) > 0 {
+				bundle["profile"] = rows[0]
+			}
+		case "personalTokens", "oauthTokens":
+			sessions[section.name] = rows
+		case "passwordRecoveries", "emailChanges", "magicLinks", "phoneVerifications", "emailVerifications", "exports":
+			pending[section.name] = rows
+		default:
+			bundle[section.name] = rows
+		}
+	}
+	bundle["sessions"] = sessions
+	bundle["pendingRequests"] = pending
+
+	return bundle, nil
+}
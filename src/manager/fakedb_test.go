@@ -0,0 +1,103 @@
+package manager
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"db"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//fakeTable - rows a fake query returns
+type fakeTable struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+//fakeHandler - answers the queries of a test. Statements that return no rows get an empty table
+type fakeHandler func(query string, args []driver.Value) fakeTable
+
+var (
+	fakeMu       sync.Mutex
+	fakeHandlers = map[string]fakeHandler{}
+)
+
+func init() {
+	sql.Register("fake", fakeDriver{})
+}
+
+//newFakeDB - returns a database whose queries are answered by the handler
+func newFakeDB(t *testing.T, handler fakeHandler) *db.MySQL {
+	fakeMu.Lock()
+	name := t.Name() + strconv.Itoa(len(fakeHandlers))
+	fakeHandlers[name] = handler
+	fakeMu.Unlock()
+
+	pool, err := sql.Open("fake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	return db.MySQL{}.InitPool(pool)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	return fakeConn{handler: fakeHandlers[name]}, nil
+}
+
+type fakeConn struct {
+	handler fakeHandler
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{handler: c.handler, query: query}, nil
+}
+func (c fakeConn) Close() error              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	handler fakeHandler
+	query   string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return strings.Count(s.query, "?") }
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.handler(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	table := s.handler(s.query, args)
+	if table.columns == nil {
+		table.columns = []string{"id"}
+	}
+	return &fakeRows{table: table}, nil
+}
+
+type fakeRows struct {
+	table fakeTable
+	next  int
+}
+
+func (r *fakeRows) Columns() []string { return r.table.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.table.rows) {
+		return io.EOF
+	}
+	copy(dest, r.table.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,304 @@
+package manager
+
+import (
+	"db"
+	"strings"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//GroupManager - groups data access object
+type GroupManager struct {
+}
+
+//GetGroups - returns every group with its roles, nested groups and members
+func (gm GroupManager) GetGroups(db *db.MySQL) (*[]types.Group, error) {
+	rows, err := db.SimpleQuery("SELECT * FROM accountGroups ORDER BY name ASC")
+	if err != nil {
+		return nil, err
+	}
+	groups := []types.Group{}
+	defer rows.Close()
+	for rows.Next() {
+		group := types.Group{Roles: []string{}, Groups: []string{}, Members: []string{}}
+		err := sqlstruct.Scan(&group, rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	roles, err := pairs(db, "SELECT gr.groupId, r.name FROM groupRoles gr JOIN roles r ON r.id = gr.roleId ORDER BY r.name ASC")
+	if err != nil {
+		return nil, err
+	}
+	nested, err := pairs(db, "SELECT groupId, memberGroupId FROM groupNesting")
+	if err != nil {
+		return nil, err
+	}
+	members, err := pairs(db, "SELECT groupId, accountId FROM groupMembers")
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		for _, pair := range roles {
+			if pair[0] == groups[i].ID {
+				groups[i].Roles = append(groups[i].Roles, pair[1])
+			}
+		}
+		for _, pair := range nested {
+			if pair[0] == groups[i].ID {
+				groups[i].Groups = append(groups[i].Groups, pair[1])
+			}
+		}
+		for _, pair := range members {
+			if pair[0] == groups[i].ID {
+				groups[i].Members = append(groups[i].Members, pair[1])
+			}
+		}
+	}
+
+	return &groups, nil
+}
+
+//GetGroup - returns a group by id
+func (gm GroupManager) GetGroup(id string, db *db.MySQL) (*types.Group, error) {
+	groups, err := gm.GetGroups(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range *groups {
+		if group.ID == id {
+			return &group, nil
+		}
+	}
+	return nil, nil
+}
+
+//CheckDuplicateGroup - returns a reason if another group has the same name
+func (gm GroupManager) CheckDuplicateGroup(group *types.Group, db *db.MySQL) (string, error) {
+	found, err := pairs(db, "SELECT id, name FROM accountGroups WHERE name = ? AND id <> ?", group.Name, group.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(found) > 0 {
+		return "Group name already exists", nil
+	}
+	return "", nil
+}
+
+//CreateGroup - creates a group with its roles
+func (gm GroupManager) CreateGroup(group *types.Group, db *db.MySQL) error {
+	group.ID = utils.RandomString()
+	group.Created = time.Now()
+
+	err := exec(db, "INSERT INTO accountGroups (id, name, description, created) VALUES(?,?,?,?)", group.ID, group.Name, group.Description, group.Created)
+	if err != nil {
+		return err
+	}
+
+	return gm.setGroupRoles(group, db)
+}
+
+//UpdateGroup - updates the name, description and roles of a group
+func (gm GroupManager) UpdateGroup(group *types.Group, db *db.MySQL) error {
+	err := exec(db, "UPDATE accountGroups SET name = ?, description = ? WHERE id = ?", group.Name, group.Description, group.ID)
+	if err != nil {
+		return err
+	}
+
+	return gm.setGroupRoles(group, db)
+}
+
+//setGroupRoles - replaces the roles of a group. Unknown role names are ignored
+func (gm GroupManager) setGroupRoles(group *types.Group, db *db.MySQL) error {
+	err := exec(db, "DELETE FROM groupRoles WHERE groupId = ?", group.ID)
+	if err != nil {
+		return err
+	}
+	if len(group.Roles) == 0 {
+		return nil
+	}
+
+	args := []interface{}{group.ID}
+	for _, role := range group.Roles {
+		args = append(args, role)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(group.Roles)), ",")
+	return exec(db, "INSERT IGNORE INTO groupRoles (groupId, roleId) SELECT ?, id FROM roles WHERE name IN ("+placeholders+")", args...)
+}
+
+//DeleteGroup - deletes a group, its memberships and its roles
+func (gm GroupManager) DeleteGroup(group *types.Group, db *db.MySQL) error {
+	queries := []string{
+		"DELETE FROM groupMembers WHERE groupId = ?",
+		"DELETE FROM groupRoles WHERE groupId = ?",
+		"DELETE FROM groupNesting WHERE groupId = ? OR memberGroupId = ?",
+		"DELETE FROM accountGroups WHERE id = ?",
+	}
+	for _, query := range queries {
+		args := []interface{}{group.ID}
+		if strings.Count(query, "?") == 2 {
+			args = append(args, group.ID)
+		}
+		if err := exec(db, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//AddMember - adds an account to a group
+func (gm GroupManager) AddMember(groupID string, accountID string, db *db.MySQL) error {
+	return exec(db, "INSERT IGNORE INTO groupMembers (groupId, accountId) VALUES(?,?)", groupID, accountID)
+}
+
+//RemoveMember - removes an account from a group
+func (gm GroupManager) RemoveMember(groupID string, accountID string, db *db.MySQL) error {
+	return exec(db, "DELETE FROM groupMembers WHERE groupId = ? AND accountId = ?", groupID, accountID)
+}
+
+//RemoveAccount - removes an account from every group
+func (gm GroupManager) RemoveAccount(accountID string, db *db.MySQL) error {
+	return exec(db, "DELETE FROM groupMembers WHERE accountId = ?", accountID)
+}
+
+//AddGroup - nests a group in another. Returns a reason if it would make a cycle
+func (gm GroupManager) AddGroup(groupID string, memberGroupID string, db *db.MySQL) (string, error) {
+	parents, err := gm.parents(db)
+	if err != nil {
+		return "", err
+	}
+	if CreatesCycle(groupID, memberGroupID, parents) {
+		return "Group cannot contain itself", nil
+	}
+
+	return "", exec(db, "INSERT IGNORE INTO groupNesting (groupId, memberGroupId) VALUES(?,?)", groupID, memberGroupID)
+}
+
+//RemoveGroup - removes a nested group from a group
+func (gm GroupManager) RemoveGroup(groupID string, memberGroupID string, db *db.MySQL) error {
+	return exec(db, "DELETE FROM groupNesting WHERE groupId = ? AND memberGroupId = ?", groupID, memberGroupID)
+}
+
+//parents - returns the groups each group is nested in
+func (gm GroupManager) parents(db *db.MySQL) (map[string][]string, error) {
+	nested, err := pairs(db, "SELECT groupId, memberGroupId FROM groupNesting")
+	if err != nil {
+		return nil, err
+	}
+	parents := map[string][]string{}
+	for _, pair := range nested {
+		parents[pair[1]] = append(parents[pair[1]], pair[0])
+	}
+	return parents, nil
+}
+
+//CreatesCycle - checks if nesting a group in another would make a group contain itself.
+//It would if the group is the new member or already nested somewhere inside it
+func CreatesCycle(groupID string, memberGroupID string, parents map[string][]string) bool {
+	for _, ancestor := range ExpandGroups([]string{groupID}, parents) {
+		if ancestor == memberGroupID {
+			return true
+		}
+	}
+	return false
+}
+
+//ExpandGroups - returns the groups given and every group containing them. Safe with cycles
+func ExpandGroups(groupIDs []string, parents map[string][]string) []string {
+	seen := map[string]bool{}
+	expanded := []string{}
+	queue := append([]string{}, groupIDs...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		expanded = append(expanded, id)
+		queue = append(queue, parents[id]...)
+	}
+	return expanded
+}
+
+//EffectiveGroups - returns the groups an account is in directly or through nesting
+func (gm GroupManager) EffectiveGroups(accountID string, db *db.MySQL) ([]string, error) {
+	direct, err := pairs(db, "SELECT groupId, accountId FROM groupMembers WHERE accountId = ?", accountID)
+	if err != nil {
+		return nil, err
+	}
+	if len(direct) == 0 {
+		return []string{}, nil
+	}
+	parents, err := gm.parents(db)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIDs := []string{}
+	for _, pair := range direct {
+		groupIDs = append(groupIDs, pair[0])
+	}
+	return ExpandGroups(groupIDs, parents), nil
+}
+
+//EffectiveRoles - returns the role names a group gives its members, including the ones of the groups containing it
+func (gm GroupManager) EffectiveRoles(groupID string, db *db.MySQL) ([]string, error) {
+	parents, err := gm.parents(db)
+	if err != nil {
+		return nil, err
+	}
+	placeholders, args := RoleManager{}.groupArgs(ExpandGroups([]string{groupID}, parents))
+	roles, err := pairs(db, "SELECT DISTINCT r.name, r.`rank` FROM groupRoles gr JOIN roles r ON r.id = gr.roleId WHERE gr.groupId IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, pair := range roles {
+		names = append(names, pair[0])
+	}
+	return names, nil
+}
+
+//GroupRoleNames - returns the role names every account gets from its groups
+func (gm GroupManager) GroupRoleNames(db *db.MySQL) (map[string][]string, error) {
+	members, err := pairs(db, "SELECT groupId, accountId FROM groupMembers")
+	if err != nil {
+		return nil, err
+	}
+	roles, err := pairs(db, "SELECT gr.groupId, r.name FROM groupRoles gr JOIN roles r ON r.id = gr.roleId")
+	if err != nil {
+		return nil, err
+	}
+	parents, err := gm.parents(db)
+	if err != nil {
+		return nil, err
+	}
+
+	groupRoles := map[string][]string{}
+	for _, pair := range roles {
+		groupRoles[pair[0]] = append(groupRoles[pair[0]], pair[1])
+	}
+	accountGroups := map[string][]string{}
+	for _, pair := range members {
+		accountGroups[pair[1]] = append(accountGroups[pair[1]], pair[0])
+	}
+
+	names := map[string][]string{}
+	for accountID, groupIDs := range accountGroups {
+		for _, groupID := range ExpandGroups(groupIDs, parents) {
+			for _, role := range groupRoles[groupID] {
+				if !utils.Contains(role, names[accountID]) {
+					names[accountID] = append(names[accountID], role)
+				}
+			}
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,185 @@
+package manager
+
+import (
+	"db"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//IdentityManager - external identities data access object
+type IdentityManager struct {
+}
+
+//CreateState - saves a pending login with an external provider
+func (im IdentityManager) CreateState(state *types.FederatedState, db *db.MySQL) error {
+	stmt, err := db.PreparedQuery("INSERT INTO federatedStates (id, provider, nonce, verifier, continueUrl, accountId, created) VALUES(?,?,?,?,?,?,?)")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(state.ID, state.Provider, state.Nonce, state.Verifier, state.Continue, state.AccountID, state.Created)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return nil
+}
+
+//ConsumeState - returns a pending login and removes it so it can only be used once
+func (im IdentityManager) ConsumeState(id string, db *db.MySQL) (*types.FederatedState, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM federatedStates WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		state := types.FederatedState{}
+		err = sqlstruct.Scan(&state, rows)
+		if err != nil {
+			return nil, err
+		}
+		del, err := db.PreparedQuery("DELETE FROM federatedStates WHERE id = ?")
+		if err != nil {
+			return nil, err
+		}
+		res, err := del.Exec(state.ID)
+		del.Close()
+		if err != nil {
+			return nil, err
+		}
+		//Another request already used this state
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil, nil
+		}
+		return &state, nil
+	}
+	return nil, nil
+}
+
+//GetIdentity - returns the identity of a provider subject
+func (im IdentityManager) GetIdentity(provider string, subject string, db *db.MySQL) (*types.Identity, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM identities WHERE provider = ? AND subject = ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(provider, subject)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		identity := types.Identity{}
+		err = sqlstruct.Scan(&identity, rows)
+		if err != nil {
+			return nil, err
+		}
+		return &identity, nil
+	}
+	return nil, nil
+}
+
+//GetIdentityByID - returns an identity by id
+func (im IdentityManager) GetIdentityByID(id string, db *db.MySQL) (*types.Identity, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM identities WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		identity := types.Identity{}
+		err = sqlstruct.Scan(&identity, rows)
+		if err != nil {
+			return nil, err
+		}
+		return &identity, nil
+	}
+	return nil, nil
+}
+
+//GetAccountIdentities - returns all identities linked to an account
+func (im IdentityManager) GetAccountIdentities(account *types.Account, db *db.MySQL) (*[]types.Identity, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM identities WHERE accountId = ? ORDER BY created ASC")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	identities := []types.Identity{}
+	defer rows.Close()
+	for rows.Next() {
+		identity := types.Identity{}
+		err = sqlstruct.Scan(&identity, rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return &identities, nil
+}
+
+//CreateIdentity - links an identity to an account
+func (im IdentityManager) CreateIdentity(identity *types.Identity, db *db.MySQL) error {
+	identity.ID = utils.RandomString()
+	identity.Created = time.Now()
+
+	stmt, err := db.PreparedQuery("INSERT INTO identities (id, accountId, type, provider, subject, email, publicKey, signCount, created) VALUES(?,?,?,?,?,?,?,?,?)")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(identity.ID, identity.AccountID, identity.Type, identity.Provider, identity.Subject, identity.Email, identity.PublicKey, identity.SignCount, identity.Created)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return nil
+}
+
+//CreatePasswordIdentity - records that the account can login with a password. Does nothing if it already can
+func (im IdentityManager) CreatePasswordIdentity(account *types.Account, db *db.MySQL) error {
+	identity, err := im.GetIdentity(types.LocalProvider, account.ID, db)
+	if err != nil {
+		return err
+	}
+	if identity != nil {
+		return nil
+	}
+	return im.CreateIdentity(&types.Identity{AccountID: account.ID, Type: types.IdentityPassword, Provider: types.LocalProvider, Subject: account.ID, Email: account.Email}, db)
+}
+
+//UpdateSignCount - saves the signature counter of a passkey identity
+func (im IdentityManager) UpdateSignCount(identity *types.Identity, db *db.MySQL) error {
+	return exec(db, "UPDATE identities SET signCount = ? WHERE id = ?", identity.SignCount, identity.ID)
+}
+
+//DeleteIdentity - unlinks an identity from its account
+func (im IdentityManager) DeleteIdentity(identity *types.Identity, db *db.MySQL) error {
+	stmt, err := db.PreparedQuery("DELETE FROM identities WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(identity.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return nil
+}
@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"db"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//InviteManager - invites data access object
+type InviteManager struct {
+}
+
+//CreateInvite - stores a new invite that expires after the duration given
+func (im InviteManager) CreateInvite(invite *types.Invite, ttl time.Duration, db *db.MySQL) error {
+	invite.ID = utils.RandomString()
+	invite.Created = time.Now()
+	invite.Expires = invite.Created.Add(ttl).Truncate(time.Second)
+
+	return exec(db, "INSERT INTO invites (id, email, name, role, orgId, invitedBy, created, expires) VALUES(?,?,?,?,?,?,?,?)",
+		invite.ID, invite.Email, invite.Name, invite.Role, invite.OrgID, invite.InvitedBy, invite.Created, invite.Expires)
+}
+
+//getInvites - returns the invites of a query
+func (im InviteManager) getInvites(db *db.MySQL, query string, args ...interface{}) (*[]types.Invite, error) {
+	stmt, err := db.PreparedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	invites := []types.Invite{}
+	for rows.Next() {
+		invite := types.Invite{}
+		err = sqlstruct.Scan(&invite, rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	return &invites, nil
+}
+
+//GetInvites - returns the pending invites. Only those of the organization if one is given
+func (im InviteManager) GetInvites(orgID string, db *db.MySQL) (*[]types.Invite, error) {
+	if orgID == "" {
+		return im.getInvites(db, "SELECT * FROM invites ORDER BY created DESC")
+	}
+	return im.getInvites(db, "SELECT * FROM invites WHERE orgId = ? ORDER BY created DESC", orgID)
+}
+
+//GetInvite - returns an invite by id
+func (im InviteManager) GetInvite(id string, db *db.MySQL) (*types.Invite, error) {
+	invites, err := im.getInvites(db, "SELECT * FROM invites WHERE id = ?", id)
+	if err != nil || len(*invites) == 0 {
+		return nil, err
+	}
+	return &(*invites)[0], nil
+}
+
+//GetInviteByEmail - returns the pending invite sent to an email in an organization
+func (im InviteManager) GetInviteByEmail(email string, orgID string, db *db.MySQL) (*types.Invite, error) {
+	invites, err := im.getInvites(db, "SELECT * FROM invites WHERE email = ? AND orgId = ?", email, orgID)
+	if err != nil || len(*invites) == 0 {
+		return nil, err
+	}
+	return &(*invites)[0], nil
+}
+
+//RenewInvite - gives an invite a new id and expiry. Links sent before stop working
+func (im InviteManager) RenewInvite(invite *types.Invite, ttl time.Duration, db *db.MySQL) error {
+	oldID := invite.ID
+	invite.ID = utils.RandomString()
+	invite.Expires = time.Now().Add(ttl).Truncate(time.Second)
+
+	return exec(db, "UPDATE invites SET id = ?, expires = ? WHERE id = ?", invite.ID, invite.Expires, oldID)
+}
+
+//DeleteInvite - removes an invite
+func (im InviteManager) DeleteInvite(id string, db *db.MySQL) error {
+	return exec(db, "DELETE FROM invites WHERE id = ?", id)
+}
@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"db"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//MagicLinkManager - magic link data access object
+type MagicLinkManager struct {
+}
+
+//CreateMagicLink - creates a new magic link. Returns the plain token, only its hash is stored
+func (mm MagicLinkManager) CreateMagicLink(account *types.Account, db *db.MySQL) (string, *types.MagicLink, error) {
+	token, err := utils.SecureString()
+	if err != nil {
+		return "", nil, err
+	}
+	link := types.MagicLink{ID: utils.HashToken(token), AccountID: account.ID, Email: account.Email, Created: time.Now()}
+
+	stmt, err := db.PreparedQuery("INSERT INTO magicLinks (id, accountId, email, created) VALUES(?,?,?,?)")
+	if err != nil {
+		return "", nil, err
+	}
+	rows, err := stmt.Query(link.ID, link.AccountID, link.Email, link.Created)
+	if err != nil {
+		return "", nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+
+	return token, &link, nil
+}
+
+//ConsumeMagicLink - returns a magic link from its plain token and removes it so it can only be used once
+func (mm MagicLinkManager) ConsumeMagicLink(token string, db *db.MySQL) (*types.MagicLink, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM magicLinks WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(utils.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		link := types.MagicLink{}
+		err = sqlstruct.Scan(&link, rows)
+		if err != nil {
+			return nil, err
+		}
+		del, err := db.PreparedQuery("DELETE FROM magicLinks WHERE id = ?")
+		if err != nil {
+			return nil, err
+		}
+		res, err := del.Exec(link.ID)
+		del.Close()
+		if err != nil {
+			return nil, err
+		}
+		//Another request already used this link
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil, nil
+		}
+		return &link, nil
+	}
+	return nil, nil
+}
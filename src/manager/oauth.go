@@ -0,0 +1,311 @@
+package manager
+
+import (
+	"db"
+	"strings"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//OAuthManager - oauth clients, codes and tokens data access object
+type OAuthManager struct {
+}
+
+//CreateClient - registers a new client. Returns the client and its plain secret
+func (om OAuthManager) CreateClient(request *types.CreateClientRequest, db *db.MySQL) (*types.OAuthClient, string, error) {
+	client := types.OAuthClient{
+		ID:           utils.RandomString(),
+		Name:         request.Name,
+		RedirectURIs: strings.Join(request.RedirectURIs, " "),
+		LogoutURIs:   strings.Join(request.LogoutURIs, " "),
+		GrantTypes:   strings.Join(request.GrantTypes, " "),
+		Scopes:       strings.Join(request.Scopes, " "),
+		Created:      time.Now(),
+	}
+
+	secret := ""
+	if !request.Public {
+		var err error
+		secret, err = utils.SecureString()
+		if err != nil {
+			return nil, "", err
+		}
+		hash, err := utils.HashPassword(secret)
+		if err != nil {
+			return nil, "", err
+		}
+		client.Secret = hash
+	}
+
+	stmt, err := db.PreparedQuery("INSERT INTO oauthClients (id, secret, name, redirectUris, logoutUris, grantTypes, scopes, revoked, created) VALUES(?,?,?,?,?,?,?,?,?)")
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := stmt.Query(client.ID, client.Secret, client.Name, client.RedirectURIs, client.LogoutURIs, client.GrantTypes, client.Scopes, client.Revoked, client.Created)
+	if err != nil {
+		return nil, "", err
+	}
+	stmt.Close()
+	defer rows.Close()
+
+	return &client, secret, nil
+}
+
+//RotateSecret - replaces the secret of a client. Returns the new plain secret
+func (om OAuthManager) RotateSecret(client *types.OAuthClient, db *db.MySQL) (string, error) {
+	secret, err := utils.SecureString()
+	if err != nil {
+		return "", err
+	}
+	hash, err := utils.HashPassword(secret)
+	if err != nil {
+		return "", err
+	}
+	client.Secret = hash
+
+	stmt, err := db.PreparedQuery("UPDATE oauthClients SET secret = ? WHERE id = ?")
+	if err != nil {
+		return "", err
+	}
+	rows, err := stmt.Query(client.Secret, client.ID)
+	if err != nil {
+		return "", err
+	}
+	stmt.Close()
+	defer rows.Close()
+
+	return secret, nil
+}
+
+//RevokeClient - revokes a client and removes all of its tokens
+func (om OAuthManager) RevokeClient(client *types.OAuthClient, db *db.MySQL) error {
+	stmt, err := db.PreparedQuery("UPDATE oauthClients SET revoked = 1 WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(client.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	client.Revoked = true
+
+	return om.DeleteClientTokens(client, db)
+}
+
+//DeleteClientTokens - removes all tokens issued to a client
+func (om OAuthManager) DeleteClientTokens(client *types.OAuthClient, db *db.MySQL) error {
+	stmt, err := db.PreparedQuery("DELETE FROM oauthTokens WHERE clientId = ?")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(client.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return nil
+}
+
+//GetClient - returns a client by id
+func (om OAuthManager) GetClient(id string, db *db.MySQL) (*types.OAuthClient, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM oauthClients WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		client := types.OAuthClient{}
+		err = sqlstruct.Scan(&client, rows)
+		if err != nil {
+			return nil, err
+		}
+		return &client, nil
+	}
+	return nil, nil
+}
+
+//GetAllClients - returns all registered clients
+func (om OAuthManager) GetAllClients(db *db.MySQL) (*[]types.OAuthClient, error) {
+	rows, err := db.SimpleQuery("SELECT * FROM oauthClients ORDER BY name ASC")
+	if err != nil {
+		return nil, err
+	}
+	clients := []types.OAuthClient{}
+	defer rows.Close()
+	for rows.Next() {
+		client := types.OAuthClient{}
+		err := sqlstruct.Scan(&client, rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return &clients, nil
+}
+
+//CreateCode - saves a new authorization code
+func (om OAuthManager) CreateCode(code *types.OAuthCode, db *db.MySQL) error {
+	stmt, err := db.PreparedQuery("INSERT INTO oauthCodes (id, clientId, accountId, redirectUri, scope, nonce, codeChallenge, created) VALUES(?,?,?,?,?,?,?,?)")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(code.ID, code.ClientID, code.AccountID, code.RedirectURI, code.Scope, code.Nonce, code.CodeChallenge, code.Created)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return nil
+}
+
+//ConsumeCode - returns an authorization code and removes it so it can only be used once
+func (om OAuthManager) ConsumeCode(id string, db *db.MySQL) (*types.OAuthCode, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM oauthCodes WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		code := types.OAuthCode{}
+		err = sqlstruct.Scan(&code, rows)
+		if err != nil {
+			return nil, err
+		}
+		del, err := db.PreparedQuery("DELETE FROM oauthCodes WHERE id = ?")
+		if err != nil {
+			return nil, err
+		}
+		res, err := del.Exec(code.ID)
+		del.Close()
+		if err != nil {
+			return nil, err
+		}
+		//Another request already used this code
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil, nil
+		}
+		return &code, nil
+	}
+	return nil, nil
+}
+
+//CreateToken - issues a new token. Returns the plain token, only its hash is stored
+func (om OAuthManager) CreateToken(tokenType string, clientID string, accountID string, scope string, ttl int, db *db.MySQL) (string, *types.OAuthToken, error) {
+	plain, err := utils.SecureString()
+	if err != nil {
+		return "", nil, err
+	}
+	token := types.OAuthToken{
+		ID:        utils.HashToken(plain),
+		Type:      tokenType,
+		ClientID:  clientID,
+		AccountID: accountID,
+		Scope:     scope,
+		Expires:   time.Now().Add(time.Duration(ttl) * time.Second),
+		Created:   time.Now(),
+	}
+
+	stmt, err := db.PreparedQuery("INSERT INTO oauthTokens (id, type, clientId, accountId, scope, expires, created) VALUES(?,?,?,?,?,?,?)")
+	if err != nil {
+		return "", nil, err
+	}
+	rows, err := stmt.Query(token.ID, token.Type, token.ClientID, token.AccountID, token.Scope, token.Expires, token.Created)
+	if err != nil {
+		return "", nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+
+	return plain, &token, nil
+}
+
+//GetToken - returns an unexpired token from its plain value
+func (om OAuthManager) GetToken(plain string, db *db.MySQL) (*types.OAuthToken, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM oauthTokens WHERE id = ? AND expires > ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(utils.HashToken(plain), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		token := types.OAuthToken{}
+		err = sqlstruct.Scan(&token, rows)
+		if err != nil {
+			return nil, err
+		}
+		return &token, nil
+	}
+	return nil, nil
+}
+
+//ConsumeToken - returns an unexpired token from its plain value and removes it so it can only be used once
+func (om OAuthManager) ConsumeToken(plain string, db *db.MySQL) (*types.OAuthToken, error) {
+	token, err := om.GetToken(plain, db)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	stmt, err := db.PreparedQuery("DELETE FROM oauthTokens WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	res, err := stmt.Exec(token.ID)
+	stmt.Close()
+	if err != nil {
+		return nil, err
+	}
+	//Another request already used this token
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, nil
+	}
+	return token, nil
+}
+
+//DeleteToken - removes a token
+func (om OAuthManager) DeleteToken(token *types.OAuthToken, db *db.MySQL) error {
+	stmt, err := db.PreparedQuery("DELETE FROM oauthTokens WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(token.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return nil
+}
+
+//DeleteAccountTokens - removes all tokens a client holds for an account
+func (om OAuthManager) DeleteAccountTokens(clientID string, accountID string, db *db.MySQL) error {
+	stmt, err := db.PreparedQuery("DELETE FROM oauthTokens WHERE clientId = ? AND accountId = ?")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(clientID, accountID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return nil
+}
@@ -0,0 +1,160 @@
+package manager
+
+import (
+	"db"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//OrgManager - organizations data access object
+type OrgManager struct {
+}
+
+//CreateOrg - creates an organization with the owner as its admin
+func (om OrgManager) CreateOrg(org *types.Organization, owner *types.Account, db *db.MySQL) (string, error) {
+	existing, err := om.GetOrgBySlug(org.Slug, db)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return "Slug is taken: " + org.Slug, nil
+	}
+
+	org.ID = utils.RandomString()
+	org.Role = types.LevelAdmin
+	org.Created = time.Now()
+
+	stmt, err := db.PreparedQuery("INSERT INTO organizations (id, name, slug, created) VALUES(?,?,?,?)")
+	if err != nil {
+		return "", err
+	}
+	_, err = stmt.Exec(org.ID, org.Name, org.Slug, org.Created)
+	if err != nil {
+		return "", err
+	}
+	stmt.Close()
+
+	err = om.AddMember(&types.OrgMember{OrgID: org.ID, AccountID: owner.ID, Role: types.LevelAdmin}, db)
+	if err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+//getOrg - returns the first organization of a query
+func (om OrgManager) getOrg(query string, value string, db *db.MySQL) (*types.Organization, error) {
+	stmt, err := db.PreparedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(value)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		org := types.Organization{}
+		err = sqlstruct.Scan(&org, rows)
+		if err != nil {
+			return nil, err
+		}
+		return &org, nil
+	}
+	return nil, nil
+}
+
+//GetOrg - returns an organization by id
+func (om OrgManager) GetOrg(id string, db *db.MySQL) (*types.Organization, error) {
+	return om.getOrg("SELECT * FROM organizations WHERE id = ?", id, db)
+}
+
+//GetOrgBySlug - returns an organization by slug
+func (om OrgManager) GetOrgBySlug(slug string, db *db.MySQL) (*types.Organization, error) {
+	return om.getOrg("SELECT * FROM organizations WHERE slug = ?", slug, db)
+}
+
+//GetAccountOrgs - returns the organizations of an account with its role in each
+func (om OrgManager) GetAccountOrgs(account *types.Account, db *db.MySQL) (*[]types.Organization, error) {
+	stmt, err := db.PreparedQuery("SELECT o.id, o.name, o.slug, o.created, m.role FROM organizations o JOIN orgMembers m ON m.orgId = o.id WHERE m.accountId = ? ORDER BY o.name ASC")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	orgs := []types.Organization{}
+	for rows.Next() {
+		org := types.Organization{}
+		err = sqlstruct.Scan(&org, rows)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+	return &orgs, nil
+}
+
+//GetMember - returns the membership of an account in an organization. Nil if it is not a member
+func (om OrgManager) GetMember(orgID string, accountID string, db *db.MySQL) (*types.OrgMember, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM orgMembers WHERE orgId = ? AND accountId = ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(orgID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		member := types.OrgMember{}
+		err = sqlstruct.Scan(&member, rows)
+		if err != nil {
+			return nil, err
+		}
+		return &member, nil
+	}
+	return nil, nil
+}
+
+//AddMember - adds an account to an organization or changes its role there
+func (om OrgManager) AddMember(member *types.OrgMember, db *db.MySQL) error {
+	member.Created = time.Now()
+
+	stmt, err := db.PreparedQuery("INSERT INTO orgMembers (orgId, accountId, role, created) VALUES(?,?,?,?) ON DUPLICATE KEY UPDATE role = VALUES(role)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(member.OrgID, member.AccountID, member.Role, member.Created)
+	return err
+}
+
+//RemoveMember - removes an account from an organization. Clears it as the active organization of the account
+func (om OrgManager) RemoveMember(orgID string, accountID string, db *db.MySQL) error {
+	stmt, err := db.PreparedQuery("DELETE FROM orgMembers WHERE orgId = ? AND accountId = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(orgID, accountID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+
+	stmt, err = db.PreparedQuery("UPDATE users SET activeOrg = '' WHERE id = ? AND activeOrg = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(accountID, orgID)
+	return err
+}
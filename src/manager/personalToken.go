@@ -0,0 +1,126 @@
+package manager
+
+import (
+	"db"
+	"strings"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//PersonalTokenManager - personal access tokens data access object
+type PersonalTokenManager struct {
+}
+
+//CreateToken - creates a personal access token. Returns the plain token, only its hash is stored
+func (pm PersonalTokenManager) CreateToken(account *types.Account, request *types.PersonalTokenRequest, db *db.MySQL) (string, *types.PersonalToken, error) {
+	secret, err := utils.SecureString()
+	if err != nil {
+		return "", nil, err
+	}
+	plain := types.PersonalTokenPrefix + secret
+	token := types.PersonalToken{
+		ID:        utils.RandomString(),
+		AccountID: account.ID,
+		Name:      request.Name,
+		TokenHash: utils.HashToken(plain),
+		Scopes:    strings.Join(request.Scopes, " "),
+		Expires:   time.Now().AddDate(0, 0, request.ExpiresIn),
+		Created:   time.Now(),
+	}
+
+	stmt, err := db.PreparedQuery("INSERT INTO personalTokens (id, accountId, name, tokenHash, scopes, expires, created) VALUES(?,?,?,?,?,?,?)")
+	if err != nil {
+		return "", nil, err
+	}
+	rows, err := stmt.Query(token.ID, token.AccountID, token.Name, token.TokenHash, token.Scopes, token.Expires, token.Created)
+	if err != nil {
+		return "", nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+
+	return plain, &token, nil
+}
+
+//GetToken - returns an unexpired token from its plain value
+func (pm PersonalTokenManager) GetToken(plain string, db *db.MySQL) (*types.PersonalToken, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM personalTokens WHERE tokenHash = ? AND expires > ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(utils.HashToken(plain), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		token := types.PersonalToken{}
+		err = sqlstruct.Scan(&token, rows)
+		if err != nil {
+			return nil, err
+		}
+		return &token, nil
+	}
+	return nil, nil
+}
+
+//GetAccountTokens - returns all tokens of an account
+func (pm PersonalTokenManager) GetAccountTokens(account *types.Account, db *db.MySQL) (*[]types.PersonalToken, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM personalTokens WHERE accountId = ? ORDER BY created DESC")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	tokens := []types.PersonalToken{}
+	defer rows.Close()
+	for rows.Next() {
+		token := types.PersonalToken{}
+		err = sqlstruct.Scan(&token, rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return &tokens, nil
+}
+
+//UpdateLastUsed - records that a token was just used
+func (pm PersonalTokenManager) UpdateLastUsed(token *types.PersonalToken, db *db.MySQL) error {
+	now := time.Now()
+	token.LastUsed = &now
+
+	stmt, err := db.PreparedQuery("UPDATE personalTokens SET lastUsed = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(token.LastUsed, token.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return nil
+}
+
+//DeleteToken - removes a token of an account. Returns false if the account has no token with the id
+func (pm PersonalTokenManager) DeleteToken(account *types.Account, id string, db *db.MySQL) (bool, error) {
+	stmt, err := db.PreparedQuery("DELETE FROM personalTokens WHERE id = ? AND accountId = ?")
+	if err != nil {
+		return false, err
+	}
+	res, err := stmt.Exec(id, account.ID)
+	stmt.Close()
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
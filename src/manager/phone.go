@@ -0,0 +1,181 @@
+package manager
+
+import (
+	"cache"
+	"db"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//PhoneManager - phone verification data access object
+type PhoneManager struct {
+}
+
+//CreateVerification - creates a new phone verification for a phone of the account. Replaces any pending verification
+func (pm PhoneManager) CreateVerification(account *types.Account, phone string, db *db.MySQL) (*types.PhoneVerification, error) {
+	code, err := utils.SecureCode()
+	if err != nil {
+		return nil, err
+	}
+	verification := types.PhoneVerification{ID: utils.RandomString(), AccountID: account.ID, Phone: phone, Code: code, Created: time.Now()}
+
+	stmt, err := db.PreparedQuery("DELETE FROM phoneVerifications WHERE accountId = ?")
+	if err != nil {
+		return nil, err
+	}
+	_, err = stmt.Exec(account.ID)
+	stmt.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err = db.PreparedQuery("INSERT INTO phoneVerifications (id, accountId, phone, code, attempts, created) VALUES(?,?,?,?,?,?)")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(verification.ID, verification.AccountID, verification.Phone, verification.Code, verification.Attempts, verification.Created)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return &verification, nil
+}
+
+//GetVerification - returns the pending phone verification of an account
+func (pm PhoneManager) GetVerification(account *types.Account, db *db.MySQL) (*types.PhoneVerification, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM phoneVerifications WHERE accountId = ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		verification := types.PhoneVerification{}
+		err = sqlstruct.Scan(&verification, rows)
+		if err != nil {
+			return nil, err
+		}
+		return &verification, nil
+	}
+	return nil, nil
+}
+
+//AddAttempt - counts a wrong code against a phone verification
+func (pm PhoneManager) AddAttempt(verification *types.PhoneVerification, db *db.MySQL) error {
+	verification.Attempts++
+
+	stmt, err := db.PreparedQuery("UPDATE phoneVerifications SET attempts = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(verification.Attempts, verification.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return nil
+}
+
+//DeleteVerification - removes a phone verification
+func (pm PhoneManager) DeleteVerification(verification *types.PhoneVerification, db *db.MySQL) error {
+	stmt, err := db.PreparedQuery("DELETE FROM phoneVerifications WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(verification.ID)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+	return nil
+}
+
+//PhoneTaken - checks if another account that is not deleted has the phone
+func (pm PhoneManager) PhoneTaken(phone string, accountID string, db *db.MySQL) (bool, error) {
+	found, err := pairs(db, "SELECT id, phone FROM users WHERE phone = ? AND id <> ?"+notDeleted, phone, accountID)
+	if err != nil {
+		return false, err
+	}
+	return len(found) > 0, nil
+}
+
+//SetPendingPhone - stores a new phone for the account until its code is confirmed. The current phone is kept
+func (pm PhoneManager) SetPendingPhone(account *types.Account, phone string, db *db.MySQL, cache *cache.Cache) error {
+	account.PendingPhone = phone
+
+	err := exec(db, "UPDATE users SET pendingPhone = ? WHERE id = ?", account.PendingPhone, account.ID)
+	if err != nil {
+		return err
+	}
+
+	AccountManager{}.SaveToCache(account, cache)
+	return nil
+}
+
+//ConfirmPendingPhone - replaces the phone of the account with its confirmed pending phone
+func (pm PhoneManager) ConfirmPendingPhone(account *types.Account, db *db.MySQL, cache *cache.Cache) error {
+	account.Phone = account.PendingPhone
+	account.PendingPhone = ""
+	account.PhoneVerified = true
+
+	err := exec(db, "UPDATE users SET phone = ?, pendingPhone = '', phoneVerified = ? WHERE id = ?", account.Phone, account.PhoneVerified, account.ID)
+	if err != nil {
+		return err
+	}
+
+	AccountManager{}.SaveToCache(account, cache)
+	return nil
+}
+
+//NormalizePhones - converts stored phones to E.164 with the country code given. A phone that changes must be verified again.
+//Returns the accounts whose phone cannot be converted, mapped to the phone
+func (pm PhoneManager) NormalizePhones(countryCode string, db *db.MySQL, cache *cache.Cache) (map[string]string, error) {
+	stmt, err := db.PreparedQuery("SELECT id, phone, token FROM users WHERE phone <> ''")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	type stored struct{ id, phone, token string }
+	accounts := []stored{}
+	for rows.Next() {
+		account := stored{}
+		if err := rows.Scan(&account.id, &account.phone, &account.token); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	rows.Close()
+
+	failed := map[string]string{}
+	for _, account := range accounts {
+		phone, err := types.NormalizePhone(account.phone, countryCode)
+		if err != nil {
+			failed[account.id] = account.phone
+			continue
+		}
+		if phone == account.phone {
+			continue
+		}
+		err = exec(db, "UPDATE users SET phone = ?, phoneVerified = 0, deliveryChannel = ? WHERE id = ?", phone, types.ChannelEmail, account.id)
+		if err != nil {
+			return nil, err
+		}
+		cache.Del(account.token)
+	}
+	return failed, nil
+}
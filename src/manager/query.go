@@ -0,0 +1,38 @@
+package manager
+
+import "db"
+
+//exec - runs a statement that returns no rows
+func exec(db *db.MySQL, query string, args ...interface{}) error {
+	stmt, err := db.PreparedQuery(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(args...)
+	return err
+}
+
+//pairs - returns the two columns of every row of a query
+func pairs(db *db.MySQL, query string, args ...interface{}) ([][2]string, error) {
+	stmt, err := db.PreparedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+
+	pairs := [][2]string{}
+	for rows.Next() {
+		var pair [2]string
+		if err := rows.Scan(&pair[0], &pair[1]); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
@@ -87,6 +87,12 @@ func (rm RecoveryManager) FinishRecovery(account *types.Account, recoveryRequest
 	//Save updated account to cache
 	AccountManager{}.SaveToCache(account, cache)
 
+	//Accounts created by an identity provider can now also login with a password
+	err = IdentityManager{}.CreatePasswordIdentity(account, db)
+	if err != nil {
+		return "", err
+	}
+
 	//If this fails it will expire within the HOUR. The request is already completed.
 	_, _ = db.SimpleQuery("DELETE FROM recover WHERE id = '" + recovery.ID + "'")
 
@@ -0,0 +1,357 @@
+package manager
+
+import (
+	"database/sql"
+	"db"
+	"strconv"
+	"strings"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//RoleManager - roles and permissions data access object
+type RoleManager struct {
+}
+
+//GetPermissions - returns every permission a role can give
+func (rm RoleManager) GetPermissions(db *db.MySQL) (*[]types.Permission, error) {
+	rows, err := db.SimpleQuery("SELECT * FROM permissions ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	permissions := []types.Permission{}
+	defer rows.Close()
+	for rows.Next() {
+		permission := types.Permission{}
+		err := sqlstruct.Scan(&permission, rows)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+	return &permissions, nil
+}
+
+//GetRoles - returns every role with its permissions
+func (rm RoleManager) GetRoles(db *db.MySQL) (*[]types.Role, error) {
+	rows, err := db.SimpleQuery("SELECT * FROM roles ORDER BY name ASC")
+	if err != nil {
+		return nil, err
+	}
+	roles := []types.Role{}
+	defer rows.Close()
+	for rows.Next() {
+		role := types.Role{Permissions: []string{}}
+		err := sqlstruct.Scan(&role, rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	permissions, err := db.SimpleQuery("SELECT roleId, permissionId FROM rolePermissions")
+	if err != nil {
+		return nil, err
+	}
+	defer permissions.Close()
+	for permissions.Next() {
+		var roleID, permissionID string
+		if err := permissions.Scan(&roleID, &permissionID); err != nil {
+			return nil, err
+		}
+		for i := range roles {
+			if roles[i].ID == roleID {
+				roles[i].Permissions = append(roles[i].Permissions, permissionID)
+			}
+		}
+	}
+
+	return &roles, nil
+}
+
+//GetRole - returns a role by id with its permissions
+func (rm RoleManager) GetRole(id string, db *db.MySQL) (*types.Role, error) {
+	roles, err := rm.GetRoles(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range *roles {
+		if role.ID == id {
+			return &role, nil
+		}
+	}
+	return nil, nil
+}
+
+//CheckDuplicateRole - returns a reason if another role has the same name
+func (rm RoleManager) CheckDuplicateRole(role *types.Role, db *db.MySQL) (string, error) {
+	stmt, err := db.PreparedQuery("SELECT id FROM roles WHERE name = ? AND id <> ?")
+	if err != nil {
+		return "", err
+	}
+	rows, err := stmt.Query(role.Name, role.ID)
+	if err != nil {
+		return "", err
+	}
+	stmt.Close()
+	defer rows.Close()
+	if rows.Next() {
+		return "Role name already exists", nil
+	}
+	return "", nil
+}
+
+//CreateRole - creates a role with its permissions
+func (rm RoleManager) CreateRole(role *types.Role, db *db.MySQL) error {
+	role.ID = utils.RandomString()
+	role.Created = time.Now()
+
+	err := exec(db, "INSERT INTO roles (id, name, description, `rank`, created) VALUES(?,?,?,?,?)", role.ID, role.Name, role.Description, role.Rank, role.Created)
+	if err != nil {
+		return err
+	}
+
+	return rm.setRolePermissions(role, db)
+}
+
+//UpdateRole - updates the name, description, rank and permissions of a role
+func (rm RoleManager) UpdateRole(role *types.Role, db *db.MySQL) error {
+	err := exec(db, "UPDATE roles SET name = ?, description = ?, `rank` = ? WHERE id = ?", role.Name, role.Description, role.Rank, role.ID)
+	if err != nil {
+		return err
+	}
+
+	return rm.setRolePermissions(role, db)
+}
+
+//setRolePermissions - replaces the permissions of a role
+func (rm RoleManager) setRolePermissions(role *types.Role, db *db.MySQL) error {
+	err := exec(db, "DELETE FROM rolePermissions WHERE roleId = ?", role.ID)
+	if err != nil {
+		return err
+	}
+	for _, permission := range role.Permissions {
+		err = exec(db, "INSERT INTO rolePermissions (roleId, permissionId) VALUES(?,?)", role.ID, permission)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//DeleteRole - deletes a role and removes it from every account
+func (rm RoleManager) DeleteRole(role *types.Role, db *db.MySQL) error {
+	err := exec(db, "DELETE FROM accountRoles WHERE roleId = ?", role.ID)
+	if err != nil {
+		return err
+	}
+	err = exec(db, "DELETE FROM groupRoles WHERE roleId = ?", role.ID)
+	if err != nil {
+		return err
+	}
+	err = exec(db, "DELETE FROM rolePermissions WHERE roleId = ?", role.ID)
+	if err != nil {
+		return err
+	}
+	return exec(db, "DELETE FROM roles WHERE id = ?", role.ID)
+}
+
+//groupArgs - returns placeholders and arguments for a list of group ids
+func (rm RoleManager) groupArgs(groupIDs []string, args ...interface{}) (string, []interface{}) {
+	for _, id := range groupIDs {
+		args = append(args, id)
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(groupIDs)), ","), args
+}
+
+//LoadAccountRoles - sets the role names, permissions and rank of an account.
+//Includes the roles given by its groups and the groups containing them
+func (rm RoleManager) LoadAccountRoles(account *types.Account, db *db.MySQL) error {
+	account.Roles = []string{}
+	account.Permissions = []string{}
+	account.Rank = 0
+
+	err := rm.loadRoles(account, db, "SELECT r.name, r.`rank`, rp.permissionId FROM accountRoles ar JOIN roles r ON r.id = ar.roleId LEFT JOIN rolePermissions rp ON rp.roleId = r.id WHERE ar.accountId = ?", account.ID)
+	if err != nil {
+		return err
+	}
+
+	groupIDs, err := GroupManager{}.EffectiveGroups(account.ID, db)
+	if err != nil || len(groupIDs) == 0 {
+		return err
+	}
+	placeholders, args := rm.groupArgs(groupIDs)
+	return rm.loadRoles(account, db, "SELECT r.name, r.`rank`, rp.permissionId FROM groupRoles gr JOIN roles r ON r.id = gr.roleId LEFT JOIN rolePermissions rp ON rp.roleId = r.id WHERE gr.groupId IN ("+placeholders+")", args...)
+}
+
+//loadRoles - adds the role names, ranks and permissions a query returns to an account
+func (rm RoleManager) loadRoles(account *types.Account, db *db.MySQL, query string, args ...interface{}) error {
+	stmt, err := db.PreparedQuery(query)
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return err
+	}
+	stmt.Close()
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var rank int
+		var permission sql.NullString
+		if err := rows.Scan(&name, &rank, &permission); err != nil {
+			return err
+		}
+		if rank > account.Rank {
+			account.Rank = rank
+		}
+		if !utils.Contains(name, account.Roles) {
+			account.Roles = append(account.Roles, name)
+		}
+		if permission.Valid && !utils.Contains(permission.String, account.Permissions) {
+			account.Permissions = append(account.Permissions, permission.String)
+		}
+	}
+	return nil
+}
+
+//LoadAccountsRoles - sets the role names of a list of accounts. Includes the roles given by groups
+func (rm RoleManager) LoadAccountsRoles(accounts []types.Account, db *db.MySQL) error {
+	rows, err := db.SimpleQuery("SELECT ar.accountId, r.name FROM accountRoles ar JOIN roles r ON r.id = ar.roleId ORDER BY r.name ASC")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	roles := map[string][]string{}
+	for rows.Next() {
+		var accountID, name string
+		if err := rows.Scan(&accountID, &name); err != nil {
+			return err
+		}
+		roles[accountID] = append(roles[accountID], name)
+	}
+
+	inherited, err := GroupManager{}.GroupRoleNames(db)
+	if err != nil {
+		return err
+	}
+	for accountID, names := range inherited {
+		for _, name := range names {
+			if !utils.Contains(name, roles[accountID]) {
+				roles[accountID] = append(roles[accountID], name)
+			}
+		}
+	}
+	for i := range accounts {
+		accounts[i].Roles = roles[accounts[i].ID]
+		if accounts[i].Roles == nil {
+			accounts[i].Roles = []string{}
+		}
+	}
+	return nil
+}
+
+//SetAccountRoles - replaces the roles of an account. The old role level is kept in sync
+func (rm RoleManager) SetAccountRoles(account *types.Account, roles []string, db *db.MySQL) error {
+	err := exec(db, "DELETE FROM accountRoles WHERE accountId = ?", account.ID)
+	if err != nil {
+		return err
+	}
+	err = rm.addAccountRoles(account, roles, db)
+	if err != nil {
+		return err
+	}
+
+	account.Role = types.LegacyLevel(roles)
+	return exec(db, "UPDATE users SET role = ? WHERE id = ?", account.Role, account.ID)
+}
+
+//SetLegacyRoles - gives an account the built in roles of its old role level.
+//Used when login providers or older endpoints set the level. Other roles are kept
+func (rm RoleManager) SetLegacyRoles(account *types.Account, db *db.MySQL) error {
+	err := exec(db, "DELETE ar FROM accountRoles ar JOIN roles r ON r.id = ar.roleId WHERE ar.accountId = ? AND r.name IN (?,?)", account.ID, types.RoleAdmin, types.RoleDefault)
+	if err != nil {
+		return err
+	}
+	return rm.addAccountRoles(account, types.LegacyRoles(account.Role), db)
+}
+
+//addAccountRoles - gives an account roles by name. Unknown names are ignored
+func (rm RoleManager) addAccountRoles(account *types.Account, roles []string, db *db.MySQL) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	args := []interface{}{account.ID}
+	for _, role := range roles {
+		args = append(args, role)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(roles)), ",")
+	return exec(db, "INSERT IGNORE INTO accountRoles (accountId, roleId) SELECT ?, id FROM roles WHERE name IN ("+placeholders+")", args...)
+}
+
+//RolesRank - returns the highest rank of the roles named. Unknown names are ignored
+func (rm RoleManager) RolesRank(roles []string, db *db.MySQL) (int, error) {
+	if len(roles) == 0 {
+		return 0, nil
+	}
+	args := []interface{}{}
+	for _, role := range roles {
+		args = append(args, role)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(roles)), ",")
+	ranks, err := pairs(db, "SELECT name, `rank` FROM roles WHERE name IN ("+placeholders+")", args...)
+	if err != nil {
+		return 0, err
+	}
+	rank := 0
+	for _, pair := range ranks {
+		if r, err := strconv.Atoi(pair[1]); err == nil && r > rank {
+			rank = r
+		}
+	}
+	return rank, nil
+}
+
+//GetPermissionRoles - returns the names of the roles of an account that give a permission. Includes the roles given by groups
+func (rm RoleManager) GetPermissionRoles(account *types.Account, permission string, db *db.MySQL) ([]string, error) {
+	query := "SELECT r.name FROM roles r JOIN rolePermissions rp ON rp.roleId = r.id WHERE rp.permissionId = ? AND (r.id IN (SELECT roleId FROM accountRoles WHERE accountId = ?)"
+	args := []interface{}{permission, account.ID}
+
+	groupIDs, err := GroupManager{}.EffectiveGroups(account.ID, db)
+	if err != nil {
+		return nil, err
+	}
+	if len(groupIDs) > 0 {
+		var placeholders string
+		placeholders, args = rm.groupArgs(groupIDs, args...)
+		query += " OR r.id IN (SELECT roleId FROM groupRoles WHERE groupId IN (" + placeholders + "))"
+	}
+	query += ") ORDER BY r.name ASC"
+
+	stmt, err := db.PreparedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		roles = append(roles, name)
+	}
+	return roles, nil
+}
@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"db"
+	"time"
+	"types"
+	"utils"
+
+	"github.com/kisielk/sqlstruct"
+)
+
+//SignupManager - sign up email verification data access object
+type SignupManager struct {
+}
+
+//SetPendingVerification - sets if an account must verify its email before it can login
+func (sm SignupManager) SetPendingVerification(account *types.Account, pending bool, db *db.MySQL) error {
+	account.PendingVerification = pending
+	return exec(db, "UPDATE users SET pendingVerification = ? WHERE id = ?", pending, account.ID)
+}
+
+//PurgeUnverified - purges the signed up accounts that did not verify their email within the days given.
+//They are marked deleted first so AccountManager.PurgeAccount removes every row connected to them
+func (sm SignupManager) PurgeUnverified(days int, db *db.MySQL) error {
+	accounts, err := pairs(db, "SELECT id, userName FROM users WHERE pendingVerification = 1 AND created < (NOW() - INTERVAL ? DAY)", days)
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		err = exec(db, "UPDATE users SET deletedAt = ? WHERE id = ? AND pendingVerification = 1 AND deletedAt IS NULL", time.Now(), account[0])
+		if err != nil {
+			return err
+		}
+		if err := (AccountManager{}).PurgeAccount(account[0], db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//CreateVerification - creates a verification link and removes older ones. Returns the plain token, only its hash is stored
+func (sm SignupManager) CreateVerification(account *types.Account, db *db.MySQL) (string, *types.EmailVerification, error) {
+	err := exec(db, "DELETE FROM emailVerifications WHERE accountId = ?", account.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token, err := utils.SecureString()
+	if err != nil {
+		return "", nil, err
+	}
+	verification := types.EmailVerification{ID: utils.HashToken(token), AccountID: account.ID, Email: account.Email, Created: time.Now()}
+
+	err = exec(db, "INSERT INTO emailVerifications (id, accountId, email, created) VALUES(?,?,?,?)", verification.ID, verification.AccountID, verification.Email, verification.Created)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, &verification, nil
+}
+
+//GetLatestVerification - returns the last verification link sent to an account
+func (sm SignupManager) GetLatestVerification(accountID string, db *db.MySQL) (*types.EmailVerification, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM emailVerifications WHERE accountId = ? ORDER BY created DESC LIMIT 1")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(accountID)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		verification := types.EmailVerification{}
+		err = sqlstruct.Scan(&verification, rows)
+		if err != nil {
+			return nil, err
+		}
+		return &verification, nil
+	}
+	return nil, nil
+}
+
+//ConsumeVerification - returns a verification link from its plain token and removes it so it can only be used once
+func (sm SignupManager) ConsumeVerification(token string, db *db.MySQL) (*types.EmailVerification, error) {
+	stmt, err := db.PreparedQuery("SELECT * FROM emailVerifications WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(utils.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	stmt.Close()
+	defer rows.Close()
+	for rows.Next() {
+		verification := types.EmailVerification{}
+		err = sqlstruct.Scan(&verification, rows)
+		if err != nil {
+			return nil, err
+		}
+		del, err := db.PreparedQuery("DELETE FROM emailVerifications WHERE id = ?")
+		if err != nil {
+			return nil, err
+		}
+		res, err := del.Exec(verification.ID)
+		del.Close()
+		if err != nil {
+			return nil, err
+		}
+		//Another request already used this link
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil, nil
+		}
+		return &verification, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,36 @@
+package manager
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPurgeUnverified(t *testing.T) {
+	statements := []string{}
+	db := newFakeDB(t, func(query string, args []driver.Value) fakeTable {
+		statements = append(statements, fmt.Sprint(query, args))
+		if strings.HasPrefix(query, "SELECT id, userName FROM users WHERE pendingVerification = 1") {
+			return fakeTable{columns: []string{"id", "userName"}, rows: [][]driver.Value{{"pending", "new"}}}
+		}
+		return fakeTable{}
+	})
+
+	err := SignupManager{}.PurgeUnverified(7, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"SELECT id, userName FROM users WHERE pendingVerification = 1 AND created < (NOW() - INTERVAL ? DAY)[7]",
+		"UPDATE users SET deletedAt = ? WHERE id = ? AND pendingVerification = 1 AND deletedAt IS NULL",
+		"DELETE FROM identities WHERE accountId = ?[pending]",
+		"DELETE FROM accountRoles WHERE accountId = ?[pending]",
+		"DELETE FROM accountAttributes WHERE accountId = ?[pending]",
+		"DELETE FROM users WHERE id = ? AND deletedAt IS NOT NULL[pending]",
+	} {
+		if !contains(statements, want) {
+			t.Errorf("missing statement %q", want)
+		}
+	}
+}
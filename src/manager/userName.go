@@ -0,0 +1,107 @@
+package manager
+
+import (
+	"cache"
+	"db"
+	"strings"
+	"time"
+	"types"
+)
+
+//UserNameManager - username changes and holds data access object
+type UserNameManager struct {
+}
+
+//ReserveUserNames - stores usernames no account can take. Accounts that already have one keep it
+func (um UserNameManager) ReserveUserNames(names []string, db *db.MySQL) error {
+	for _, name := range names {
+		err := exec(db, "INSERT INTO userNameHolds (userName, accountId, created, expires) VALUES(?,'',?,NULL) ON DUPLICATE KEY UPDATE accountId = '', expires = NULL", name, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//HoldApplies - checks if a hold on a username stops an account taking it. Reserved names have no account and never expire
+func HoldApplies(holderID string, expires *time.Time, accountID string, now time.Time) bool {
+	if expires != nil && !now.Before(*expires) {
+		return false
+	}
+	return holderID == "" || holderID != accountID
+}
+
+//UserNameHeld - checks if a reserved name or a hold for another account stops the account taking its username.
+//Accounts already using the name keep it
+func (um UserNameManager) UserNameHeld(account *types.Account, db *db.MySQL) (bool, error) {
+	stmt, err := db.PreparedQuery("SELECT accountId, expires FROM userNameHolds WHERE userName = ?")
+	if err != nil {
+		return false, err
+	}
+	rows, err := stmt.Query(account.UserName)
+	if err != nil {
+		return false, err
+	}
+	stmt.Close()
+	held := false
+	for rows.Next() {
+		var holderID string
+		var expires *time.Time
+		if err := rows.Scan(&holderID, &expires); err != nil {
+			rows.Close()
+			return false, err
+		}
+		if HoldApplies(holderID, expires, account.ID, time.Now()) {
+			held = true
+		}
+	}
+	rows.Close()
+	if !held || account.ID == "" {
+		return held, nil
+	}
+
+	current, err := pairs(db, "SELECT id, userName FROM users WHERE id = ?", account.ID)
+	if err != nil {
+		return false, err
+	}
+	return len(current) == 0 || !strings.EqualFold(current[0][1], account.UserName), nil
+}
+
+//HoldUserName - keeps an old username for its account so no one else can claim it until the hold expires
+func (um UserNameManager) HoldUserName(userName string, accountID string, hold time.Duration, db *db.MySQL) error {
+	if hold <= 0 {
+		return nil
+	}
+	//Reserved names are kept as they are
+	err := exec(db, "DELETE FROM userNameHolds WHERE (userName = ? OR expires < ?) AND expires IS NOT NULL", userName, time.Now())
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return exec(db, "INSERT IGNORE INTO userNameHolds (userName, accountId, created, expires) VALUES(?,?,?,?)", userName, accountID, now, now.Add(hold))
+}
+
+//ChangeUserName - changes the username of an account and holds the old one for it
+func (um UserNameManager) ChangeUserName(account *types.Account, userName string, hold time.Duration, db *db.MySQL, cache *cache.Cache) error {
+	oldUserName := account.UserName
+	now := time.Now()
+	err := exec(db, "UPDATE users SET userName = ?, userNameChanged = ? WHERE id = ?", userName, now, account.ID)
+	if err != nil {
+		return err
+	}
+	account.UserName = userName
+	account.UserNameChanged = &now
+
+	//Taking back an old username ends its hold
+	err = exec(db, "DELETE FROM userNameHolds WHERE userName = ? AND accountId = ?", userName, account.ID)
+	if err != nil {
+		return err
+	}
+	err = um.HoldUserName(oldUserName, account.ID, hold, db)
+	if err != nil {
+		return err
+	}
+
+	AccountManager{}.SaveToCache(account, cache)
+	return nil
+}
@@ -0,0 +1,83 @@
+package manager
+
+import (
+	"database/sql/driver"
+	"db"
+	"strings"
+	"testing"
+	"time"
+	"types"
+)
+
+//hold - row of the userNameHolds table
+type hold struct {
+	accountID string
+	expires   *time.Time
+}
+
+//holdsDB - fake database with username holds and one stored account
+func holdsDB(t *testing.T, holds []hold, stored types.Account) *db.MySQL {
+	return newFakeDB(t, func(query string, args []driver.Value) fakeTable {
+		switch {
+		case strings.Contains(query, "FROM userNameHolds"):
+			table := fakeTable{columns: []string{"accountId", "expires"}}
+			for _, h := range holds {
+				var expires driver.Value
+				if h.expires != nil {
+					expires = *h.expires
+				}
+				table.rows = append(table.rows, []driver.Value{h.accountID, expires})
+			}
+			return table
+		case strings.HasPrefix(query, "SELECT id, userName FROM users WHERE id = ?") && args[0] == stored.ID:
+			return fakeTable{columns: []string{"id", "userName"}, rows: [][]driver.Value{{stored.ID, stored.UserName}}}
+		}
+		return fakeTable{}
+	})
+}
+
+func TestCreateAccountRejectsReservedName(t *testing.T) {
+	db := holdsDB(t, []hold{{accountID: ""}}, types.Account{})
+
+	account := types.Account{UserName: "administrator", Password: "password1", Name: "Test", Email: "test@example.com", Phone: "+15551234567"}
+	res, err := AccountManager{}.CreateAccount(&account, nil, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "UserName is not available: administrator" {
+		t.Fatalf("reserved name was not rejected, got %q", res)
+	}
+}
+
+func TestCheckDuplicatesHolds(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		holds   []hold
+		account types.Account
+		stored  types.Account
+		want    string
+	}{
+		{"reserved for new account", []hold{{accountID: ""}}, types.Account{UserName: "support"}, types.Account{}, "UserName is not available: support"},
+		{"reserved for existing account", []hold{{accountID: ""}}, types.Account{ID: "a1", UserName: "support"}, types.Account{ID: "a1", UserName: "someone"}, "UserName is not available: support"},
+		{"reserved name already used", []hold{{accountID: ""}}, types.Account{ID: "a1", UserName: "support"}, types.Account{ID: "a1", UserName: "Support"}, ""},
+		{"held for another account", []hold{{accountID: "a2", expires: &future}}, types.Account{ID: "a1", UserName: "oldname"}, types.Account{ID: "a1", UserName: "someone"}, "UserName is not available: oldname"},
+		{"held for new account", []hold{{accountID: "a2", expires: &future}}, types.Account{UserName: "oldname"}, types.Account{}, "UserName is not available: oldname"},
+		{"held for itself", []hold{{accountID: "a1", expires: &future}}, types.Account{ID: "a1", UserName: "oldname"}, types.Account{ID: "a1", UserName: "newname"}, ""},
+		{"hold expired", []hold{{accountID: "a2", expires: &past}}, types.Account{UserName: "oldname"}, types.Account{}, ""},
+		{"not held", nil, types.Account{UserName: "freename"}, types.Account{}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := AccountManager{}.CheckDuplicates(&test.account, holdsDB(t, test.holds, test.stored))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != test.want {
+				t.Fatalf("got %q, want %q", res, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+	"types"
+)
+
+//DefaultRules - used when no policy file is configured. Accounts are compared by the highest rank of their roles
+var DefaultRules = []types.PolicyRule{
+	{
+		Name:       "no-self-delete",
+		Effect:     types.EffectDeny,
+		Actions:    []string{types.ScopeAccountsDelete},
+		Conditions: []types.PolicyCondition{{Left: "actor.id", Op: "eq", Right: "target.id"}},
+	},
+	{
+		Name:    "no-delete-equal-or-higher-role",
+		Effect:  types.EffectDeny,
+		Actions: []string{types.ScopeAccountsDelete},
+		Conditions: []types.PolicyCondition{
+			{Left: "actor.type", Op: "ne", Right: types.AccountClient},
+			{Left: "actor.rank", Op: "lte", Right: "target.rank"},
+		},
+	},
+	{
+		Name:    "no-edit-equal-or-higher-role",
+		Effect:  types.EffectDeny,
+		Actions: []string{types.ScopeAccountsWrite, types.ScopeRolesWrite},
+		Conditions: []types.PolicyCondition{
+			{Left: "actor.type", Op: "ne", Right: types.AccountClient},
+			{Left: "actor.id", Op: "ne", Right: "target.id"},
+			{Left: "actor.rank", Op: "lte", Right: "target.rank"},
+		},
+	},
+	{
+		Name:    "no-grant-higher-role",
+		Effect:  types.EffectDeny,
+		Actions: []string{types.ScopeAccountsWrite, types.ScopeRolesWrite},
+		Conditions: []types.PolicyCondition{
+			{Left: "actor.type", Op: "ne", Right: types.AccountClient},
+			{Left: "new.rank", Op: "gt", Right: "actor.rank"},
+		},
+	},
+}
+
+//Engine - evaluates access policies. Has no state besides its rules so it can be tested on its own
+type Engine struct {
+	Rules []types.PolicyRule
+}
+
+//Init - setup an engine from a policy file. The default rules are used if no file is given.
+//Returns an error if the file cannot be loaded so the server does not run with rules it was not given
+func (engine Engine) Init(path string) (*Engine, error) {
+	engine.Rules = DefaultRules
+	if path == "" {
+		return &engine, nil
+	}
+
+	rules, err := Load(path)
+	if err != nil {
+		return nil, errors.New("Invalid policy file " + path + ": " + err.Error())
+	}
+	engine.Rules = rules
+
+	return &engine, nil
+}
+
+//Load - reads the rules of a policy file
+func Load(path string) ([]types.PolicyRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []types.PolicyRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if rule.Effect != types.EffectAllow && rule.Effect != types.EffectDeny {
+			return nil, errors.New("Invalid effect of rule " + rule.Name + ": " + rule.Effect)
+		}
+		for _, condition := range rule.Conditions {
+			if _, ok := operators[condition.Op]; !ok {
+				return nil, errors.New("Invalid operator of rule " + rule.Name + ": " + condition.Op)
+			}
+		}
+	}
+	return rules, nil
+}
+
+//Evaluate - returns the effect of the first rule that matches. Allowed if no rule matches
+func (engine Engine) Evaluate(request *types.PolicyRequest) types.PolicyDecision {
+	for _, rule := range engine.Rules {
+		if matches(rule, request) {
+			return types.PolicyDecision{Allowed: rule.Effect == types.EffectAllow, Rule: rule.Name}
+		}
+	}
+	return types.PolicyDecision{Allowed: true}
+}
+
+//AccountAttributes - returns the attributes of an account policies can check.
+//Level is the old role level and rank the highest rank of its roles
+func AccountAttributes(account *types.Account) map[string]interface{} {
+	actorType := account.Type
+	if actorType == "" {
+		actorType = types.AccountUser
+	}
+	//Roles given through groups can raise the level
+	level := account.Role
+	if roleLevel := types.LegacyLevel(account.Roles); roleLevel > level {
+		level = roleLevel
+	}
+	return map[string]interface{}{
+		"id":      account.ID,
+		"type":    actorType,
+		"level":   level,
+		"rank":    account.Rank,
+		"org":     account.ActiveOrg,
+		"roles":   account.Roles,
+		"created": account.Created,
+	}
+}
+
+//matches - checks if a rule applies to the request
+func matches(rule types.PolicyRule, request *types.PolicyRequest) bool {
+	action := false
+	for _, a := range rule.Actions {
+		if a == "*" || a == request.Action {
+			action = true
+		}
+	}
+	if !action {
+		return false
+	}
+
+	for _, condition := range rule.Conditions {
+		left, lok := resolve(condition.Left, request)
+		right, rok := resolve(condition.Right, request)
+		//A missing attribute never matches
+		if !lok || !rok || !operators[condition.Op](left, right) {
+			return false
+		}
+	}
+	return true
+}
+
+//resolve - returns the value of an attribute reference or the literal given. False if the attribute is missing
+func resolve(value interface{}, request *types.PolicyRequest) (interface{}, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return normalize(value), true
+	}
+
+	if s == "action" {
+		return request.Action, true
+	}
+	attributes := map[string]map[string]interface{}{"actor.": request.Actor, "target.": request.Target, "new.": request.New}
+	for prefix, values := range attributes {
+		if strings.HasPrefix(s, prefix) {
+			v, found := values[strings.TrimPrefix(s, prefix)]
+			return normalize(v), found
+		}
+	}
+	return s, true
+}
+
+//normalize - numbers and times become float64 so they can be compared
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case time.Time:
+		return float64(v.Unix())
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return float64(v.Unix())
+	}
+	return value
+}
+
+//operators - comparisons conditions can use
+var operators = map[string]func(left interface{}, right interface{}) bool{
+	"eq": func(l, r interface{}) bool { return fmt.Sprint(l) == fmt.Sprint(r) },
+	"ne": func(l, r interface{}) bool { return fmt.Sprint(l) != fmt.Sprint(r) },
+	"gt": func(l, r interface{}) bool {
+		a, b, ok := numbers(l, r)
+		return ok && a > b
+	},
+	"gte": func(l, r interface{}) bool {
+		a, b, ok := numbers(l, r)
+		return ok && a >= b
+	},
+	"lt": func(l, r interface{}) bool {
+		a, b, ok := numbers(l, r)
+		return ok && a < b
+	},
+	"lte": func(l, r interface{}) bool {
+		a, b, ok := numbers(l, r)
+		return ok && a <= b
+	},
+	"contains": func(l, r interface{}) bool {
+		switch list := l.(type) {
+		case []string:
+			for _, item := range list {
+				if item == fmt.Sprint(r) {
+					return true
+				}
+			}
+		case []interface{}:
+			for _, item := range list {
+				if fmt.Sprint(item) == fmt.Sprint(r) {
+					return true
+				}
+			}
+		case string:
+			return strings.Contains(list, fmt.Sprint(r))
+		}
+		return false
+	},
+}
+
+//numbers - returns both values as numbers. False if either is not a number
+func numbers(l, r interface{}) (float64, float64, bool) {
+	a, aok := l.(float64)
+	b, bok := r.(float64)
+	return a, b, aok && bok
+}
@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"types"
+)
+
+var (
+	admin     = &types.Account{ID: "admin", Role: types.LevelAdmin, Roles: []string{types.RoleAdmin, types.RoleDefault}, Rank: types.LevelAdmin}
+	moderator = &types.Account{ID: "moderator", Role: types.LevelDefault, Roles: []string{"moderator", types.RoleDefault}, Rank: 500}
+	member    = &types.Account{ID: "member", Role: types.LevelDefault, Roles: []string{types.RoleDefault}}
+	client    = &types.Account{ID: "client", Type: types.AccountClient}
+)
+
+//request - returns a policy request for an action on a target
+func request(action string, actor *types.Account, target *types.Account, updated *types.Account) *types.PolicyRequest {
+	request := &types.PolicyRequest{Action: action, Actor: AccountAttributes(actor), Target: AccountAttributes(target)}
+	if updated != nil {
+		request.New = AccountAttributes(updated)
+	}
+	return request
+}
+
+func TestEvaluateDefaultRules(t *testing.T) {
+	engine := Engine{Rules: DefaultRules}
+	tests := []struct {
+		name    string
+		request *types.PolicyRequest
+		allowed bool
+		rule    string
+	}{
+		{"admin deletes member", request(types.ScopeAccountsDelete, admin, member, nil), true, ""},
+		{"admin deletes itself", request(types.ScopeAccountsDelete, admin, admin, nil), false, "no-self-delete"},
+		{"admin deletes admin", request(types.ScopeAccountsDelete, admin, &types.Account{ID: "other", Rank: types.LevelAdmin}, nil), false, "no-delete-equal-or-higher-role"},
+		{"moderator deletes member", request(types.ScopeAccountsDelete, moderator, member, nil), true, ""},
+		{"moderator edits member", request(types.ScopeAccountsWrite, moderator, member, member), true, ""},
+		{"moderator deletes admin", request(types.ScopeAccountsDelete, moderator, admin, nil), false, "no-delete-equal-or-higher-role"},
+		{"moderator edits admin", request(types.ScopeAccountsWrite, moderator, admin, admin), false, "no-edit-equal-or-higher-role"},
+		{"moderator makes member admin", request(types.ScopeAccountsWrite, moderator, member, admin), false, "no-grant-higher-role"},
+		{"moderator gives member admin roles", request(types.ScopeRolesWrite, moderator, member, admin), false, "no-grant-higher-role"},
+		{"moderator changes admin roles", request(types.ScopeRolesWrite, moderator, admin, member), false, "no-edit-equal-or-higher-role"},
+		{"moderator edits itself", request(types.ScopeAccountsWrite, moderator, moderator, moderator), true, ""},
+		{"member deletes member", request(types.ScopeAccountsDelete, member, &types.Account{ID: "other"}, nil), false, "no-delete-equal-or-higher-role"},
+		{"client deletes admin", request(types.ScopeAccountsDelete, client, admin, nil), true, ""},
+		{"other actions", request(types.ScopeAccountsRead, member, admin, nil), true, ""},
+	}
+	for _, test := range tests {
+		decision := engine.Evaluate(test.request)
+		if decision.Allowed != test.allowed || decision.Rule != test.rule {
+			t.Errorf("%s: got %v by %q; want %v by %q", test.name, decision.Allowed, decision.Rule, test.allowed, test.rule)
+		}
+	}
+}
+
+func TestEvaluateOperators(t *testing.T) {
+	tests := []struct {
+		condition types.PolicyCondition
+		matches   bool
+	}{
+		{types.PolicyCondition{Left: "actor.id", Op: "eq", Right: "moderator"}, true},
+		{types.PolicyCondition{Left: "actor.id", Op: "ne", Right: "target.id"}, true},
+		{types.PolicyCondition{Left: "actor.rank", Op: "gt", Right: 499}, true},
+		{types.PolicyCondition{Left: "actor.rank", Op: "gte", Right: "target.rank"}, true},
+		{types.PolicyCondition{Left: "actor.rank", Op: "lt", Right: "target.rank"}, false},
+		{types.PolicyCondition{Left: "actor.rank", Op: "lte", Right: 500}, true},
+		{types.PolicyCondition{Left: "actor.roles", Op: "contains", Right: "moderator"}, true},
+		{types.PolicyCondition{Left: "target.roles", Op: "contains", Right: "moderator"}, false},
+		{types.PolicyCondition{Left: "action", Op: "eq", Right: types.ScopeAccountsWrite}, true},
+		{types.PolicyCondition{Left: "actor.id", Op: "gt", Right: 1}, false},
+		{types.PolicyCondition{Left: "actor.missing", Op: "ne", Right: "x"}, false},
+		{types.PolicyCondition{Left: "new.rank", Op: "eq", Right: 0}, false},
+	}
+	for _, test := range tests {
+		engine := Engine{Rules: []types.PolicyRule{{Name: "rule", Effect: types.EffectDeny, Actions: []string{"*"}, Conditions: []types.PolicyCondition{test.condition}}}}
+		decision := engine.Evaluate(request(types.ScopeAccountsWrite, moderator, member, nil))
+		if decision.Allowed == test.matches {
+			t.Errorf("%v %s %v: matched %v; want %v", test.condition.Left, test.condition.Op, test.condition.Right, !decision.Allowed, test.matches)
+		}
+	}
+}
+
+func TestEvaluateFirstMatch(t *testing.T) {
+	engine := Engine{Rules: []types.PolicyRule{
+		{Name: "allow-moderators", Effect: types.EffectAllow, Actions: []string{types.ScopeAccountsDelete}, Conditions: []types.PolicyCondition{{Left: "actor.roles", Op: "contains", Right: "moderator"}}},
+		{Name: "deny-all", Effect: types.EffectDeny, Actions: []string{"*"}},
+	}}
+	if decision := engine.Evaluate(request(types.ScopeAccountsDelete, moderator, admin, nil)); !decision.Allowed || decision.Rule != "allow-moderators" {
+		t.Errorf("moderator got %v by %q; want allowed by allow-moderators", decision.Allowed, decision.Rule)
+	}
+	if decision := engine.Evaluate(request(types.ScopeAccountsDelete, member, admin, nil)); decision.Allowed || decision.Rule != "deny-all" {
+		t.Errorf("member got %v by %q; want denied by deny-all", decision.Allowed, decision.Rule)
+	}
+}
+
+func TestAccountAttributesRank(t *testing.T) {
+	attributes := AccountAttributes(moderator)
+	if attributes["rank"] != 500 || attributes["level"] != types.LevelDefault {
+		t.Errorf("moderator rank %v level %v; want 500 and %d", attributes["rank"], attributes["level"], types.LevelDefault)
+	}
+	if attributes["type"] != types.AccountUser {
+		t.Errorf("type %v; want %s", attributes["type"], types.AccountUser)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		file    string
+		rules   int
+		wantErr bool
+	}{
+		{"valid", `[{"name":"deny-all","effect":"deny","actions":["*"]},{"name":"r","effect":"allow","actions":["accounts:write"],"conditions":[{"left":"actor.rank","op":"gt","right":"target.rank"}]}]`, 2, false},
+		{"empty", `[]`, 0, false},
+		{"invalid effect", `[{"name":"r","effect":"maybe","actions":["*"]}]`, 0, true},
+		{"invalid operator", `[{"name":"r","effect":"deny","actions":["*"],"conditions":[{"left":"actor.id","op":"like","right":"x"}]}]`, 0, true},
+		{"invalid json", `{"name":`, 0, true},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, string(rune('a'+i))+".json")
+		if err := ioutil.WriteFile(path, []byte(test.file), 0600); err != nil {
+			t.Fatal(err)
+		}
+		rules, err := Load(path)
+		if (err != nil) != test.wantErr || len(rules) != test.rules {
+			t.Errorf("%s: got %d rules, %v; want %d rules, error %v", test.name, len(rules), err, test.rules, test.wantErr)
+		}
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing file loaded")
+	}
+}
+
+func TestInit(t *testing.T) {
+	engine, err := Engine{}.Init("")
+	if err != nil || len(engine.Rules) != len(DefaultRules) {
+		t.Errorf("no file: got %v; want the default rules", err)
+	}
+
+	file, err := ioutil.TempFile("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`[{"name":"r","effect":"maybe","actions":["*"]}]`)
+	file.Close()
+
+	//An invalid file must not fall back to the default rules
+	if engine, err := (Engine{}).Init(file.Name()); err == nil || engine != nil {
+		t.Error("invalid file: got an engine; want an error")
+	}
+	if engine, err := (Engine{}).Init(file.Name() + ".missing"); err == nil || engine != nil {
+		t.Error("missing file: got an engine; want an error")
+	}
+}
@@ -0,0 +1,80 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//suspendAccount - endpoint to suspend or lock an account
+func (router Router) suspendAccount(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.AccountStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, account, err := router.Auth.SuspendAccount(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.statusNotice(account)
+	router.goodRequest(w)
+}
+
+//reactivateAccount - endpoint to make a suspended account active again
+func (router Router) reactivateAccount(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.AccountStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, account, err := router.Auth.ReactivateAccount(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.statusNotice(account)
+	router.goodRequest(w)
+}
+
+//statusNotice - emails an account about its new status. The status is already saved so failures are only logged
+func (router Router) statusNotice(account *types.Account) {
+	if account.Email == "" {
+		return
+	}
+	if err := router.Emailer.AccountStatusEmail(account); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		return
+	}
+	go router.Log.LogEvent(logw.Event{Message: "Account status email sent: " + account.Email})
+}
@@ -0,0 +1,111 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//getAttributes - endpoint to list the attribute definitions
+func (router Router) getAttributes(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	result, err := router.Auth.GetAttributes(router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	data, err := json.Marshal(types.AttributesResponse{Response: true, Data: result})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Write(data)
+}
+
+//createAttribute - endpoint to create an attribute definition
+func (router Router) createAttribute(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.AttributeDefinition
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.CreateAttribute(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//updateAttribute - endpoint to update an attribute definition
+func (router Router) updateAttribute(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.AttributeDefinition
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.UpdateAttribute(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//deleteAttribute - endpoint to delete an attribute definition
+func (router Router) deleteAttribute(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.AttributeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	if err := router.Auth.DeleteAttribute(router.getSession(r), &request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.goodRequest(w)
+}
@@ -0,0 +1,60 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//getDeletedAccounts - endpoint to list deleted accounts that can be restored
+func (router Router) getDeletedAccounts(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	accounts, err := router.Auth.GetDeletedAccounts(router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	data, err := json.Marshal(types.AllUsersResponse{Response: true, Data: accounts})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Write(data)
+}
+
+//restoreAccount - endpoint to restore a deleted account
+func (router Router) restoreAccount(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.RestoreAccount(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
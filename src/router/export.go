@@ -0,0 +1,107 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//exportOwnData - endpoint for an account to request an export of its data
+func (router Router) exportOwnData(w http.ResponseWriter, r *http.Request) {
+	//Hard limiter is set on this request
+	if !router.HardLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, export, token, err := router.Auth.ExportOwnData(router.getSession(r), &request)
+	router.startExport(w, res, export, token, err)
+}
+
+//exportAccount - endpoint for an admin to request an export of an account
+func (router Router) exportAccount(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, export, token, err := router.Auth.ExportAccount(router.getSession(r), &request)
+	router.startExport(w, res, export, token, err)
+}
+
+//startExport - responds to an export request and builds the export in the background
+func (router Router) startExport(w http.ResponseWriter, res string, export *types.Export, token string, err error) {
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	go router.finishExport(export, token)
+	router.goodRequest(w)
+}
+
+//finishExport - builds an export and emails its download link
+func (router Router) finishExport(export *types.Export, token string) {
+	if err := router.Auth.BuildExport(export); err != nil {
+		router.Log.LogError(logw.Error{Message: err.Error()})
+		return
+	}
+
+	if err := router.Emailer.ExportEmail(export, token); err != nil {
+		router.Log.LogError(logw.Error{Message: err.Error()})
+		return
+	}
+
+	router.Log.LogEvent(logw.Event{Message: "Export email sent: " + export.Email})
+}
+
+//downloadExport - endpoint to download a finished export
+func (router Router) downloadExport(w http.ResponseWriter, r *http.Request) {
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	export, err := router.Auth.GetExportDownload(r.URL.Query().Get("token"))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	if export.Format == types.ExportZIP {
+		w.Header().Set("Content-Type", "application/zip")
+	}
+	w.Header().Set("Content-Disposition", "attachment; filename=account-export."+export.Format)
+	http.ServeFile(w, r, export.File)
+}
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"logw"
+	"net/http"
+	"net/url"
+	"types"
+
+	"github.com/gorilla/mux"
+)
+
+//federatedLogin - endpoint to start a login with an external identity provider
+func (router Router) federatedLogin(w http.ResponseWriter, r *http.Request) {
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	continueURL := router.safeContinue(r.URL.Query().Get("continue"))
+
+	authURL, err := router.Auth.FederatedLoginURL(mux.Vars(r)["provider"], continueURL, "")
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.redirectWith(w, r, router.Host+"/login", url.Values{"error": {"federated_login_failed"}})
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+//federatedCallback - endpoint the external identity provider returns the user to
+func (router Router) federatedCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	provider := mux.Vars(r)["provider"]
+
+	//User cancelled or the provider refused the login
+	if query.Get("error") != "" {
+		go router.Log.LogError(logw.Error{Message: "Federated login refused by " + provider + ": " + query.Get("error")})
+		router.redirectWith(w, r, router.Host+"/login", url.Values{"error": {"federated_login_failed"}})
+		return
+	}
+
+	account, device, continueURL, err := router.Auth.FederatedLogin(provider, query.Get("state"), query.Get("code"), router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.redirectWith(w, r, router.Host+"/login", url.Values{"error": {"federated_login_failed"}})
+		return
+	}
+
+	router.finishExternalLogin(w, r, account, device, continueURL, provider)
+}
+
+//finishExternalLogin - sets the session of a login with an identity provider and sends the user on
+func (router Router) finishExternalLogin(w http.ResponseWriter, r *http.Request, account *types.Account, device *types.Device, continueURL string, provider string) {
+	router.addCookie(w, "sessionId", account.Token)
+	go router.Log.LogEvent(logw.Event{Message: "Federated login with " + provider + ": " + account.Email})
+
+	//Device needs activation.
+	if device != nil && !device.Active {
+		if err := router.sendDeviceCode(account, device); err != nil {
+			go router.Log.LogError(logw.Error{Message: err.Error()})
+			router.redirectWith(w, r, router.Host+"/login", url.Values{"error": {"federated_login_failed"}})
+			return
+		}
+		router.addCookie(w, "deviceId", device.ID)
+		router.redirectWith(w, r, router.Host+"/activateDevice", url.Values{"continue": {continueURL}})
+		return
+	}
+
+	if continueURL == "" {
+		continueURL = router.Host
+	}
+	http.Redirect(w, r, continueURL, http.StatusFound)
+}
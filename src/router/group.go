@@ -0,0 +1,169 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//getGroups - endpoint to list groups with their roles and members
+func (router Router) getGroups(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	result, err := router.Auth.GetGroups(router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	data, err := json.Marshal(types.GroupsResponse{Response: true, Data: result})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Write(data)
+}
+
+//createGroup - endpoint to create a group
+func (router Router) createGroup(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.Group
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.CreateGroup(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//updateGroup - endpoint to update a group and its roles
+func (router Router) updateGroup(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.Group
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.UpdateGroup(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//deleteGroup - endpoint to delete a group
+func (router Router) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	if err := router.Auth.DeleteGroup(router.getSession(r), &request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//addGroupMember - endpoint to add an account or a nested group to a group
+func (router Router) addGroupMember(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.GroupMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.AddGroupMember(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//removeGroupMember - endpoint to remove an account or a nested group from a group
+func (router Router) removeGroupMember(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.GroupMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.RemoveGroupMember(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
@@ -0,0 +1,116 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//getIdentities - endpoint to list the login identities of the session account
+func (router Router) getIdentities(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	identities, err := router.Auth.GetIdentities(router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	data, err := json.Marshal(types.IdentitiesResponse{Response: true, Data: identities})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Write(data)
+}
+
+//linkIdentity - endpoint to add a login identity to the session account
+func (router Router) linkIdentity(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	var request types.LinkIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+	request.Continue = router.safeContinue(request.Continue)
+
+	res, url, err := router.Auth.LinkIdentity(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	//Password or directory user was added
+	if url == "" {
+		router.goodRequest(w)
+		return
+	}
+
+	//User must login with the identity provider to finish linking
+	data, err := json.Marshal(types.URLResponse{Response: true, URL: url})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Write(data)
+}
+
+//unlinkIdentity - endpoint to remove a login identity from the session account
+func (router Router) unlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	var request types.UnlinkIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.UnlinkIdentity(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//getInvites - endpoint to list pending invites
+func (router Router) getInvites(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	result, err := router.Auth.GetInvites(router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	data, err := json.Marshal(types.InvitesResponse{Response: true, Data: result})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Write(data)
+}
+
+//createInvite - endpoint to invite someone to create an account
+func (router Router) createInvite(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, invite, token, err := router.Auth.CreateInvite(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	if err = router.Emailer.InviteEmail(invite, token); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	go router.Log.LogEvent(logw.Event{Message: "Invite email sent: " + invite.Email})
+	router.goodRequest(w)
+}
+
+//resendInvite - endpoint to email a new link for a pending invite
+func (router Router) resendInvite(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	invite, token, err := router.Auth.ResendInvite(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	if err = router.Emailer.InviteEmail(invite, token); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	go router.Log.LogEvent(logw.Event{Message: "Invite email resent: " + invite.Email})
+	router.goodRequest(w)
+}
+
+//revokeInvite - endpoint to cancel a pending invite
+func (router Router) revokeInvite(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	if err := router.Auth.RevokeInvite(router.getSession(r), &request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//acceptInvite - endpoint to create an account from an invite
+func (router Router) acceptInvite(w http.ResponseWriter, r *http.Request) {
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.AcceptInvite(&request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//magicLink - endpoint to email a login link
+func (router Router) magicLink(w http.ResponseWriter, r *http.Request) {
+	//Hard limiter is set on this request
+	if !router.HardLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.MagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Always respond the same so emails cannot be checked for accounts
+	link, token, err := router.Auth.RequestMagicLink(&request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.goodRequest(w)
+		return
+	}
+
+	if err = router.Emailer.MagicLinkEmail(link, token); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	go router.Log.LogEvent(logw.Event{Message: "Magic link email sent: " + link.Email})
+	router.goodRequest(w)
+}
+
+//verifyMagicLink - endpoint to login with a magic link
+func (router Router) verifyMagicLink(w http.ResponseWriter, r *http.Request) {
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.MagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	account, device, err := router.Auth.VerifyMagicLink(&request, router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.loginResponse(w, account, device)
+}
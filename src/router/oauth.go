@@ -0,0 +1,416 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"net/url"
+	"strings"
+	"types"
+)
+
+//---------------OAUTH HELPERS BELOW-------------------\\
+
+//oauthError - returns an oauth error response
+func (router Router) oauthError(w http.ResponseWriter, status int, err error) {
+	oauthErr, ok := err.(types.OAuthError)
+	if !ok {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		oauthErr = types.OAuthError{Code: "server_error"}
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	data, _ := json.Marshal(oauthErr)
+	w.Write(data)
+}
+
+//redirectWith - redirects to the uri with the query parameters added
+func (router Router) redirectWith(w http.ResponseWriter, r *http.Request, uri string, params url.Values) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		router.badRequest(w)
+		return
+	}
+	query := u.Query()
+	for key, values := range params {
+		for _, value := range values {
+			if value != "" {
+				query.Add(key, value)
+			}
+		}
+	}
+	u.RawQuery = query.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+//setUpPublicHeaders - sets the headers for endpoints any origin can read
+func (router Router) setUpPublicHeaders(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Max-Age", "120")
+	w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(200)
+		return false
+	}
+	return true
+}
+
+//getBearerToken - returns the bearer token from the authorization header
+func (router Router) getBearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return header[7:]
+	}
+	return ""
+}
+
+//getTokenLookupRequest - returns the introspection or revocation parameters of a request
+func (router Router) getTokenLookupRequest(r *http.Request) (*types.TokenLookupRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	request := types.TokenLookupRequest{
+		Token:        r.PostForm.Get("token"),
+		DeviceID:     r.PostForm.Get("device_id"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+	}
+	if id, secret, ok := r.BasicAuth(); ok {
+		request.ClientID = id
+		request.ClientSecret = secret
+	}
+	return &request, nil
+}
+
+//---------------OAUTH ROUTES BELOW-------------------\\
+
+//openIDConfiguration - endpoint for the openid connect discovery document
+func (router Router) openIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpPublicHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	data, err := json.Marshal(router.Auth.OpenIDConfiguration())
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+	w.Write(data)
+}
+
+//jwks - endpoint for the public signing keys
+func (router Router) jwks(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpPublicHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	data, err := json.Marshal(router.Auth.Signer.JWKS())
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+	w.Write(data)
+}
+
+//authorize - endpoint to start an authorization code flow
+func (router Router) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := types.AuthorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		Nonce:               query.Get("nonce"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	//Never redirect to an unregistered uri
+	if _, err := router.Auth.GetAuthorizeClient(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.oauthError(w, http.StatusBadRequest, types.OAuthError{Code: "invalid_request", Description: "Invalid client or redirect_uri"})
+		return
+	}
+
+	//Send the user to login first, the app will return them here afterwards
+	if _, err := router.Auth.CheckAccountSession(router.getSession(r)); err != nil {
+		continueURL := router.Auth.Config.OAuth.Issuer + r.URL.RequestURI()
+		router.redirectWith(w, r, router.Host+"/login", url.Values{"continue": {continueURL}})
+		return
+	}
+
+	code, err := router.Auth.Authorize(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		oauthErr, ok := err.(types.OAuthError)
+		if !ok {
+			oauthErr = types.OAuthError{Code: "server_error"}
+		}
+		router.redirectWith(w, r, request.RedirectURI, url.Values{"error": {oauthErr.Code}, "error_description": {oauthErr.Description}, "state": {request.State}})
+		return
+	}
+
+	router.redirectWith(w, r, request.RedirectURI, url.Values{"code": {code.ID}, "state": {request.State}})
+}
+
+//token - endpoint to exchange a grant for tokens
+func (router Router) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		router.oauthError(w, http.StatusMethodNotAllowed, types.OAuthError{Code: "invalid_request", Description: "POST required"})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		router.oauthError(w, http.StatusBadRequest, types.OAuthError{Code: "invalid_request", Description: err.Error()})
+		return
+	}
+
+	request := types.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		ClientID:     r.PostForm.Get("client_id"),
+		Scope:        r.PostForm.Get("scope"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+	}
+
+	//Prefer client_secret_basic
+	if id, secret, ok := r.BasicAuth(); ok {
+		request.ClientID = id
+		request.ClientSecret = secret
+	}
+
+	response, err := router.Auth.Token(&request)
+	if err != nil {
+		status := http.StatusBadRequest
+		if oauthErr, ok := err.(types.OAuthError); ok && oauthErr.Code == "invalid_client" {
+			status = http.StatusUnauthorized
+		}
+		router.oauthError(w, status, err)
+		return
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		router.oauthError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(data)
+}
+
+//introspect - endpoint for resource servers to check a token (RFC 7662)
+func (router Router) introspect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		router.oauthError(w, http.StatusMethodNotAllowed, types.OAuthError{Code: "invalid_request", Description: "POST required"})
+		return
+	}
+	request, err := router.getTokenLookupRequest(r)
+	if err != nil {
+		router.oauthError(w, http.StatusBadRequest, types.OAuthError{Code: "invalid_request", Description: err.Error()})
+		return
+	}
+
+	response, err := router.Auth.Introspect(request)
+	if err != nil {
+		router.oauthError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		router.oauthError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(data)
+}
+
+//revoke - endpoint for clients to revoke a token (RFC 7009)
+func (router Router) revoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		router.oauthError(w, http.StatusMethodNotAllowed, types.OAuthError{Code: "invalid_request", Description: "POST required"})
+		return
+	}
+	request, err := router.getTokenLookupRequest(r)
+	if err != nil {
+		router.oauthError(w, http.StatusBadRequest, types.OAuthError{Code: "invalid_request", Description: err.Error()})
+		return
+	}
+
+	err = router.Auth.Revoke(request)
+	if err != nil {
+		status := http.StatusBadRequest
+		if oauthErr, ok := err.(types.OAuthError); ok && oauthErr.Code == "invalid_client" {
+			status = http.StatusUnauthorized
+		}
+		router.oauthError(w, status, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+//userInfo - endpoint to return the claims of an access token
+func (router Router) userInfo(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpPublicHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	claims, err := router.Auth.UserInfo(router.getBearerToken(r))
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		router.oauthError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		router.oauthError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Write(data)
+}
+
+//endSession - endpoint for relying party initiated logout
+func (router Router) endSession(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := types.EndSessionRequest{
+		IDTokenHint:           query.Get("id_token_hint"),
+		PostLogoutRedirectURI: query.Get("post_logout_redirect_uri"),
+		State:                 query.Get("state"),
+	}
+
+	redirect, err := router.Auth.EndSession(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.oauthError(w, http.StatusBadRequest, types.OAuthError{Code: "invalid_request", Description: "Invalid logout request"})
+		return
+	}
+
+	router.addCookie(w, "sessionId", "")
+
+	if redirect == "" {
+		http.Redirect(w, r, router.Host, http.StatusFound)
+		return
+	}
+	router.redirectWith(w, r, redirect, url.Values{"state": {request.State}})
+}
+
+//createClient - endpoint to register an oauth client (ADMINS ONLY)
+func (router Router) createClient(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.CreateClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	client, secret, err := router.Auth.CreateClient(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	data, err := json.Marshal(types.ClientResponse{Response: true, Client: client, Secret: secret})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	go router.Log.LogEvent(logw.Event{Message: "OAuth client created: " + client.Name})
+	w.Write(data)
+}
+
+//getClients - endpoint to get all oauth clients (ADMINS ONLY)
+func (router Router) getClients(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	clients, err := router.Auth.GetAllClients(router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	data, err := json.Marshal(types.AllClientsResponse{Response: true, Data: clients})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Write(data)
+}
+
+//rotateClientSecret - endpoint to replace an oauth client secret (ADMINS ONLY)
+func (router Router) rotateClientSecret(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.ClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	client, secret, err := router.Auth.RotateClientSecret(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	data, err := json.Marshal(types.ClientResponse{Response: true, Client: client, Secret: secret})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	go router.Log.LogEvent(logw.Event{Message: "OAuth client secret rotated: " + client.Name})
+	w.Write(data)
+}
+
+//revokeClient - endpoint to revoke an oauth client (ADMINS ONLY)
+func (router Router) revokeClient(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.ClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	err := router.Auth.RevokeClient(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	go router.Log.LogEvent(logw.Event{Message: "OAuth client revoked: " + request.ID})
+	router.goodRequest(w)
+}
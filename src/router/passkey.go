@@ -0,0 +1,135 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//passkeyOptions - writes the options for the browser with the state of the passkey registration or login
+func (router Router) passkeyOptions(w http.ResponseWriter, state string, options *types.PasskeyOptions) {
+	data, err := json.Marshal(types.PasskeyOptionsResponse{Response: true, State: state, Options: options})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Write(data)
+}
+
+//passkeyRegistrationOptions - endpoint to start adding a passkey to the session account
+func (router Router) passkeyRegistrationOptions(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	var request types.LinkIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	state, options, err := router.Auth.PasskeyRegistrationOptions(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.passkeyOptions(w, state, options)
+}
+
+//registerPasskey - endpoint to link the passkey the browser created to the session account
+func (router Router) registerPasskey(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	var credential types.PasskeyCredential
+	if err := json.NewDecoder(r.Body).Decode(&credential); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.RegisterPasskey(router.getSession(r), &credential)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//passkeyLoginOptions - endpoint to start a login with a passkey
+func (router Router) passkeyLoginOptions(w http.ResponseWriter, r *http.Request) {
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	state, options, err := router.Auth.PasskeyLoginOptions()
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.passkeyOptions(w, state, options)
+}
+
+//passkeyLogin - endpoint to login with the passkey the browser signed with
+func (router Router) passkeyLogin(w http.ResponseWriter, r *http.Request) {
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var credential types.PasskeyCredential
+	if err := json.NewDecoder(r.Body).Decode(&credential); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	account, device, err := router.Auth.PasskeyLogin(&credential, router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.loginResponse(w, account, device)
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//getPersonalTokens - endpoint to list the personal access tokens of the session account.
+//?accountId= lists the tokens of a service account instead
+func (router Router) getPersonalTokens(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	request := types.PersonalTokenRequest{AccountID: r.URL.Query().Get("accountId")}
+	tokens, err := router.Auth.GetPersonalTokens(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	data, err := json.Marshal(types.PersonalTokensResponse{Response: true, Data: tokens})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Write(data)
+}
+
+//createPersonalToken - endpoint to create a personal access token. The token is only shown in this response
+func (router Router) createPersonalToken(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.PersonalTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, plain, token, err := router.Auth.CreatePersonalToken(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	data, err := json.Marshal(types.PersonalTokenResponse{Response: true, Data: token, Token: plain})
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(data)
+}
+
+//revokePersonalToken - endpoint to revoke a personal access token
+func (router Router) revokePersonalToken(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.PersonalTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	err := router.Auth.RevokePersonalToken(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.goodRequest(w)
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//sendPhoneCode - endpoint to text a verification code to the phone of the session account
+func (router Router) sendPhoneCode(w http.ResponseWriter, r *http.Request) {
+	//Hard limiter is set on this request
+	if !router.HardLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	account, verification, err := router.Auth.RequestPhoneVerification(router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	if err = router.SMS.Send(verification.Phone, "Your verification code is: "+verification.Code); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	go router.Log.LogEvent(logw.Event{Message: "Phone verification sent: " + account.Email})
+	router.goodRequest(w)
+}
+
+//verifyPhone - endpoint to verify the phone of the session account
+func (router Router) verifyPhone(w http.ResponseWriter, r *http.Request) {
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.PhoneVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.VerifyPhone(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//setDeliveryChannel - endpoint to choose where device codes are sent
+func (router Router) setDeliveryChannel(w http.ResponseWriter, r *http.Request) {
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.DeliveryChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.SetDeliveryChannel(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//sendPendingPhoneCode - texts the code confirming a new phone. Nil if the phone did not change.
+//The change is saved even if sending fails, the code can be sent again with sendPhoneCode
+func (router Router) sendPendingPhoneCode(verification *types.PhoneVerification) {
+	if verification == nil {
+		return
+	}
+	if err := router.SMS.Send(verification.Phone, "Your verification code is: "+verification.Code); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+	}
+}
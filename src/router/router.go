@@ -7,9 +7,10 @@ import (
 	"fmt"
 	"logw"
 	"net/http"
+	"sms"
+	"strings"
 	"time"
 	"types"
-	"utils"
 
 	"github.com/gorilla/mux"
 	"golang.org/x/time/rate"
@@ -19,6 +20,7 @@ import (
 type Router struct {
 	Auth        *auth.Authenticate
 	Emailer     *emailer.Emailer
+	SMS         sms.Sender
 	Log         *logw.Log
 	Host        string
 	HardLimiter *rate.Limiter
@@ -35,6 +37,7 @@ func (router Router) Init(auth *auth.Authenticate, config *types.Config) {
 	//Setup Helpers
 	router.Auth = auth
 	router.Emailer = emailer.Emailer{}.Init(config)
+	router.SMS = sms.Init(config)
 	router.Log = logw.Log{}.Init(config)
 	router.Host = config.Host
 
@@ -51,11 +54,26 @@ func (router Router) setUpRoutes(r *mux.Router) {
 	r.HandleFunc("/api/auth/login", router.login)
 	r.HandleFunc("/api/auth/logout", router.logout)
 	r.HandleFunc("/api/auth/checkSession", router.checkSession)
+	r.HandleFunc("/api/auth/authorize", router.checkPermission)
 	r.HandleFunc("/api/auth/register", router.registerAccount)
+	r.HandleFunc("/api/auth/signup", router.signup)
+	r.HandleFunc("/api/auth/signup/verify", router.verifySignup)
+	r.HandleFunc("/api/auth/signup/resend", router.resendVerification)
 	r.HandleFunc("/api/auth/delete", router.deleteAccount)
+	r.HandleFunc("/api/auth/getDeletedAccounts", router.getDeletedAccounts)
+	r.HandleFunc("/api/auth/restoreAccount", router.restoreAccount)
+	r.HandleFunc("/api/auth/export", router.exportOwnData)
+	r.HandleFunc("/api/auth/exportAccount", router.exportAccount)
+	r.HandleFunc("/api/auth/export/download", router.downloadExport)
+	r.HandleFunc("/api/auth/deleteMyAccount", router.deleteMyAccount)
+	r.HandleFunc("/api/auth/deleteMyAccount/cancel", router.cancelDeletion)
+	r.HandleFunc("/api/auth/deleteMyAccount/code", router.sendDeletionCode)
+	r.HandleFunc("/api/auth/suspendAccount", router.suspendAccount)
+	r.HandleFunc("/api/auth/reactivateAccount", router.reactivateAccount)
 	r.HandleFunc("/api/auth/getAllAccounts", router.getAllAccounts)
 	r.HandleFunc("/api/auth/getAccounts", router.getAccounts)
 	r.HandleFunc("/api/auth/updateSettings", router.updateSettings)
+	r.HandleFunc("/api/auth/changeUserName", router.changeUserName)
 	r.HandleFunc("/api/auth/updateAccountSettings", router.updateAccountSettings)
 	r.HandleFunc("/api/auth/activateDevice", router.activateDevice)
 	r.HandleFunc("/api/auth/recoverAccount", router.recoverAccount)
@@ -65,6 +83,66 @@ func (router Router) setUpRoutes(r *mux.Router) {
 	r.HandleFunc("/api/auth/disableTwoFA", router.disableTwoFA)
 	r.HandleFunc("/api/auth/changeEmail", router.changeEmail)
 	r.HandleFunc("/api/auth/finishEmailChange", router.finishEmailChange)
+	r.HandleFunc("/api/auth/sendPhoneCode", router.sendPhoneCode)
+	r.HandleFunc("/api/auth/verifyPhone", router.verifyPhone)
+	r.HandleFunc("/api/auth/setDeliveryChannel", router.setDeliveryChannel)
+	r.HandleFunc("/api/auth/tokens", router.getPersonalTokens)
+	r.HandleFunc("/api/auth/tokens/create", router.createPersonalToken)
+	r.HandleFunc("/api/auth/tokens/revoke", router.revokePersonalToken)
+	r.HandleFunc("/api/auth/serviceAccounts", router.getServiceAccounts)
+	r.HandleFunc("/api/auth/serviceAccounts/create", router.createServiceAccount)
+	r.HandleFunc("/api/auth/roles", router.getRoles)
+	r.HandleFunc("/api/auth/roles/create", router.createRole)
+	r.HandleFunc("/api/auth/roles/update", router.updateRole)
+	r.HandleFunc("/api/auth/roles/delete", router.deleteRole)
+	r.HandleFunc("/api/auth/roles/assign", router.setAccountRoles)
+	r.HandleFunc("/api/auth/permissions", router.getPermissions)
+	r.HandleFunc("/api/auth/groups", router.getGroups)
+	r.HandleFunc("/api/auth/groups/create", router.createGroup)
+	r.HandleFunc("/api/auth/groups/update", router.updateGroup)
+	r.HandleFunc("/api/auth/groups/delete", router.deleteGroup)
+	r.HandleFunc("/api/auth/groups/members/add", router.addGroupMember)
+	r.HandleFunc("/api/auth/groups/members/remove", router.removeGroupMember)
+	r.HandleFunc("/api/auth/attributes", router.getAttributes)
+	r.HandleFunc("/api/auth/attributes/create", router.createAttribute)
+	r.HandleFunc("/api/auth/attributes/update", router.updateAttribute)
+	r.HandleFunc("/api/auth/attributes/delete", router.deleteAttribute)
+	r.HandleFunc("/api/auth/orgs", router.getOrgs)
+	r.HandleFunc("/api/auth/orgs/create", router.createOrg)
+	r.HandleFunc("/api/auth/orgs/switch", router.switchOrg)
+	r.HandleFunc("/api/auth/orgs/members/add", router.addOrgMember)
+	r.HandleFunc("/api/auth/orgs/members/remove", router.removeOrgMember)
+	r.HandleFunc("/api/auth/invites", router.getInvites)
+	r.HandleFunc("/api/auth/invites/create", router.createInvite)
+	r.HandleFunc("/api/auth/invites/resend", router.resendInvite)
+	r.HandleFunc("/api/auth/invites/revoke", router.revokeInvite)
+	r.HandleFunc("/api/auth/invites/accept", router.acceptInvite)
+	r.HandleFunc("/api/auth/magicLink", router.magicLink)
+	r.HandleFunc("/api/auth/magicLink/verify", router.verifyMagicLink)
+	r.HandleFunc("/api/auth/oauth/createClient", router.createClient)
+	r.HandleFunc("/api/auth/oauth/getClients", router.getClients)
+	r.HandleFunc("/api/auth/oauth/rotateClientSecret", router.rotateClientSecret)
+	r.HandleFunc("/api/auth/oauth/revokeClient", router.revokeClient)
+	r.HandleFunc("/.well-known/openid-configuration", router.openIDConfiguration)
+	r.HandleFunc("/.well-known/jwks.json", router.jwks)
+	r.HandleFunc("/oauth/authorize", router.authorize)
+	r.HandleFunc("/oauth/token", router.token)
+	r.HandleFunc("/oauth/logout", router.endSession)
+	r.HandleFunc("/oauth/introspect", router.introspect)
+	r.HandleFunc("/oauth/revoke", router.revoke)
+	r.HandleFunc("/userinfo", router.userInfo)
+	r.HandleFunc("/api/auth/federated/{provider}/login", router.federatedLogin)
+	r.HandleFunc("/api/auth/federated/{provider}/callback", router.federatedCallback)
+	r.HandleFunc("/api/auth/saml/metadata", router.samlMetadata)
+	r.HandleFunc("/api/auth/saml/acs", router.samlACS)
+	r.HandleFunc("/api/auth/saml/{provider}/login", router.samlLogin)
+	r.HandleFunc("/api/auth/identities", router.getIdentities)
+	r.HandleFunc("/api/auth/identities/link", router.linkIdentity)
+	r.HandleFunc("/api/auth/passkeys/register/options", router.passkeyRegistrationOptions)
+	r.HandleFunc("/api/auth/passkeys/register", router.registerPasskey)
+	r.HandleFunc("/api/auth/passkeys/login/options", router.passkeyLoginOptions)
+	r.HandleFunc("/api/auth/passkeys/login", router.passkeyLogin)
+	r.HandleFunc("/api/auth/identities/unlink", router.unlinkIdentity)
 }
 
 //---------------HELPERS BELOW-------------------\\
@@ -162,8 +240,34 @@ func (router Router) getIP(r *http.Request) string {
 	return IPAddress
 }
 
+//sendDeviceCode - sends the account the code to activate a new device
+func (router Router) sendDeviceCode(account *types.Account, device *types.Device) error {
+	//Text the code if the account chose to and its phone is verified
+	if account.DeliveryChannel == types.ChannelSMS && account.PhoneVerified {
+		if err := router.SMS.Send(account.Phone, "Your new device code is: "+device.Code); err != nil {
+			return err
+		}
+		go router.Log.LogEvent(logw.Event{Message: "New device SMS sent: " + account.Email})
+		return nil
+	}
+
+	if err := router.Emailer.NewDeviceEmail(account, device); err != nil {
+		return err
+	}
+	go router.Log.LogEvent(logw.Event{Message: "New device email sent: " + account.Email})
+	return nil
+}
+
+//safeContinue - returns the url if it points back to the app or this service. Prevents open redirects
+func (router Router) safeContinue(continueURL string) string {
+	if strings.HasPrefix(continueURL, router.Host+"/") || strings.HasPrefix(continueURL, router.Auth.Config.OAuth.Issuer+"/") {
+		return continueURL
+	}
+	return ""
+}
+
 func (router Router) getSession(r *http.Request) *types.Session {
-	return &types.Session{Token: router.getSessionID(r), Device: router.getDeviceID(r)}
+	return &types.Session{Token: router.getSessionID(r), Device: router.getDeviceID(r), Bearer: router.getBearerToken(r), IP: router.getIP(r)}
 }
 
 //---------------ROUTES BELOW-------------------\\
@@ -194,12 +298,16 @@ func (router Router) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	router.loginResponse(w, account, device)
+}
+
+//loginResponse - sets the session of a login and tells the client if the device needs activating
+func (router Router) loginResponse(w http.ResponseWriter, account *types.Account, device *types.Device) {
 	if account != nil {
 		router.addCookie(w, "sessionId", account.Token)
 		account = account.HideImportant()
-		account.GetAccountPermissions()
 
-		if utils.Contains("ADMIN", account.Roles) || account.TwoFA {
+		if account.IsPrivileged() || account.TwoFA {
 			//DEVICE was not found.
 			if device == nil {
 				router.badRequest(w)
@@ -207,8 +315,8 @@ func (router Router) login(w http.ResponseWriter, r *http.Request) {
 			}
 			//Device needs activation.
 			if !device.Active {
-				//Send New Device Email
-				if err = router.Emailer.NewDeviceEmail(account, device); err != nil {
+				//Send New Device Code
+				if err := router.sendDeviceCode(account, device); err != nil {
 					go router.Log.LogError(logw.Error{Message: err.Error()})
 					router.badRequest(w)
 					return
@@ -222,8 +330,7 @@ func (router Router) login(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 
-				//Device needs setup. Log and send response to client
-				go router.Log.LogEvent(logw.Event{Message: "New device email sent: " + account.Email})
+				//Device needs setup. Send response to client
 				router.addCookie(w, "deviceId", device.ID)
 				w.Write(data)
 				return
@@ -277,6 +384,12 @@ func (router Router) checkSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := router.Auth.LoadAttributes(acc); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
 	res, err := json.Marshal(types.AccountResponse{Response: true, Account: acc.HideImportant()})
 	if err != nil {
 		go router.Log.LogError(logw.Error{Message: err.Error()})
@@ -420,7 +533,7 @@ func (router Router) updateAccountSettings(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	res, err := router.Auth.UpdateOtherAccountSettings(&account, router.getSession(r))
+	res, verification, err := router.Auth.UpdateOtherAccountSettings(&account, router.getSession(r))
 	//Some error occured while trying to create the account
 	if err != nil {
 		go router.Log.LogError(logw.Error{Message: err.Error()})
@@ -434,6 +547,9 @@ func (router Router) updateAccountSettings(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	//The account confirms its new phone with the code sent to it
+	router.sendPendingPhoneCode(verification)
+
 	//Account Updated
 	router.goodRequest(w)
 }
@@ -450,7 +566,7 @@ func (router Router) updateSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := router.Auth.UpdateAccountSettings(&account, router.getSession(r))
+	res, verification, err := router.Auth.UpdateAccountSettings(&account, router.getSession(r))
 	//Some error occured while trying to create the account
 	if err != nil {
 		go router.Log.LogError(logw.Error{Message: err.Error()})
@@ -464,10 +580,49 @@ func (router Router) updateSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	//A new phone is confirmed with the code sent to it
+	router.sendPendingPhoneCode(verification)
+
 	//Account Updated
 	router.goodRequest(w)
 }
 
+//changeUserName - endpoint for an account to change its own username
+func (router Router) changeUserName(w http.ResponseWriter, r *http.Request) {
+	//Hard limiter is set on this request
+	if !router.HardLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.ChangeUserNameRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, err := router.Auth.ChangeUserName(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	//UserName changed
+	router.goodRequest(w)
+}
+
 //activateDevice - endpoint to activate a device
 func (router Router) activateDevice(w http.ResponseWriter, r *http.Request) {
 	//Limit amount of times can be attempted
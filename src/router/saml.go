@@ -0,0 +1,77 @@
+package router
+
+import (
+	"html/template"
+	"logw"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+)
+
+//Auto submitted form for the HTTP-POST binding
+var samlPostForm = template.Must(template.New("samlPost").Parse(`<!DOCTYPE html>
+<html><body onload="document.forms[0].submit()">
+<form method="POST" action="{{.URL}}">
+<input type="hidden" name="SAMLRequest" value="{{.SAMLRequest}}">
+<input type="hidden" name="RelayState" value="{{.RelayState}}">
+<noscript><button type="submit">Continue</button></noscript>
+</form>
+</body></html>`))
+
+//samlMetadata - endpoint for the metadata saml identity providers use to register this service
+func (router Router) samlMetadata(w http.ResponseWriter, r *http.Request) {
+	metadata, err := router.Auth.SAMLMetadata()
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.Write(metadata)
+}
+
+//samlLogin - endpoint to start a login with a saml identity provider
+func (router Router) samlLogin(w http.ResponseWriter, r *http.Request) {
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	continueURL := router.safeContinue(r.URL.Query().Get("continue"))
+
+	request, err := router.Auth.SAMLLoginRequest(mux.Vars(r)["provider"], continueURL)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.redirectWith(w, r, router.Host+"/login", url.Values{"error": {"federated_login_failed"}})
+		return
+	}
+
+	if request.Post {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		samlPostForm.Execute(w, request)
+		return
+	}
+
+	http.Redirect(w, r, request.URL, http.StatusFound)
+}
+
+//samlACS - endpoint saml identity providers post responses to
+func (router Router) samlACS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	account, device, continueURL, err := router.Auth.SAMLLogin(r.PostFormValue("RelayState"), r.PostFormValue("SAMLResponse"), router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.redirectWith(w, r, router.Host+"/login", url.Values{"error": {"federated_login_failed"}})
+		return
+	}
+
+	router.finishExternalLogin(w, r, account, device, continueURL, "SAML")
+}
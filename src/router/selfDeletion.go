@@ -0,0 +1,116 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//deleteMyAccount - endpoint for an account to schedule its own deletion
+func (router Router) deleteMyAccount(w http.ResponseWriter, r *http.Request) {
+	//Hard limiter is set on this request
+	if !router.HardLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.SelfDeletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, deletion, token, err := router.Auth.RequestDeletion(router.getSession(r), &request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	//The deletion is scheduled even if the confirmation cannot be sent
+	if err := router.Emailer.DeletionRequestEmail(deletion, token); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+	}
+
+	router.goodRequest(w)
+}
+
+//sendDeletionCode - endpoint to send the one-time code confirming an account deletion.
+//Texted if the account chose to and its phone is verified, emailed otherwise
+func (router Router) sendDeletionCode(w http.ResponseWriter, r *http.Request) {
+	//Hard limiter is set on this request
+	if !router.HardLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	res, account, device, err := router.Auth.SendDeletionCode(router.getSession(r))
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	if account.DeliveryChannel == types.ChannelSMS && account.PhoneVerified {
+		err = router.SMS.Send(account.Phone, "Your account deletion code is: "+device.Code)
+	} else {
+		err = router.Emailer.DeletionCodeEmail(account, device)
+	}
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//cancelDeletion - endpoint to cancel a scheduled account deletion with the link from its confirmation email
+func (router Router) cancelDeletion(w http.ResponseWriter, r *http.Request) {
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.SelfDeletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	if err := router.Auth.CancelDeletion(router.getSession(r), &request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.goodRequest(w)
+}
@@ -0,0 +1,117 @@
+package router
+
+import (
+	"encoding/json"
+	"logw"
+	"net/http"
+	"types"
+)
+
+//signup - endpoint for anyone to create an account when sign up is enabled
+func (router Router) signup(w http.ResponseWriter, r *http.Request) {
+	//Hard limiter is set on this request
+	if !router.HardLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.SignupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	res, verification, token, err := router.Auth.Signup(&request)
+	if err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Return a bad response with the reason
+	if res != "" {
+		router.reasonRequest(w, false, res)
+		return
+	}
+
+	if err = router.Emailer.VerifyEmail(verification, token); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	go router.Log.LogEvent(logw.Event{Message: "Verification email sent: " + verification.Email})
+	router.goodRequest(w)
+}
+
+//verifySignup - endpoint to verify the email of a signed up account
+func (router Router) verifySignup(w http.ResponseWriter, r *http.Request) {
+	//Medium limiter is set on this request
+	if !router.MedLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.SignupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	if err := router.Auth.VerifySignup(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	router.goodRequest(w)
+}
+
+//resendVerification - endpoint to email a new verification link
+func (router Router) resendVerification(w http.ResponseWriter, r *http.Request) {
+	//Hard limiter is set on this request
+	if !router.HardLimiter.Allow() {
+		router.tooManyRequests(w)
+		return
+	}
+
+	if !router.setUpHeaders(w, r) {
+		return //request was an OPTIONS which was handled.
+	}
+
+	var request types.SignupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.badRequest(w)
+		return
+	}
+
+	//Always respond the same so emails cannot be checked for accounts
+	verification, token, err := router.Auth.ResendVerification(&request)
+	if err != nil || verification == nil {
+		if err != nil {
+			go router.Log.LogError(logw.Error{Message: err.Error()})
+		}
+		router.goodRequest(w)
+		return
+	}
+
+	if err = router.Emailer.VerifyEmail(verification, token); err != nil {
+		go router.Log.LogError(logw.Error{Message: err.Error()})
+		router.goodRequest(w)
+		return
+	}
+
+	go router.Log.LogEvent(logw.Event{Message: "Verification email resent: " + verification.Email})
+	router.goodRequest(w)
+}
@@ -0,0 +1,326 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"types"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+//XML namespaces
+const (
+	protocolNS  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	assertionNS = "urn:oasis:names:tc:SAML:2.0:assertion"
+	metadataNS  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	postBinding = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	successCode = "urn:oasis:names:tc:SAML:2.0:status:Success"
+)
+
+//How far apart the clocks of the identity provider and this service may be
+const clockSkew = 90 * time.Second
+
+//Default attribute names of account fields
+var defaultAttributes = map[string]string{
+	"userName": "uid",
+	"name":     "displayName",
+	"email":    "email",
+	"phone":    "telephoneNumber",
+	"groups":   "groups",
+}
+
+//Provider - external saml 2.0 identity provider
+type Provider struct {
+	Config      types.SAMLProviderConfig
+	EntityID    string //Entity id of this service
+	ACSURL      string //Where the identity provider posts responses to
+	Certificate *x509.Certificate
+	Clock       *dsig.Clock //Time responses are checked at. Nil uses the system clock
+}
+
+//Init - setup a provider. Responses are rejected if the certificate cannot be read
+func (provider Provider) Init(config types.SAMLProviderConfig, entityID string, acsURL string) *Provider {
+	provider.Config = config
+	provider.EntityID = entityID
+	provider.ACSURL = acsURL
+
+	block, _ := pem.Decode([]byte(config.Certificate))
+	if block == nil {
+		fmt.Println("Invalid SAML certificate for provider: " + config.Name)
+		return &provider
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		fmt.Println("Invalid SAML certificate for provider: " + config.Name + " " + err.Error())
+		return &provider
+	}
+	provider.Certificate = cert
+
+	return &provider
+}
+
+//Request - returns the authentication request to send the user to the provider with
+func (provider Provider) Request(id string, relayState string) (*types.SAMLRequest, error) {
+	doc := etree.NewDocument()
+	request := doc.CreateElement("samlp:AuthnRequest")
+	request.CreateAttr("xmlns:samlp", protocolNS)
+	request.CreateAttr("xmlns:saml", assertionNS)
+	request.CreateAttr("ID", id)
+	request.CreateAttr("Version", "2.0")
+	request.CreateAttr("IssueInstant", time.Now().UTC().Format(time.RFC3339))
+	request.CreateAttr("Destination", provider.Config.SSOURL)
+	request.CreateAttr("AssertionConsumerServiceURL", provider.ACSURL)
+	request.CreateAttr("ProtocolBinding", postBinding)
+	request.CreateElement("saml:Issuer").SetText(provider.EntityID)
+
+	xml, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	if provider.Config.PostBinding {
+		return &types.SAMLRequest{URL: provider.Config.SSOURL, SAMLRequest: base64.StdEncoding.EncodeToString(xml), RelayState: relayState, Post: true}, nil
+	}
+
+	//HTTP-Redirect binding deflates the request
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	writer.Write(xml)
+	writer.Close()
+
+	query := url.Values{
+		"SAMLRequest": {base64.StdEncoding.EncodeToString(buf.Bytes())},
+		"RelayState":  {relayState},
+	}
+	separator := "?"
+	if strings.Contains(provider.Config.SSOURL, "?") {
+		separator = "&"
+	}
+	return &types.SAMLRequest{URL: provider.Config.SSOURL + separator + query.Encode(), RelayState: relayState}, nil
+}
+
+//ParseResponse - verifies a base64 encoded response to the request with the id given. Returns the signed assertion
+func (provider Provider) ParseResponse(encoded string, requestID string) (*types.SAMLAssertion, error) {
+	if provider.Certificate == nil {
+		return nil, errors.New("No SAML certificate for provider: " + provider.Config.Name)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil {
+		return nil, err
+	}
+	response := doc.Root()
+	if response == nil || response.Tag != "Response" || response.NamespaceURI() != protocolNS {
+		return nil, errors.New("Invalid SAML response")
+	}
+
+	if response.SelectAttrValue("InResponseTo", "") != requestID {
+		return nil, errors.New("SAML response is not for this request")
+	}
+	if destination := response.SelectAttrValue("Destination", ""); destination != "" && destination != provider.ACSURL {
+		return nil, errors.New("Invalid SAML response destination: " + destination)
+	}
+	status := child(child(response, protocolNS, "Status"), protocolNS, "StatusCode")
+	if status == nil || status.SelectAttrValue("Value", "") != successCode {
+		return nil, errors.New("SAML login failed")
+	}
+
+	assertion, err := provider.signedAssertion(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.checkAssertion(assertion, requestID)
+}
+
+//signedAssertion - returns the assertion from a response. Either the response or the assertion must be signed.
+//Only the element returned by the signature validation is trusted
+func (provider Provider) signedAssertion(response *etree.Element) (*etree.Element, error) {
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{provider.Certificate}})
+	ctx.Clock = provider.Clock
+
+	if child(response, dsig.Namespace, "Signature") != nil {
+		validated, err := ctx.Validate(response)
+		if err != nil {
+			return nil, err
+		}
+		if assertions := children(validated, assertionNS, "Assertion"); len(assertions) == 1 {
+			return assertions[0], nil
+		}
+		return nil, errors.New("SAML response must contain exactly one assertion")
+	}
+
+	assertions := children(response, assertionNS, "Assertion")
+	if len(assertions) != 1 {
+		return nil, errors.New("SAML response must contain exactly one assertion")
+	}
+	if child(assertions[0], dsig.Namespace, "Signature") == nil {
+		return nil, errors.New("SAML assertion is not signed")
+	}
+
+	//Namespaces declared on the response are needed to check the assertion on its own
+	nsCtx, err := etreeutils.NSBuildParentContext(assertions[0])
+	if err != nil {
+		return nil, err
+	}
+	assertion, err := etreeutils.NSDetatch(nsCtx, assertions[0])
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Validate(assertion)
+}
+
+//checkAssertion - checks the issuer, audience, recipient and times of an assertion
+func (provider Provider) checkAssertion(assertion *etree.Element, requestID string) (*types.SAMLAssertion, error) {
+	now := provider.Clock.Now()
+
+	if issuer := child(assertion, assertionNS, "Issuer"); issuer == nil || issuer.Text() != provider.Config.EntityID {
+		return nil, errors.New("Invalid SAML assertion issuer")
+	}
+
+	subject := child(assertion, assertionNS, "Subject")
+	nameID := child(subject, assertionNS, "NameID")
+	if nameID == nil || nameID.Text() == "" {
+		return nil, errors.New("SAML assertion has no subject")
+	}
+
+	//At least one bearer confirmation must be for this request
+	confirmed := false
+	for _, confirmation := range children(subject, assertionNS, "SubjectConfirmation") {
+		data := child(confirmation, assertionNS, "SubjectConfirmationData")
+		if confirmation.SelectAttrValue("Method", "") != "urn:oasis:names:tc:SAML:2.0:cm:bearer" || data == nil {
+			continue
+		}
+		if data.SelectAttrValue("Recipient", "") != provider.ACSURL || data.SelectAttrValue("InResponseTo", "") != requestID {
+			continue
+		}
+		if notOnOrAfter, err := time.Parse(time.RFC3339, data.SelectAttrValue("NotOnOrAfter", "")); err != nil || !now.Before(notOnOrAfter.Add(clockSkew)) {
+			continue
+		}
+		confirmed = true
+	}
+	if !confirmed {
+		return nil, errors.New("SAML assertion subject could not be confirmed")
+	}
+
+	conditions := child(assertion, assertionNS, "Conditions")
+	if conditions == nil {
+		return nil, errors.New("SAML assertion has no conditions")
+	}
+	if notBefore := conditions.SelectAttrValue("NotBefore", ""); notBefore != "" {
+		t, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil || now.Add(clockSkew).Before(t) {
+			return nil, errors.New("SAML assertion is not valid yet")
+		}
+	}
+	if notOnOrAfter := conditions.SelectAttrValue("NotOnOrAfter", ""); notOnOrAfter != "" {
+		t, err := time.Parse(time.RFC3339, notOnOrAfter)
+		if err != nil || !now.Before(t.Add(clockSkew)) {
+			return nil, errors.New("SAML assertion expired")
+		}
+	}
+	audience := false
+	for _, restriction := range children(conditions, assertionNS, "AudienceRestriction") {
+		for _, a := range children(restriction, assertionNS, "Audience") {
+			if a.Text() == provider.EntityID {
+				audience = true
+			}
+		}
+	}
+	if !audience {
+		return nil, errors.New("SAML assertion is not for this service")
+	}
+
+	result := types.SAMLAssertion{Subject: nameID.Text(), Attributes: map[string][]string{}}
+	for _, statement := range children(assertion, assertionNS, "AttributeStatement") {
+		for _, attribute := range children(statement, assertionNS, "Attribute") {
+			name := attribute.SelectAttrValue("Name", "")
+			for _, value := range children(attribute, assertionNS, "AttributeValue") {
+				result.Attributes[name] = append(result.Attributes[name], value.Text())
+			}
+		}
+	}
+
+	return &result, nil
+}
+
+//Attribute - returns the name of the attribute mapped to an account field
+func (provider Provider) Attribute(field string) string {
+	if name, ok := provider.Config.Attributes[field]; ok {
+		return name
+	}
+	return defaultAttributes[field]
+}
+
+//Role - returns the highest role of the groups given. Returns the default role if none are mapped
+func (provider Provider) Role(groups []string) int {
+	return types.GetGroupRole(groups, provider.Config.GroupRoles, provider.Config.DefaultRole)
+}
+
+//Metadata - returns the metadata identity providers use to register this service
+func Metadata(entityID string, acsURL string) ([]byte, error) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	descriptor := doc.CreateElement("md:EntityDescriptor")
+	descriptor.CreateAttr("xmlns:md", metadataNS)
+	descriptor.CreateAttr("entityID", entityID)
+
+	sp := descriptor.CreateElement("md:SPSSODescriptor")
+	sp.CreateAttr("AuthnRequestsSigned", "false")
+	sp.CreateAttr("WantAssertionsSigned", "true")
+	sp.CreateAttr("protocolSupportEnumeration", protocolNS)
+	sp.CreateElement("md:NameIDFormat").SetText("urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified")
+
+	acs := sp.CreateElement("md:AssertionConsumerService")
+	acs.CreateAttr("Binding", postBinding)
+	acs.CreateAttr("Location", acsURL)
+	acs.CreateAttr("index", "0")
+	acs.CreateAttr("isDefault", "true")
+
+	doc.Indent(2)
+	return doc.WriteToBytes()
+}
+
+//child - returns the first child element with the namespace and tag given
+func child(el *etree.Element, namespace string, tag string) *etree.Element {
+	if el == nil {
+		return nil
+	}
+	for _, c := range el.ChildElements() {
+		if c.Tag == tag && c.NamespaceURI() == namespace {
+			return c
+		}
+	}
+	return nil
+}
+
+//children - returns all child elements with the namespace and tag given
+func children(el *etree.Element, namespace string, tag string) []*etree.Element {
+	found := []*etree.Element{}
+	if el == nil {
+		return found
+	}
+	for _, c := range el.ChildElements() {
+		if c.Tag == tag && c.NamespaceURI() == namespace {
+			found = append(found, c)
+		}
+	}
+	return found
+}
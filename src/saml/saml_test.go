@@ -0,0 +1,156 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"types"
+
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+//Responses in testdata were signed offline with the key of idp.crt for request _request1.
+//Their assertions are valid from 12:00 to 12:05 on 2026-10-18
+var issued = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+//testProvider - returns a provider trusting the test identity provider with its clock set to the time given
+func testProvider(t *testing.T, now time.Time) *Provider {
+	cert, err := ioutil.ReadFile("testdata/idp.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := Provider{}.Init(types.SAMLProviderConfig{
+		Name:        "test",
+		EntityID:    "https://idp.example.com/metadata",
+		SSOURL:      "https://idp.example.com/sso",
+		Certificate: string(cert),
+	}, "https://auth.example.com/api/auth/saml/metadata", "https://auth.example.com/api/auth/saml/acs")
+	provider.Clock = dsig.NewFakeClockAt(now)
+	return provider
+}
+
+//fixture - returns a response from testdata with replacements applied
+func fixture(t *testing.T, name string, replacements ...string) string {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml := strings.NewReplacer(replacements...).Replace(string(data))
+	return base64.StdEncoding.EncodeToString([]byte(xml))
+}
+
+func TestParseResponse(t *testing.T) {
+	for _, name := range []string{"assertion_signed.xml", "response_signed.xml"} {
+		assertion, err := testProvider(t, issued.Add(time.Minute)).ParseResponse(fixture(t, name), "_request1")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if assertion.Subject != "alice-1234" {
+			t.Errorf("%s: subject %q; want alice-1234", name, assertion.Subject)
+		}
+		if assertion.Attributes["email"][0] != "alice@example.com" || assertion.Attributes["displayName"][0] != "Alice Smith" || len(assertion.Attributes["groups"]) != 2 {
+			t.Errorf("%s: unexpected attributes %v", name, assertion.Attributes)
+		}
+	}
+}
+
+func TestParseResponseRejected(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		replacements []string
+		now          time.Time
+		requestID    string
+	}{
+		{"other request", "assertion_signed.xml", nil, issued, "_request2"},
+		{"expired", "assertion_signed.xml", nil, issued.Add(10 * time.Minute), "_request1"},
+		{"not valid yet", "assertion_signed.xml", nil, issued.Add(-10 * time.Minute), "_request1"},
+		{"changed subject", "assertion_signed.xml", []string{"alice-1234", "admin-1"}, issued, "_request1"},
+		{"changed attribute", "response_signed.xml", []string{"alice@example.com", "admin@example.com"}, issued, "_request1"},
+		{"changed destination", "assertion_signed.xml", []string{`Destination="https://auth.example.com`, `Destination="https://evil.example.com`}, issued, "_request1"},
+		{"failed status", "assertion_signed.xml", []string{"status:Success", "status:Responder"}, issued, "_request1"},
+		{"removed signature", "assertion_signed.xml", []string{"ds:Signature", "ds:Removed"}, issued, "_request1"},
+		{"not a response", "assertion_signed.xml", []string{"samlp:Response", "samlp:LogoutResponse"}, issued, "_request1"},
+	}
+	for _, test := range tests {
+		if _, err := testProvider(t, test.now).ParseResponse(fixture(t, test.file, test.replacements...), test.requestID); err == nil {
+			t.Errorf("%s: response accepted", test.name)
+		}
+	}
+}
+
+func TestParseResponseWrongProvider(t *testing.T) {
+	//Another service must not accept the assertion
+	provider := testProvider(t, issued)
+	provider.EntityID = "https://other.example.com/metadata"
+	if _, err := provider.ParseResponse(fixture(t, "assertion_signed.xml"), "_request1"); err == nil {
+		t.Error("response accepted for another audience")
+	}
+
+	//Responses signed by another provider are rejected
+	provider = testProvider(t, issued)
+	provider.Config.EntityID = "https://other-idp.example.com/metadata"
+	if _, err := provider.ParseResponse(fixture(t, "assertion_signed.xml"), "_request1"); err == nil {
+		t.Error("response accepted from another issuer")
+	}
+
+	provider = testProvider(t, issued)
+	provider.Certificate = nil
+	if _, err := provider.ParseResponse(fixture(t, "assertion_signed.xml"), "_request1"); err == nil {
+		t.Error("response accepted without a certificate")
+	}
+}
+
+func TestRequest(t *testing.T) {
+	provider := testProvider(t, issued)
+
+	request, err := provider.Request("_request1", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(request.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Post || !strings.HasPrefix(request.URL, "https://idp.example.com/sso?") || parsed.Query().Get("RelayState") != "state" {
+		t.Fatalf("unexpected request: %+v", request)
+	}
+	deflated, err := base64.StdEncoding.DecodeString(parsed.Query().Get("SAMLRequest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(xml), `ID="_request1"`) || !strings.Contains(string(xml), `AssertionConsumerServiceURL="https://auth.example.com/api/auth/saml/acs"`) {
+		t.Errorf("unexpected request: %s", xml)
+	}
+
+	provider.Config.PostBinding = true
+	request, err = provider.Request("_request1", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !request.Post || request.URL != "https://idp.example.com/sso" || request.SAMLRequest == "" {
+		t.Errorf("unexpected post request: %+v", request)
+	}
+}
+
+func TestAttributeAndRole(t *testing.T) {
+	provider := testProvider(t, issued)
+	provider.Config.Attributes = map[string]string{"email": "mail"}
+	provider.Config.GroupRoles = map[string]int{"admins": types.LevelAdmin}
+
+	if provider.Attribute("email") != "mail" || provider.Attribute("userName") != "uid" {
+		t.Errorf("unexpected attributes: %s, %s", provider.Attribute("email"), provider.Attribute("userName"))
+	}
+	if role := provider.Role([]string{"staff", "Admins"}); role != types.LevelAdmin {
+		t.Errorf("role %d; want %d", role, types.LevelAdmin)
+	}
+}
@@ -0,0 +1,90 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+	"types"
+)
+
+//Sender - sends text messages
+type Sender interface {
+	Send(to string, message string) error
+}
+
+//Init - returns the sender chosen in the config
+func Init(config *types.Config) Sender {
+	if config.SMS.Provider == "http" {
+		return HTTPSender{}.Init(config.SMS)
+	}
+	return FileSender{Path: config.SMS.File}
+}
+
+//FileSender - writes messages to a file instead of sending them. Writes to the console if no file is set
+type FileSender struct {
+	Path string
+}
+
+//Send - writes the message
+func (sender FileSender) Send(to string, message string) error {
+	line := time.Now().Format(time.RFC3339) + " SMS to " + to + ": " + message + "\n"
+	if sender.Path == "" {
+		fmt.Print(line)
+		return nil
+	}
+
+	file, err := os.OpenFile(sender.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(line)
+	return err
+}
+
+//HTTPSender - sends messages through an http sms gateway
+type HTTPSender struct {
+	URL    string
+	APIKey string
+	From   string
+	Client *http.Client
+}
+
+//Init - setup the gateway sender
+func (sender HTTPSender) Init(config types.SMSConfig) *HTTPSender {
+	sender.URL = config.URL
+	sender.APIKey = config.APIKey
+	sender.From = config.From
+	sender.Client = &http.Client{Timeout: 10 * time.Second}
+	return &sender
+}
+
+//Send - posts the message to the gateway
+func (sender HTTPSender) Send(to string, message string) error {
+	body, err := json.Marshal(map[string]string{"from": sender.From, "to": to, "message": message})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sender.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+sender.APIKey)
+
+	res, err := sender.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.New("SMS gateway request failed: " + res.Status)
+	}
+	return nil
+}
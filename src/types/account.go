@@ -3,22 +3,64 @@ package types
 import (
 	"errors"
 	"regexp"
+	"strings"
 	"time"
 )
 
+//Delivery channels for device codes
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+//Account types
+const (
+	AccountUser    = "user"
+	AccountService = "service" //Cannot login, only uses tokens
+	AccountClient  = "client"  //Machine client making an admin request. Never stored
+)
+
+//Account statuses. Every status but active blocks login and sessions
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusLocked    = "locked"
+	StatusPending   = "pending"
+)
+
 //Account - struct for account class
 type Account struct {
-	ID       string    `sql:"id" json:"id"`
-	UserName string    `sql:"userName" json:"userName"`
-	Password string    `sql:"password" json:"password"`
-	Name     string    `sql:"name" json:"name"`
-	Phone    string    `sql:"phone" json:"phone"`
-	Email    string    `sql:"email" json:"email"`
-	Role     int       `sql:"role" json:"role"`
-	Token    string    `sql:"token" json:"token"`
-	TwoFA    bool      `sql:"twoFA" json:"twoFA"`
-	Roles    []string  `json:"roles"`
-	Created  time.Time `sql:"created" json:"created"`
+	ID                  string            `sql:"id" json:"id"`
+	UserName            string            `sql:"userName" json:"userName"`
+	Password            string            `sql:"password" json:"password"`
+	Name                string            `sql:"name" json:"name"`
+	Phone               string            `sql:"phone" json:"phone"`
+	Email               string            `sql:"email" json:"email"`
+	Role                int               `sql:"role" json:"role"`
+	Token               string            `sql:"token" json:"token"`
+	TwoFA               bool              `sql:"twoFA" json:"twoFA"`
+	PhoneVerified       bool              `sql:"phoneVerified" json:"phoneVerified"`
+	PendingPhone        string            `sql:"pendingPhone" json:"pendingPhone"`               //New phone waiting for its code to be confirmed
+	PendingVerification bool              `sql:"pendingVerification" json:"pendingVerification"` //Signed up and has not verified its email. Cannot login
+	DeliveryChannel     string            `sql:"deliveryChannel" json:"deliveryChannel"`         //Where device codes are sent
+	Type                string            `sql:"type" json:"type"`
+	OwnerID             string            `sql:"ownerId" json:"ownerId"`     //Account that manages a service account
+	ActiveOrg           string            `sql:"activeOrg" json:"activeOrg"` //Organization the session is working in. Empty for none
+	OrgRole             int               `sql:"orgRole" json:"orgRole"`     //Role in the organization it was listed for
+	OrgUnique           bool              `sql:"-" json:"-"`                 //Only check duplicates against accounts sharing an organization
+	Roles               []string          `json:"roles"`                     //Role names
+	Permissions         []string          `json:"permissions"`               //Permissions of all roles
+	Rank                int               `sql:"-" json:"-"`                 //Highest rank of its roles
+	Attributes          map[string]string `json:"attributes"`                //Custom attribute values by name. Only those the viewer can see
+	Status              string            `sql:"status" json:"status"`
+	StatusReason        string            `sql:"statusReason" json:"statusReason"`
+	StatusUntil         *time.Time        `sql:"statusUntil" json:"statusUntil"` //When the status ends. Nil until reactivated
+	DeletedAt           *time.Time        `sql:"deletedAt" json:"deletedAt"`     //Set while a deleted account can still be restored
+	DeletedUserName     string            `sql:"deletedUserName" json:"deletedUserName"`
+	DeletedEmail        string            `sql:"deletedEmail" json:"deletedEmail"`
+	UserNameChanged     *time.Time        `sql:"userNameChanged" json:"userNameChanged"` //Last time the account changed its own username
+	LastLogin           *time.Time        `sql:"lastLogin" json:"lastLogin"`
+	Created             time.Time         `sql:"created" json:"created"`
 }
 
 //CheckUserName - verify username is valid.
@@ -54,14 +96,61 @@ func (account Account) CheckEmail() error {
 	return nil
 }
 
-//CheckPhone - verify phone is valid.
+//CheckPhone - verify phone is valid. Phones are stored in E.164 format, see NormalizePhone
 func (account Account) CheckPhone() error {
-	if !regexp.MustCompile(`^[\+]?[(]?[0-9]{3}[)]?[-\s\.]?[0-9]{3}[-\s\.]?[0-9]{4,6}$`).MatchString(account.Phone) {
+	if !regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`).MatchString(account.Phone) {
 		return errors.New("Invalid phone number: " + account.Phone)
 	}
 	return nil
 }
 
+//NormalizePhone - returns a phone number in E.164 format (+15551234567). Spaces, dashes, dots and brackets are removed.
+//Numbers without a + or 00 prefix are given the country code, dropping a leading trunk 0
+func NormalizePhone(phone string, countryCode string) (string, error) {
+	if phone == "" {
+		return "", nil
+	}
+
+	number := regexp.MustCompile(`[\s\-\.\(\)]`).ReplaceAllString(phone, "")
+	switch {
+	case strings.HasPrefix(number, "+"):
+	case strings.HasPrefix(number, "00"):
+		number = "+" + number[2:]
+	case countryCode != "":
+		number = "+" + strings.TrimPrefix(countryCode, "+") + strings.TrimPrefix(number, "0")
+	}
+
+	if err := (Account{Phone: number}).CheckPhone(); err != nil {
+		return "", errors.New("Invalid phone number: " + phone)
+	}
+	return number, nil
+}
+
+//IsBlocked - checks if the status of the account stops it from logging in
+func (account Account) IsBlocked() bool {
+	if account.Status == "" || account.Status == StatusActive {
+		return false
+	}
+	return account.StatusUntil == nil || time.Now().Before(*account.StatusUntil)
+}
+
+//IsService - checks if the account is a service account
+func (account Account) IsService() bool {
+	return account.Type == AccountService
+}
+
+//ChangePhone - sets a new phone. A new phone must be verified before codes are sent to it
+func (account *Account) ChangePhone(phone string) {
+	if account.Phone == phone {
+		return
+	}
+	account.Phone = phone
+	account.PhoneVerified = false
+	if account.DeliveryChannel == ChannelSMS {
+		account.DeliveryChannel = ChannelEmail
+	}
+}
+
 //HideImportant - Hides sensative info on the account
 func (account Account) HideImportant() *Account {
 	account.Password = ""
@@ -76,16 +165,17 @@ func (account Account) HideInfo() Account {
 	return account
 }
 
-//----------!!! UPDATE BOTH FUNCTIONS AT THE SAME TIME !!!------------\\
-
-//GetAccountPermissions - return the accounts permission. Return a pointer
-func (account Account) GetAccountPermissions() *Account {
-	account.Roles = GetRoles(account.Role)
-	return &account
+//Can - checks if the account has a permission. Its roles must be loaded first
+func (account Account) Can(permission string) bool {
+	for _, p := range account.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
 }
 
-//GetPermissions - return the accounts permission. Returns a value
-func (account Account) GetPermissions() Account {
-	account.Roles = GetRoles(account.Role)
-	return account
+//IsPrivileged - accounts with any permission must verify their devices
+func (account Account) IsPrivileged() bool {
+	return len(account.Permissions) > 0
 }
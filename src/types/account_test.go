@@ -0,0 +1,30 @@
+package types
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone       string
+		countryCode string
+		want        string
+		wantErr     bool
+	}{
+		{"", "1", "", false},
+		{"+15551234567", "1", "+15551234567", false},
+		{"555-123-4567", "1", "+15551234567", false},
+		{"(555) 123.4567", "1", "+15551234567", false},
+		{"0044 20 7946 0958", "1", "+442079460958", false},
+		{"020 7946 0958", "44", "+442079460958", false},
+		{"020 7946 0958", "+44", "+442079460958", false},
+		{"555-123-4567", "", "", true},
+		{"+0551234567", "1", "", true},
+		{"phone", "1", "", true},
+		{"+1234", "1", "", true},
+	}
+	for _, test := range tests {
+		got, err := NormalizePhone(test.phone, test.countryCode)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("NormalizePhone(%q, %q) = %q, %v; want %q, error %v", test.phone, test.countryCode, got, err, test.want, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package types
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Attribute types
+const (
+	AttributeText    = "text"
+	AttributeNumber  = "number"
+	AttributeDate    = "date" //YYYY-MM-DD
+	AttributeBoolean = "boolean"
+	AttributeSelect  = "select" //One of the options
+)
+
+//Attribute visibility
+const (
+	VisibilitySelf   = "self"   //Account can see and edit it
+	VisibilityAdmin  = "admin"  //Account can see it, only admins edit it
+	VisibilityHidden = "hidden" //Only admins see and edit it
+)
+
+//AttributeDefinition - custom profile field admins add to every account
+type AttributeDefinition struct {
+	ID         string    `sql:"id" json:"id"`
+	Name       string    `sql:"name" json:"name"` //Key of the value in Account.Attributes
+	Label      string    `sql:"label" json:"label"`
+	Type       string    `sql:"type" json:"type"`
+	Required   bool      `sql:"required" json:"required"` //Cannot be cleared once set
+	Pattern    string    `sql:"pattern" json:"pattern"`   //Regular expression text values must match
+	Min        *float64  `sql:"minValue" json:"min"`      //Lowest number or shortest text
+	Max        *float64  `sql:"maxValue" json:"max"`      //Highest number or longest text
+	Options    string    `sql:"options" json:"options"`   //Comma separated choices of a select
+	Visibility string    `sql:"visibility" json:"visibility"`
+	Created    time.Time `sql:"created" json:"created"`
+}
+
+//Check - verify the definition is valid
+func (def AttributeDefinition) Check() error {
+	if !regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,49}$`).MatchString(def.Name) {
+		return errors.New("Attribute name must start with a letter and only have letters, digits or _")
+	}
+	switch def.Type {
+	case AttributeText, AttributeNumber, AttributeDate, AttributeBoolean:
+	case AttributeSelect:
+		if len(def.OptionList()) == 0 {
+			return errors.New("Select attribute needs options: " + def.Name)
+		}
+	default:
+		return errors.New("Invalid attribute type: " + def.Type)
+	}
+	switch def.Visibility {
+	case VisibilitySelf, VisibilityAdmin, VisibilityHidden:
+	default:
+		return errors.New("Invalid attribute visibility: " + def.Visibility)
+	}
+	if _, err := regexp.Compile(def.Pattern); err != nil {
+		return errors.New("Invalid attribute pattern: " + def.Pattern)
+	}
+	if def.Min != nil && def.Max != nil && *def.Min > *def.Max {
+		return errors.New("Attribute min cannot be more than max: " + def.Name)
+	}
+	return nil
+}
+
+//OptionList - returns the choices of a select attribute
+func (def AttributeDefinition) OptionList() []string {
+	options := []string{}
+	for _, option := range strings.Split(def.Options, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+//VisibleTo - checks if the account or an admin can see the attribute
+func (def AttributeDefinition) VisibleTo(admin bool) bool {
+	return admin || def.Visibility != VisibilityHidden
+}
+
+//EditableBy - checks if the account or an admin can change the attribute
+func (def AttributeDefinition) EditableBy(admin bool) bool {
+	return admin || def.Visibility == VisibilitySelf
+}
+
+//CheckValue - verify a value is valid for the attribute. Empty clears it
+func (def AttributeDefinition) CheckValue(value string) error {
+	if value == "" {
+		if def.Required {
+			return errors.New(def.Label + " is required")
+		}
+		return nil
+	}
+	if len(value) > 255 {
+		return errors.New(def.Label + " must be 255 characters or less")
+	}
+
+	switch def.Type {
+	case AttributeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.New(def.Label + " must be a number")
+		}
+		if (def.Min != nil && number < *def.Min) || (def.Max != nil && number > *def.Max) {
+			return errors.New(def.Label + " is out of range")
+		}
+	case AttributeDate:
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return errors.New(def.Label + " must be a date (YYYY-MM-DD)")
+		}
+	case AttributeBoolean:
+		if value != "true" && value != "false" {
+			return errors.New(def.Label + " must be true or false")
+		}
+	case AttributeSelect:
+		for _, option := range def.OptionList() {
+			if option == value {
+				return nil
+			}
+		}
+		return errors.New(def.Label + " must be one of: " + strings.Join(def.OptionList(), ", "))
+	default:
+		if (def.Min != nil && float64(len(value)) < *def.Min) || (def.Max != nil && float64(len(value)) > *def.Max) {
+			return errors.New(def.Label + " has an invalid length")
+		}
+	}
+
+	if def.Pattern != "" && !regexp.MustCompile(def.Pattern).MatchString(value) {
+		return errors.New(def.Label + " is invalid")
+	}
+	return nil
+}
+
+//AttributeRequest - id of an attribute definition
+type AttributeRequest struct {
+	ID string `json:"id"`
+}
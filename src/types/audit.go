@@ -0,0 +1,28 @@
+package types
+
+import "time"
+
+//Audit events
+const (
+	AuditLogin             = "login"
+	AuditLoginFailed       = "login_failed"
+	AuditAccountDeleted    = "account_deleted"
+	AuditAccountRestored   = "account_restored"
+	AuditStatusChanged     = "status_changed"
+	AuditRolesChanged      = "roles_changed"
+	AuditExportRequested   = "export_requested"
+	AuditDeletionRequested = "deletion_requested"
+	AuditDeletionCancelled = "deletion_cancelled"
+	AuditUserNameChanged   = "username_changed"
+)
+
+//AuditEvent - something that happened to an account
+type AuditEvent struct {
+	ID        string    `sql:"id" json:"id"`
+	AccountID string    `sql:"accountId" json:"accountId"`
+	ActorID   string    `sql:"actorId" json:"actorId"` //Account that did it. Same as AccountID for its own actions
+	Event     string    `sql:"event" json:"event"`
+	Detail    string    `sql:"detail" json:"detail"`
+	IP        string    `sql:"ip" json:"ip"`
+	Created   time.Time `sql:"created" json:"created"`
+}
@@ -23,11 +23,118 @@ type EmailConfig struct {
 	SMTPPort    int
 }
 
+//SMSConfig - text message sender
+type SMSConfig struct {
+	Provider string //"http" to use an sms gateway, otherwise messages are written to File
+	File     string //Empty writes messages to the console
+	URL      string //Gateway url messages are posted to
+	APIKey   string
+	From     string
+}
+
+//PhoneConfig - phone number settings
+type PhoneConfig struct {
+	CountryCode string //Calling code given to numbers entered without one. Empty requires a + or 00 prefix
+	Unique      bool   //Only one account can have a phone number
+	ResendWait  int    //Minutes before another code can be sent to an account. Defaults to 1
+}
+
+//OAuthConfig - oauth/openid connect provider settings
+type OAuthConfig struct {
+	Issuer          string
+	KeyFile         string
+	AccessTokenTTL  int
+	RefreshTokenTTL int
+}
+
+//IdentityProviderConfig - external openid connect provider users can sign in with
+type IdentityProviderConfig struct {
+	Name         string //Used in the login and callback urls
+	Issuer       string //Discovery document is read from Issuer + /.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	DefaultRole  int //Role given to accounts created on first login
+}
+
+//SAMLProviderConfig - external saml 2.0 identity provider users can sign in with.
+//Names are shared with IdentityProviderConfig and must be unique
+type SAMLProviderConfig struct {
+	Name        string            //Used in the login url
+	EntityID    string            //Issuer of the identity provider
+	SSOURL      string            //Single sign on url of the identity provider
+	Certificate string            //PEM certificate the identity provider signs responses with
+	PostBinding bool              //Send requests with the HTTP-POST binding instead of HTTP-Redirect
+	Attributes  map[string]string //Account field (userName, name, email, phone, groups) to attribute name
+	TrustEmail  bool              //Link to an existing account with the same email on first login
+	GroupRoles  map[string]int    //Group to role. The highest role of the groups a user is in is used
+	DefaultRole int               //Role given when the user is in none of the groups
+}
+
+//LDAPConfig - ldap / active directory server users can login with
+type LDAPConfig struct {
+	URL          string //ldap://host:389 or ldaps://host:636. Empty disables ldap login
+	StartTLS     bool
+	BindDN       string //Account used to search for users
+	BindPassword string
+	BaseDN       string
+	UserFilter   string         //%s is replaced with the login. e.g. (&(objectClass=user)(|(sAMAccountName=%s)(mail=%s)))
+	IDAttribute  string         //Attribute that never changes for a user. e.g. objectGUID or entryUUID. The DN is used if empty
+	GroupRoles   map[string]int //Group DN to role. The highest role of the groups a user is in is used
+	DefaultRole  int            //Role given when the user is in none of the groups
+}
+
+//PasskeyConfig - passkey (webauthn) login settings
+type PasskeyConfig struct {
+	RPID   string //Domain passkeys are bound to. e.g. example.com. Empty disables passkeys
+	RPName string //Site name shown by the authenticator
+	Origin string //Origin the app runs on. e.g. https://app.example.com. Empty uses Host
+}
+
+//OrgConfig - organization settings
+type OrgConfig struct {
+	UniquePerOrg bool //Usernames and emails only need to be unique within each organization
+}
+
+//SignupConfig - public sign up settings
+type SignupConfig struct {
+	Enabled    bool //Anyone can create an account with /api/auth/signup
+	ResendWait int  //Minutes before another verification email can be sent
+}
+
+//DeletionConfig - deleted account settings
+type DeletionConfig struct {
+	RestoreDays  int  //Days a deleted account can be restored before it is purged
+	ReserveNames bool //Deleted accounts keep their username and email until purged
+	GraceDays    int  //Days before an account's own deletion request is carried out
+}
+
+//UserNameConfig - username change settings
+type UserNameConfig struct {
+	CooldownDays int      //Days an account must wait between changing its username
+	HoldDays     int      //Days an old username stays with its account before others can claim it
+	Reserved     []string //Usernames no account can take
+}
+
 //Config - runtime config
 type Config struct {
 	MySQL       MySQLConfig
 	Redis       RedisConfig
 	Email       EmailConfig
+	SMS         SMSConfig
+	Phones      PhoneConfig
+	OAuth       OAuthConfig
+	Providers   []IdentityProviderConfig
+	SAML        []SAMLProviderConfig
+	LDAP        LDAPConfig
+	Passkeys    PasskeyConfig
+	LoginOrder  []string
+	PolicyFile  string
+	Orgs        OrgConfig
+	InviteTTL   int //Hours an invite link can be used for
+	Signup      SignupConfig
+	Deletion    DeletionConfig
+	UserNames   UserNameConfig
 	ServerPort  string
 	Host        string
 	LogDuration float64
@@ -0,0 +1,20 @@
+package types
+
+import "time"
+
+//DeletionRequest - deletion an account requested for itself. Carried out at ScheduledFor unless cancelled
+type DeletionRequest struct {
+	ID           string    `sql:"id"` //Hash of the token sent in the cancel link
+	AccountID    string    `sql:"accountId"`
+	Email        string    `sql:"email"` //Where the confirmation is sent
+	Created      time.Time `sql:"created"`
+	ScheduledFor time.Time `sql:"scheduledFor"`
+}
+
+//SelfDeletionRequest - struct for an account requesting or cancelling its own deletion.
+//Password re-authenticates the account. Accounts without a password give the one-time code sent to them instead
+type SelfDeletionRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+	Token    string `json:"token"`
+}
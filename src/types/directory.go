@@ -0,0 +1,12 @@
+package types
+
+//DirectoryUser - user found in an ldap directory
+type DirectoryUser struct {
+	ID       string
+	DN       string
+	UserName string
+	Name     string
+	Email    string
+	Phone    string
+	Groups   []string
+}
@@ -0,0 +1,35 @@
+package types
+
+import "time"
+
+//Export formats
+const (
+	ExportJSON = "json"
+	ExportZIP  = "zip"
+)
+
+//Export statuses
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+//Export - bundle of the data held about an account. Built in the background and downloaded with a link
+type Export struct {
+	ID          string     `sql:"id"` //Hash of the token sent in the link
+	AccountID   string     `sql:"accountId"`
+	RequestedBy string     `sql:"requestedBy"`
+	Email       string     `sql:"email"` //Where the link is sent
+	Format      string     `sql:"format"`
+	Status      string     `sql:"status"`
+	File        string     `sql:"file"`
+	Created     time.Time  `sql:"created"`
+	Completed   *time.Time `sql:"completed"`
+}
+
+//ExportRequest - struct for requesting an export. Admins can set the account
+type ExportRequest struct {
+	AccountID string `json:"accountId"`
+	Format    string `json:"format"`
+}
@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+//Group - set of accounts and other groups. Members get the roles of the group and of every group containing it
+type Group struct {
+	ID          string    `sql:"id" json:"id"`
+	Name        string    `sql:"name" json:"name"`
+	Description string    `sql:"description" json:"description"`
+	Roles       []string  `json:"roles"`   //Role names
+	Groups      []string  `json:"groups"`  //Ids of groups nested in this group
+	Members     []string  `json:"members"` //Ids of accounts in this group
+	Created     time.Time `sql:"created" json:"created"`
+}
+
+//GroupRequest - id of a group
+type GroupRequest struct {
+	ID string `json:"id"`
+}
+
+//GroupMemberRequest - account or group to add to or remove from a group
+type GroupMemberRequest struct {
+	GroupID       string `json:"groupId"`
+	AccountID     string `json:"accountId"`
+	MemberGroupID string `json:"memberGroupId"`
+}
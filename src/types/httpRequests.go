@@ -1,11 +1,27 @@
 package types
 
+import "time"
+
 //GetAccountsRequest - type of account wanted
 type GetAccountsRequest struct {
-	Roles []int `json:"roles"`
+	Roles []string `json:"roles"` //Role names
 }
 
 //DeleteAccountRequest - Id of the account being deletes
 type DeleteAccountRequest struct {
 	ID string `json:"id"`
 }
+
+//ChangeUserNameRequest - new username of the session account. Password re-authenticates the account
+type ChangeUserNameRequest struct {
+	UserName string `json:"userName"`
+	Password string `json:"password"`
+}
+
+//AccountStatusRequest - status to give an account
+type AccountStatusRequest struct {
+	ID     string     `json:"id"`
+	Status string     `json:"status"`
+	Reason string     `json:"reason"`
+	Until  *time.Time `json:"until"` //Optional end of the status
+}
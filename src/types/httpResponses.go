@@ -42,3 +42,96 @@ type ReasonResponse struct {
 	Response bool   `json:"response"`
 	Reason   string `json:"reason"`
 }
+
+//ClientResponse - return a client with its secret. Secret is only shown once
+type ClientResponse struct {
+	Response bool         `json:"response"`
+	Client   *OAuthClient `json:"client"`
+	Secret   string       `json:"secret"`
+}
+
+//AllClientsResponse - return success with data
+type AllClientsResponse struct {
+	Response bool           `json:"response"`
+	Data     *[]OAuthClient `json:"data"`
+}
+
+//IdentitiesResponse - return success with data
+type IdentitiesResponse struct {
+	Response bool        `json:"response"`
+	Data     *[]Identity `json:"data"`
+}
+
+//URLResponse - return a url to send the user to
+type URLResponse struct {
+	Response bool   `json:"response"`
+	URL      string `json:"url"`
+}
+
+//PasskeyOptionsResponse - return the options for the browser and the state to send back with the passkey
+type PasskeyOptionsResponse struct {
+	Response bool            `json:"response"`
+	State    string          `json:"state"`
+	Options  *PasskeyOptions `json:"publicKey"`
+}
+
+//PersonalTokenResponse - return a new personal access token. The token is only ever returned here
+type PersonalTokenResponse struct {
+	Response bool           `json:"response"`
+	Data     *PersonalToken `json:"data"`
+	Token    string         `json:"token"`
+}
+
+//PersonalTokensResponse - return success with data
+type PersonalTokensResponse struct {
+	Response bool             `json:"response"`
+	Data     *[]PersonalToken `json:"data"`
+}
+
+//RolesResponse - return success with data
+type RolesResponse struct {
+	Response bool    `json:"response"`
+	Data     *[]Role `json:"data"`
+}
+
+//PermissionsResponse - return success with data
+type PermissionsResponse struct {
+	Response bool          `json:"response"`
+	Data     *[]Permission `json:"data"`
+}
+
+//PermissionDecisionResponse - return the result of a permission check
+type PermissionDecisionResponse struct {
+	Response bool                `json:"response"`
+	Data     *PermissionDecision `json:"data"`
+}
+
+//OrgsResponse - return success with data
+type OrgsResponse struct {
+	Response bool            `json:"response"`
+	Data     *[]Organization `json:"data"`
+}
+
+//OrgResponse - return success with data
+type OrgResponse struct {
+	Response bool          `json:"response"`
+	Data     *Organization `json:"data"`
+}
+
+//GroupsResponse - return success with data
+type GroupsResponse struct {
+	Response bool     `json:"response"`
+	Data     *[]Group `json:"data"`
+}
+
+//AttributesResponse - return success with data
+type AttributesResponse struct {
+	Response bool                   `json:"response"`
+	Data     *[]AttributeDefinition `json:"data"`
+}
+
+//InvitesResponse - return success with data
+type InvitesResponse struct {
+	Response bool      `json:"response"`
+	Data     *[]Invite `json:"data"`
+}
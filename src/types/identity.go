@@ -0,0 +1,57 @@
+package types
+
+import "time"
+
+//Identity types
+const (
+	IdentityPassword  = "password"
+	IdentityFederated = "federated"
+	IdentityPasskey   = "passkey"
+	IdentityDirectory = "directory"
+)
+
+//Login providers
+const (
+	LocalProvider = "local" //Provider of password identities
+	LDAPProvider  = "ldap"
+)
+
+//Identity - login identity linked to an account
+type Identity struct {
+	ID        string    `sql:"id" json:"id"`
+	AccountID string    `sql:"accountId" json:"accountId"`
+	Type      string    `sql:"type" json:"type"`
+	Provider  string    `sql:"provider" json:"provider"`
+	Subject   string    `sql:"subject" json:"subject"`
+	Email     string    `sql:"email" json:"email"`
+	PublicKey []byte    `sql:"publicKey" json:"-"` //COSE key of a passkey
+	SignCount uint32    `sql:"signCount" json:"-"` //Signature counter of a passkey
+	Created   time.Time `sql:"created" json:"created"`
+}
+
+//FederatedState - pending login with an external identity provider
+type FederatedState struct {
+	ID        string    `sql:"id"`
+	Provider  string    `sql:"provider"`
+	Nonce     string    `sql:"nonce"`
+	Verifier  string    `sql:"verifier"`
+	Continue  string    `sql:"continueUrl"`
+	AccountID string    `sql:"accountId"` //Set when linking to an existing account instead of logging in
+	Created   time.Time `sql:"created"`
+}
+
+//LinkIdentityRequest - identity to link to the session account
+type LinkIdentityRequest struct {
+	Provider      string `json:"provider"` //"local" to add a password, "ldap" for a directory user, otherwise an identity provider name
+	Password      string `json:"password"` //Current password to re-authenticate
+	NewPassword   string `json:"newPassword"`
+	Login         string `json:"login"`         //Directory username when linking ldap
+	LoginPassword string `json:"loginPassword"` //Directory password when linking ldap
+	Continue      string `json:"continue"`
+}
+
+//UnlinkIdentityRequest - identity to remove from the session account
+type UnlinkIdentityRequest struct {
+	ID       string `json:"id"`
+	Password string `json:"password"` //Current password to re-authenticate
+}
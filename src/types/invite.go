@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+//Invite - pending invitation for someone to create an account
+type Invite struct {
+	ID        string    `sql:"id" json:"id"`
+	Email     string    `sql:"email" json:"email"`
+	Name      string    `sql:"name" json:"name"`
+	Role      int       `sql:"role" json:"role"`   //Role in the organization when OrgID is set
+	OrgID     string    `sql:"orgId" json:"orgId"` //Organization the account joins
+	InvitedBy string    `sql:"invitedBy" json:"invitedBy"`
+	Created   time.Time `sql:"created" json:"created"`
+	Expires   time.Time `sql:"expires" json:"expires"`
+}
+
+//InviteRequest - struct for sending, resending, revoking and accepting invites
+type InviteRequest struct {
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Role     int    `json:"role"`
+	Token    string `json:"token"`
+	UserName string `json:"userName"`
+	Password string `json:"password"`
+	Phone    string `json:"phone"`
+}
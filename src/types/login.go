@@ -4,4 +4,5 @@ package types
 type Login struct {
 	UserName string `json:"userName"`
 	Password string `json:"password"`
+	Org      string `json:"org"` //Slug of the organization to login to. Needed when usernames are unique per organization
 }
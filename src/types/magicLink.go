@@ -0,0 +1,17 @@
+package types
+
+import "time"
+
+//MagicLink - single use link to login without a password
+type MagicLink struct {
+	ID        string    `sql:"id"` //Hash of the token sent in the link
+	AccountID string    `sql:"accountId"`
+	Email     string    `sql:"email"`
+	Created   time.Time `sql:"created"`
+}
+
+//MagicLinkRequest - struct for requesting and using a magic link
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+	Token string `json:"token"`
+}
@@ -0,0 +1,235 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+//OAuthClient - registered oauth/openid connect client
+type OAuthClient struct {
+	ID           string    `sql:"id" json:"id"`
+	Secret       string    `sql:"secret" json:"-"`
+	Name         string    `sql:"name" json:"name"`
+	RedirectURIs string    `sql:"redirectUris" json:"redirectUris"`
+	LogoutURIs   string    `sql:"logoutUris" json:"logoutUris"`
+	GrantTypes   string    `sql:"grantTypes" json:"grantTypes"`
+	Scopes       string    `sql:"scopes" json:"scopes"`
+	Revoked      bool      `sql:"revoked" json:"revoked"`
+	Created      time.Time `sql:"created" json:"created"`
+}
+
+//Scopes machine clients can be granted and the admin operations they allow. Roles give the same permissions
+const (
+	ScopeAccountsRead   = "accounts:read"   //getAllAccounts, getAccounts
+	ScopeAccountsWrite  = "accounts:write"  //register, updateAccountSettings
+	ScopeAccountsDelete = "accounts:delete" //delete
+	ScopeSessionsRevoke = "sessions:revoke" //revoke session tokens through /oauth/revoke
+	ScopeRolesRead      = "roles:read"      //roles, permissions
+	ScopeRolesWrite     = "roles:write"     //create, update, delete and assign roles
+	ScopeClientsWrite   = "clients:write"   //manage oauth clients
+	ScopeOrgsWrite      = "orgs:write"      //create organizations
+)
+
+//AdminScopes - all scopes a machine client can be granted. Also the permissions roles can give
+var AdminScopes = []string{ScopeAccountsRead, ScopeAccountsWrite, ScopeAccountsDelete, ScopeSessionsRevoke, ScopeRolesRead, ScopeRolesWrite, ScopeClientsWrite, ScopeOrgsWrite}
+
+//IsPublic - public clients have no secret and must use PKCE
+func (client OAuthClient) IsPublic() bool {
+	return client.Secret == ""
+}
+
+//HasRedirectURI - checks if the uri is registered to the client
+func (client OAuthClient) HasRedirectURI(uri string) bool {
+	for _, u := range strings.Fields(client.RedirectURIs) {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+//HasGrantType - checks if the client is allowed to use the grant type
+func (client OAuthClient) HasGrantType(grantType string) bool {
+	for _, g := range strings.Fields(client.GrantTypes) {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+//HasScope - checks if the client is allowed to request the scope
+func (client OAuthClient) HasScope(scope string) bool {
+	for _, s := range strings.Fields(client.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+//HasLogoutURI - checks if the post logout uri is registered to the client
+func (client OAuthClient) HasLogoutURI(uri string) bool {
+	for _, u := range strings.Fields(client.LogoutURIs) {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+//OAuthCode - authorization code issued to a client
+type OAuthCode struct {
+	ID            string    `sql:"id"`
+	ClientID      string    `sql:"clientId"`
+	AccountID     string    `sql:"accountId"`
+	RedirectURI   string    `sql:"redirectUri"`
+	Scope         string    `sql:"scope"`
+	Nonce         string    `sql:"nonce"`
+	CodeChallenge string    `sql:"codeChallenge"`
+	Created       time.Time `sql:"created"`
+}
+
+//OAuthToken - access or refresh token issued to a client. ID is the hash of the token
+type OAuthToken struct {
+	ID        string    `sql:"id"`
+	Type      string    `sql:"type"`
+	ClientID  string    `sql:"clientId"`
+	AccountID string    `sql:"accountId"`
+	Scope     string    `sql:"scope"`
+	Expires   time.Time `sql:"expires"`
+	Created   time.Time `sql:"created"`
+}
+
+//HasScope - checks if the token was granted the scope
+func (token OAuthToken) HasScope(scope string) bool {
+	for _, s := range strings.Fields(token.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+//AuthorizeRequest - authorization endpoint parameters
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+//TokenRequest - token endpoint parameters
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	RefreshToken string
+	CodeVerifier string
+	Scope        string
+	ClientID     string
+	ClientSecret string
+}
+
+//TokenLookupRequest - introspection and revocation parameters. Every token store is checked so no type hint is needed
+type TokenLookupRequest struct {
+	Token        string
+	DeviceID     string
+	ClientID     string
+	ClientSecret string
+}
+
+//IntrospectionResponse - token introspection response (RFC 7662)
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	UserName  string   `json:"username,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
+	Sub       string   `json:"sub,omitempty"`
+	Iss       string   `json:"iss,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+//EndSessionRequest - rp initiated logout parameters
+type EndSessionRequest struct {
+	IDTokenHint           string
+	PostLogoutRedirectURI string
+	State                 string
+}
+
+//CreateClientRequest - details for registering a new client
+type CreateClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	LogoutURIs   []string `json:"logoutUris"`
+	GrantTypes   []string `json:"grantTypes"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+}
+
+//ClientRequest - id of the client being changed
+type ClientRequest struct {
+	ID string `json:"id"`
+}
+
+//OAuthError - oauth error returned to clients
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (err OAuthError) Error() string {
+	return err.Code + ": " + err.Description
+}
+
+//TokenResponse - token endpoint response
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+//JWK - json web key
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+//JWKS - json web key set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+//OpenIDConfiguration - openid connect discovery document
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	EndSessionEndpoint                string   `json:"end_session_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+//Organization - tenant accounts belong to. Role is the role of the account it was listed for
+type Organization struct {
+	ID      string    `sql:"id" json:"id"`
+	Name    string    `sql:"name" json:"name"`
+	Slug    string    `sql:"slug" json:"slug"`
+	Role    int       `sql:"role" json:"role"`
+	Created time.Time `sql:"created" json:"created"`
+}
+
+//OrgMember - account in an organization with its role there
+type OrgMember struct {
+	OrgID     string    `sql:"orgId" json:"orgId"`
+	AccountID string    `sql:"accountId" json:"accountId"`
+	Role      int       `sql:"role" json:"role"`
+	Created   time.Time `sql:"created" json:"created"`
+}
+
+//OrgRequest - organization to create or switch to
+type OrgRequest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+//OrgMemberRequest - account to add to or remove from the active organization
+type OrgMemberRequest struct {
+	AccountID string `json:"accountId"`
+	Role      int    `json:"role"`
+}
+
+//CheckSlug - verify the slug of an organization is valid
+func (org Organization) CheckSlug() error {
+	if !regexp.MustCompile(`^[a-z0-9-]{3,50}$`).MatchString(org.Slug) {
+		return errors.New("Slug must be 3 to 50 lowercase letters, digits or -: " + org.Slug)
+	}
+	return nil
+}
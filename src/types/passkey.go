@@ -0,0 +1,55 @@
+package types
+
+//PasskeyProvider - provider of passkey identities. Their subject is the credential id
+const PasskeyProvider = "passkey"
+
+//PasskeyEntity - relying party or user of a passkey
+type PasskeyEntity struct {
+	ID          string `json:"id,omitempty"` //The user id is base64url encoded
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+//PasskeyParam - public key algorithm the relying party accepts
+type PasskeyParam struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+//PasskeyDescriptor - credential an authenticator should not register again
+type PasskeyDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"` //base64url
+}
+
+//PasskeySelection - authenticators the relying party accepts
+type PasskeySelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+//PasskeyOptions - publicKey options for navigator.credentials.create or get. Binary values are base64url encoded
+type PasskeyOptions struct {
+	Challenge              string              `json:"challenge"`
+	RP                     *PasskeyEntity      `json:"rp,omitempty"`
+	RPID                   string              `json:"rpId,omitempty"`
+	User                   *PasskeyEntity      `json:"user,omitempty"`
+	PubKeyCredParams       []PasskeyParam      `json:"pubKeyCredParams,omitempty"`
+	ExcludeCredentials     []PasskeyDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection *PasskeySelection   `json:"authenticatorSelection,omitempty"`
+	Attestation            string              `json:"attestation,omitempty"`
+	UserVerification       string              `json:"userVerification,omitempty"`
+	Timeout                int                 `json:"timeout"` //Milliseconds
+}
+
+//PasskeyCredential - credential the browser returned for a passkey registration or login.
+//Binary values are base64url encoded. State is the one returned with the options
+type PasskeyCredential struct {
+	State             string `json:"state"`
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"` //Registration only
+	AuthenticatorData string `json:"authenticatorData"` //Login only
+	Signature         string `json:"signature"`         //Login only
+	UserHandle        string `json:"userHandle"`        //Login only
+}
@@ -0,0 +1,24 @@
+package types
+
+//Rules a permission check can match
+const (
+	RuleSession = "session" //Deny. No valid session or token
+	RuleClient  = "client"  //Allow. Machine client was granted the permission as a scope
+	RuleOwner   = "owner"   //Allow. Resource belongs to the account
+	RuleScope   = "scope"   //Deny. Personal access token was not granted the permission
+	RuleRole    = "role"    //Allow. A role of the account gives the permission. Matched as role:<name>
+	RuleDefault = "default" //Deny. Nothing allowed it
+)
+
+//PermissionCheckRequest - can the session do the permission on the resource
+type PermissionCheckRequest struct {
+	Permission string            `json:"permission"`
+	Resource   map[string]string `json:"resource"` //Optional attributes of the resource. ownerId is checked against the account
+}
+
+//PermissionDecision - result of a permission check with the rule that decided it
+type PermissionDecision struct {
+	Allowed   bool   `json:"allowed"`
+	Rule      string `json:"rule"`
+	AccountID string `json:"accountId"`
+}
@@ -0,0 +1,48 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+//PersonalTokenPrefix - marks bearer tokens that are personal access tokens
+const PersonalTokenPrefix = "gat_"
+
+//ScopeAccount - lets a personal access token use the endpoints an account uses with its own session
+const ScopeAccount = "account"
+
+//PersonalToken - token a user creates to script against the api as themselves
+type PersonalToken struct {
+	ID        string     `sql:"id" json:"id"`
+	AccountID string     `sql:"accountId" json:"accountId"`
+	Name      string     `sql:"name" json:"name"`
+	TokenHash string     `sql:"tokenHash" json:"-"`
+	Scopes    string     `sql:"scopes" json:"scopes"` //Space separated
+	Expires   time.Time  `sql:"expires" json:"expires"`
+	LastUsed  *time.Time `sql:"lastUsed" json:"lastUsed"`
+	Created   time.Time  `sql:"created" json:"created"`
+}
+
+//HasScope - checks if the token was granted the scope
+func (token PersonalToken) HasScope(scope string) bool {
+	for _, s := range strings.Fields(token.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+//PersonalTokenRequest - struct for creating and revoking personal access tokens
+type PersonalTokenRequest struct {
+	ID        string   `json:"id"`
+	AccountID string   `json:"accountId"` //Service account to manage tokens of. Empty for the session account
+	Name      string   `json:"name"`
+	ExpiresIn int      `json:"expiresIn"` //Days
+	Scopes    []string `json:"scopes"`
+}
+
+//IsPersonalToken - checks if a bearer token is a personal access token
+func IsPersonalToken(token string) bool {
+	return strings.HasPrefix(token, PersonalTokenPrefix)
+}
@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+//PhoneVerification - code sent to an account phone to prove the account owns it
+type PhoneVerification struct {
+	ID        string    `sql:"id"`
+	AccountID string    `sql:"accountId"`
+	Phone     string    `sql:"phone"`
+	Code      string    `sql:"code"`
+	Attempts  int       `sql:"attempts"`
+	Created   time.Time `sql:"created"`
+}
+
+//PhoneVerificationRequest - struct for finishing a phone verification
+type PhoneVerificationRequest struct {
+	Code string `json:"code"`
+}
+
+//DeliveryChannelRequest - struct for choosing where device codes are sent
+type DeliveryChannelRequest struct {
+	Channel string `json:"channel"`
+}
@@ -0,0 +1,36 @@
+package types
+
+//Policy effects
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+//PolicyRule - rule of an access policy. It matches when the action is listed and every condition is true
+type PolicyRule struct {
+	Name       string            `json:"name"`
+	Effect     string            `json:"effect"`
+	Actions    []string          `json:"actions"` //"*" matches every action
+	Conditions []PolicyCondition `json:"conditions"`
+}
+
+//PolicyCondition - compares two values. Strings starting with actor., target., new. or action are attributes of the request
+type PolicyCondition struct {
+	Left  interface{} `json:"left"`
+	Op    string      `json:"op"` //eq, ne, gt, gte, lt, lte, contains
+	Right interface{} `json:"right"`
+}
+
+//PolicyRequest - attributes of an operation being checked
+type PolicyRequest struct {
+	Action string
+	Actor  map[string]interface{}
+	Target map[string]interface{}
+	New    map[string]interface{} //Values the target is being changed to
+}
+
+//PolicyDecision - result of a policy check with the rule that decided it
+type PolicyDecision struct {
+	Allowed bool
+	Rule    string
+}
@@ -1,13 +1,86 @@
 package types
 
-//GetRoles - returns roles based on the permission level given
-func GetRoles(role int) []string {
-	switch role {
-	case 999:
-		return []string{"ADMIN", "DEFAULT"}
-	case 0:
-		return []string{"DEFAULT"}
+import (
+	"strings"
+	"time"
+)
+
+//Built in roles. Accounts were migrated onto these from the old role levels
+const (
+	RoleAdmin   = "ADMIN"
+	RoleDefault = "DEFAULT"
+)
+
+//Old role levels. Login providers and the role column still use them.
+//They are also the ranks of the built in roles and the highest rank a role can have
+const (
+	LevelDefault = 0
+	LevelAdmin   = 999
+)
+
+//Role - named set of permissions given to accounts
+type Role struct {
+	ID          string    `sql:"id" json:"id"`
+	Name        string    `sql:"name" json:"name"`
+	Description string    `sql:"description" json:"description"`
+	Rank        int       `sql:"rank" json:"rank"` //Accounts can only manage accounts whose roles rank lower
+	Permissions []string  `json:"permissions"`
+	Created     time.Time `sql:"created" json:"created"`
+}
+
+//Permission - permission a role can give. Also used as a token scope
+type Permission struct {
+	ID          string `sql:"id" json:"id"`
+	Description string `sql:"description" json:"description"`
+}
+
+//RoleRequest - id of a role
+type RoleRequest struct {
+	ID string `json:"id"`
+}
+
+//AccountRolesRequest - roles to give an account
+type AccountRolesRequest struct {
+	AccountID string   `json:"accountId"`
+	Roles     []string `json:"roles"` //Role names
+}
+
+//IsBuiltIn - built in roles cannot be deleted or renamed
+func (role Role) IsBuiltIn() bool {
+	return role.Name == RoleAdmin || role.Name == RoleDefault
+}
+
+//LegacyRoles - returns the built in roles of an old role level
+func LegacyRoles(level int) []string {
+	switch level {
+	case LevelAdmin:
+		return []string{RoleAdmin, RoleDefault}
+	case LevelDefault:
+		return []string{RoleDefault}
 	default:
-		return []string{"NONE"}
+		return []string{}
+	}
+}
+
+//LegacyLevel - returns the old role level of the roles given
+func LegacyLevel(roles []string) int {
+	for _, role := range roles {
+		if role == RoleAdmin {
+			return LevelAdmin
+		}
+	}
+	return LevelDefault
+}
+
+//GetGroupRole - returns the highest role mapped to the groups given. Returns the default role if none are mapped
+func GetGroupRole(groups []string, groupRoles map[string]int, defaultRole int) int {
+	role, found := defaultRole, false
+	for group, groupRole := range groupRoles {
+		for _, g := range groups {
+			if strings.EqualFold(g, group) && (!found || groupRole > role) {
+				role, found = groupRole, true
+			}
+		}
 	}
+	return role
 }
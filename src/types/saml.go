@@ -0,0 +1,23 @@
+package types
+
+//SAMLRequest - authentication request to send the user to the identity provider with
+type SAMLRequest struct {
+	URL         string
+	SAMLRequest string
+	RelayState  string
+	Post        bool //Send with an auto submitted form instead of a redirect to URL
+}
+
+//SAMLAssertion - verified login from a saml identity provider
+type SAMLAssertion struct {
+	Subject    string
+	Attributes map[string][]string
+}
+
+//Attribute - returns the first value of an attribute
+func (assertion SAMLAssertion) Attribute(name string) string {
+	if values := assertion.Attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -4,4 +4,6 @@ package types
 type Session struct {
 	Token  string
 	Device string
+	Bearer string
+	IP     string
 }
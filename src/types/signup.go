@@ -0,0 +1,21 @@
+package types
+
+import "time"
+
+//EmailVerification - single use link to verify the email of a new account
+type EmailVerification struct {
+	ID        string    `sql:"id"` //Hash of the token sent in the link
+	AccountID string    `sql:"accountId"`
+	Email     string    `sql:"email"`
+	Created   time.Time `sql:"created"`
+}
+
+//SignupRequest - struct for signing up, verifying an email and resending the verification
+type SignupRequest struct {
+	UserName string `json:"userName"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Token    string `json:"token"`
+}
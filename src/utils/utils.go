@@ -1,6 +1,10 @@
 package utils
 
 import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
 	"math/rand"
 	"time"
 
@@ -41,6 +45,30 @@ func RandomCode() string {
 	return string(b)
 }
 
+//SecureString - returns a random 64 character string from a cryptographic source. Use it for secrets and tokens
+func SecureString() (string, error) {
+	return secureChars("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789", 64)
+}
+
+//SecureCode - returns a random 6 digit code from a cryptographic source. Use it for codes sent to users
+func SecureCode() (string, error) {
+	return secureChars("0123456789", 6)
+}
+
+//secureChars - returns a string of characters picked uniformly from the charset given
+func secureChars(charset string, length int) (string, error) {
+	size := big.NewInt(int64(len(charset)))
+	b := make([]byte, length)
+	for i := range b {
+		n, err := crand.Int(crand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[n.Int64()]
+	}
+	return string(b), nil
+}
+
 //HashPassword - returns a has of the given password.
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 10)
@@ -53,6 +81,12 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
+//HashToken - returns a sha256 hash of a token. Used for tokens that are looked up by value.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 //Schedule - set an interval timer
 func Schedule(what func(), delay time.Duration) chan bool {
 	stop := make(chan bool)
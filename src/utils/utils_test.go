@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSecureString(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		s, err := SecureString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !regexp.MustCompile(`^[A-Za-z0-9]{64}$`).MatchString(s) {
+			t.Fatalf("invalid string: %q", s)
+		}
+		if seen[s] {
+			t.Fatalf("repeated string: %q", s)
+		}
+		seen[s] = true
+	}
+}
+
+func TestSecureCode(t *testing.T) {
+	counts := map[rune]int{}
+	for i := 0; i < 1000; i++ {
+		code, err := SecureCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !regexp.MustCompile(`^[0-9]{6}$`).MatchString(code) {
+			t.Fatalf("invalid code: %q", code)
+		}
+		for _, c := range code {
+			counts[c]++
+		}
+	}
+	//Every digit should show up about 600 times
+	for c, n := range counts {
+		if n < 400 || n > 800 {
+			t.Errorf("digit %c picked %d times", c, n)
+		}
+	}
+	if len(counts) != 10 {
+		t.Errorf("%d digits picked; want 10", len(counts))
+	}
+}
@@ -0,0 +1,144 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+//maxDepth - how deeply cbor arrays and maps can nest. Authenticator data never comes close
+const maxDepth = 16
+
+//decodeCBOR - decodes the first cbor item of data. Returns the item and the bytes after it.
+//Integers are int64, byte strings []byte, text strings string, arrays []interface{} and maps map[interface{}]interface{}.
+//Only the definite length encodings webauthn uses are supported
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeItem(data, 0)
+}
+
+//decodeItem - decodes one cbor item nested depth levels deep
+func decodeItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > maxDepth {
+		return nil, nil, errors.New("cbor: nested too deeply")
+	}
+	if len(data) == 0 {
+		return nil, nil, errors.New("cbor: unexpected end of data")
+	}
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+
+	//Simple values
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data[1:], nil
+		case 21:
+			return true, data[1:], nil
+		case 22, 23:
+			return nil, data[1:], nil
+		}
+		return nil, nil, errors.New("cbor: unsupported simple value")
+	}
+
+	length, rest, err := decodeLength(info, data[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if length > 1<<63-1 {
+			return nil, nil, errors.New("cbor: integer overflows")
+		}
+		return int64(length), rest, nil
+	case 1:
+		if length > 1<<63-1 {
+			return nil, nil, errors.New("cbor: integer overflows")
+		}
+		return -1 - int64(length), rest, nil
+	case 2, 3:
+		if uint64(len(rest)) < length {
+			return nil, nil, errors.New("cbor: string longer than data")
+		}
+		value := append([]byte{}, rest[:length]...)
+		if major == 3 {
+			return string(value), rest[length:], nil
+		}
+		return value, rest[length:], nil
+	case 4:
+		//Every item takes at least a byte
+		if uint64(len(rest)) < length {
+			return nil, nil, errors.New("cbor: array longer than data")
+		}
+		items := make([]interface{}, 0, length)
+		for i := uint64(0); i < length; i++ {
+			var item interface{}
+			item, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case 5:
+		if uint64(len(rest))/2 < length {
+			return nil, nil, errors.New("cbor: map longer than data")
+		}
+		items := map[interface{}]interface{}{}
+		for i := uint64(0); i < length; i++ {
+			var key, value interface{}
+			key, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errors.New("cbor: unsupported map key")
+			}
+			value, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, ok := items[key]; ok {
+				return nil, nil, errors.New("cbor: duplicate map key")
+			}
+			items[key] = value
+		}
+		return items, rest, nil
+	}
+	return nil, nil, errors.New("cbor: unsupported major type")
+}
+
+//decodeLength - decodes the argument of an item header
+func decodeLength(info byte, data []byte) (uint64, []byte, error) {
+	size := 0
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, nil, errors.New("cbor: indefinite lengths are not supported")
+	}
+	if len(data) < size {
+		return 0, nil, errors.New("cbor: unexpected end of data")
+	}
+	var length uint64
+	switch size {
+	case 1:
+		length = uint64(data[0])
+	case 2:
+		length = uint64(binary.BigEndian.Uint16(data))
+	case 4:
+		length = uint64(binary.BigEndian.Uint32(data))
+	case 8:
+		length = binary.BigEndian.Uint64(data)
+	}
+	return length, data[size:], nil
+}
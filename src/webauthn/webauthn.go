@@ -0,0 +1,304 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/url"
+	"strings"
+	"types"
+)
+
+//COSE algorithms of the supported public keys
+const (
+	algES256 = -7
+	algRS256 = -257
+)
+
+//Authenticator data flags
+const (
+	flagUserPresent  = 0x01
+	flagAttestedData = 0x40
+)
+
+//How long the browser waits for the user in milliseconds
+const ceremonyTimeout = 300000
+
+//RelyingParty - site passkeys are registered with and checked for
+type RelyingParty struct {
+	ID     string //Domain the passkeys are bound to
+	Name   string
+	Origin string //Origin the browser must report
+}
+
+//Credential - public key credential registered by an authenticator
+type Credential struct {
+	ID        string //base64url
+	PublicKey []byte //COSE key
+	SignCount uint32
+}
+
+//clientData - data the browser signs with the authenticator data
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+//authenticatorData - parsed authenticator data
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte //Registration only
+	PublicKey    []byte //Registration only
+}
+
+//Init - setup the relying party. Nil if passkeys are not configured. The origin defaults to the host
+func (rp RelyingParty) Init(config types.PasskeyConfig, host string) *RelyingParty {
+	if config.RPID == "" {
+		return nil
+	}
+	rp.ID = config.RPID
+	rp.Name = config.RPName
+	rp.Origin = config.Origin
+	if rp.Origin == "" {
+		if parsed, err := url.Parse(host); err == nil {
+			rp.Origin = parsed.Scheme + "://" + parsed.Host
+		}
+	}
+	return &rp
+}
+
+//CreationOptions - returns the options to register a passkey for an account.
+//Passkeys the account already has are excluded
+func (rp RelyingParty) CreationOptions(challenge string, account *types.Account, exclude []string) *types.PasskeyOptions {
+	options := types.PasskeyOptions{
+		Challenge: base64.RawURLEncoding.EncodeToString([]byte(challenge)),
+		RP:        &types.PasskeyEntity{ID: rp.ID, Name: rp.Name},
+		User:      &types.PasskeyEntity{ID: base64.RawURLEncoding.EncodeToString([]byte(account.ID)), Name: account.UserName, DisplayName: account.Name},
+		PubKeyCredParams: []types.PasskeyParam{
+			{Type: "public-key", Alg: algES256},
+			{Type: "public-key", Alg: algRS256},
+		},
+		AuthenticatorSelection: &types.PasskeySelection{ResidentKey: "required", UserVerification: "preferred"},
+		Attestation:            "none",
+		Timeout:                ceremonyTimeout,
+	}
+	for _, id := range exclude {
+		options.ExcludeCredentials = append(options.ExcludeCredentials, types.PasskeyDescriptor{Type: "public-key", ID: id})
+	}
+	return &options
+}
+
+//RequestOptions - returns the options to login with any passkey of the site
+func (rp RelyingParty) RequestOptions(challenge string) *types.PasskeyOptions {
+	return &types.PasskeyOptions{
+		Challenge:        base64.RawURLEncoding.EncodeToString([]byte(challenge)),
+		RPID:             rp.ID,
+		UserVerification: "preferred",
+		Timeout:          ceremonyTimeout,
+	}
+}
+
+//VerifyRegistration - checks a new credential was created for the challenge on this site.
+//Attestation statements are not checked, the options ask for none
+func (rp RelyingParty) VerifyRegistration(challenge string, credential *types.PasskeyCredential) (*Credential, error) {
+	if _, err := rp.verifyClientData(credential.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	attestation, err := decode(credential.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	object, _, err := decodeCBOR(attestation)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := object.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("Invalid attestation object")
+	}
+	authData, ok := fields["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("Attestation object has no authenticator data")
+	}
+
+	data, err := rp.verifyAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if data.Flags&flagAttestedData == 0 {
+		return nil, errors.New("Authenticator data has no credential")
+	}
+	if _, err := parsePublicKey(data.PublicKey); err != nil {
+		return nil, err
+	}
+
+	return &Credential{ID: base64.RawURLEncoding.EncodeToString(data.CredentialID), PublicKey: data.PublicKey, SignCount: data.SignCount}, nil
+}
+
+//VerifyAssertion - checks a login was signed by the credential for the challenge on this site.
+//Returns the new signature counter of the authenticator
+func (rp RelyingParty) VerifyAssertion(challenge string, stored *Credential, credential *types.PasskeyCredential) (uint32, error) {
+	clientDataJSON, err := rp.verifyClientData(credential.ClientDataJSON, "webauthn.get", challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	authData, err := decode(credential.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	data, err := rp.verifyAuthenticatorData(authData)
+	if err != nil {
+		return 0, err
+	}
+
+	signature, err := decode(credential.Signature)
+	if err != nil {
+		return 0, err
+	}
+	key, err := parsePublicKey(stored.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	hash := sha256.Sum256(clientDataJSON)
+	if err := verifySignature(key, append(append([]byte{}, authData...), hash[:]...), signature); err != nil {
+		return 0, err
+	}
+
+	//A counter that does not go up means the authenticator was cloned. Authenticators without a counter always send 0
+	if (data.SignCount != 0 || stored.SignCount != 0) && data.SignCount <= stored.SignCount {
+		return 0, errors.New("Passkey signature counter went backwards")
+	}
+	return data.SignCount, nil
+}
+
+//verifyClientData - checks the browser made the request for the challenge on this site. Returns the raw client data
+func (rp RelyingParty) verifyClientData(encoded string, ceremony string, challenge string) ([]byte, error) {
+	raw, err := decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	if data.Type != ceremony {
+		return nil, errors.New("Invalid client data type: " + data.Type)
+	}
+	expected := base64.RawURLEncoding.EncodeToString([]byte(challenge))
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(expected)) != 1 {
+		return nil, errors.New("Invalid passkey challenge")
+	}
+	if data.Origin != rp.Origin {
+		return nil, errors.New("Invalid passkey origin: " + data.Origin)
+	}
+	return raw, nil
+}
+
+//verifyAuthenticatorData - parses authenticator data and checks it is for this site with the user present
+func (rp RelyingParty) verifyAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, errors.New("Authenticator data is too short")
+	}
+	data := authenticatorData{RPIDHash: raw[:32], Flags: raw[32], SignCount: binary.BigEndian.Uint32(raw[33:37])}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(data.RPIDHash, rpIDHash[:]) {
+		return nil, errors.New("Passkey was created for another site")
+	}
+	if data.Flags&flagUserPresent == 0 {
+		return nil, errors.New("User was not present")
+	}
+
+	if data.Flags&flagAttestedData != 0 {
+		//AAGUID, credential id length, credential id then the COSE key
+		rest := raw[37:]
+		if len(rest) < 18 {
+			return nil, errors.New("Attested credential data is too short")
+		}
+		length := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if length == 0 || len(rest) < length {
+			return nil, errors.New("Invalid credential id")
+		}
+		data.CredentialID = append([]byte{}, rest[:length]...)
+		rest = rest[length:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, err
+		}
+		data.PublicKey = append([]byte{}, rest[:len(rest)-len(after)]...)
+	}
+	return &data, nil
+}
+
+//parsePublicKey - returns the ES256 or RS256 public key of a COSE key
+func parsePublicKey(raw []byte) (crypto.PublicKey, error) {
+	decoded, rest, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok || len(rest) != 0 {
+		return nil, errors.New("Invalid passkey public key")
+	}
+
+	switch key[int64(3)] {
+	case int64(algES256):
+		x, xOK := key[int64(-2)].([]byte)
+		y, yOK := key[int64(-3)].([]byte)
+		if key[int64(1)] != int64(2) || key[int64(-1)] != int64(1) || !xOK || !yOK || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("Invalid ES256 passkey public key")
+		}
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !public.Curve.IsOnCurve(public.X, public.Y) {
+			return nil, errors.New("Passkey public key is not on the curve")
+		}
+		return public, nil
+	case int64(algRS256):
+		n, nOK := key[int64(-1)].([]byte)
+		e, eOK := key[int64(-2)].([]byte)
+		if key[int64(1)] != int64(3) || !nOK || !eOK || len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("Invalid RS256 passkey public key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	}
+	return nil, errors.New("Unsupported passkey algorithm")
+}
+
+//verifySignature - checks the signature of a message with an ES256 or RS256 key
+func verifySignature(key crypto.PublicKey, message []byte, signature []byte) error {
+	hash := sha256.Sum256(message)
+	switch public := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(public, hash[:], signature) {
+			return errors.New("Invalid passkey signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(public, crypto.SHA256, hash[:], signature)
+	}
+	return errors.New("Unsupported passkey key type")
+}
+
+//decode - decodes base64url with or without padding
+func decode(value string) ([]byte, error) {
+	if value == "" {
+		return nil, errors.New("Missing passkey value")
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,284 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"testing"
+	"types"
+)
+
+//encodeCBOR - encodes the values the tests need: ints, byte and text strings and maps
+func encodeCBOR(value interface{}) []byte {
+	header := func(major byte, length uint64) []byte {
+		switch {
+		case length < 24:
+			return []byte{major<<5 | byte(length)}
+		case length < 1<<8:
+			return []byte{major<<5 | 24, byte(length)}
+		default:
+			out := []byte{major<<5 | 25, 0, 0}
+			binary.BigEndian.PutUint16(out[1:], uint16(length))
+			return out
+		}
+	}
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return header(1, uint64(-1-v))
+		}
+		return header(0, uint64(v))
+	case []byte:
+		return append(header(2, uint64(len(v))), v...)
+	case string:
+		return append(header(3, uint64(len(v))), v...)
+	case map[interface{}]interface{}:
+		keys := []interface{}{}
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return string(encodeCBOR(keys[i])) < string(encodeCBOR(keys[j])) })
+		out := header(5, uint64(len(v)))
+		for _, key := range keys {
+			out = append(out, encodeCBOR(key)...)
+			out = append(out, encodeCBOR(v[key])...)
+		}
+		return out
+	}
+	panic("unsupported cbor value")
+}
+
+//authenticator - passkey authenticator running in the test
+type authenticator struct {
+	key       crypto.Signer
+	id        []byte
+	signCount uint32
+	rpID      string
+	origin    string
+}
+
+//newAuthenticator - returns an authenticator with a new ES256 or RS256 key
+func newAuthenticator(t *testing.T, alg int) *authenticator {
+	var key crypto.Signer
+	var err error
+	if alg == algRS256 {
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	} else {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &authenticator{key: key, id: []byte("credential-1"), rpID: "example.com", origin: "https://app.example.com"}
+}
+
+//coseKey - returns the COSE encoding of the public key
+func (a *authenticator) coseKey() []byte {
+	switch public := a.key.Public().(type) {
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		public.X.FillBytes(x)
+		public.Y.FillBytes(y)
+		return encodeCBOR(map[interface{}]interface{}{1: 2, 3: algES256, -1: 1, -2: x, -3: y})
+	case *rsa.PublicKey:
+		return encodeCBOR(map[interface{}]interface{}{1: 3, 3: algRS256, -1: public.N.Bytes(), -2: []byte{1, 0, 1}})
+	}
+	return nil
+}
+
+//authData - returns authenticator data for the relying party. Registrations include the credential
+func (a *authenticator) authData(flags byte, attested bool) []byte {
+	hash := sha256.Sum256([]byte(a.rpID))
+	data := append(hash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[33:], a.signCount)
+	if attested {
+		data[32] |= flagAttestedData
+		data = append(data, make([]byte, 16)...)
+		data = append(data, byte(len(a.id)>>8), byte(len(a.id)))
+		data = append(data, a.id...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+//clientData - returns the base64url client data of a ceremony
+func (a *authenticator) clientData(ceremony string, challenge string) string {
+	data, _ := json.Marshal(clientData{Type: ceremony, Challenge: base64.RawURLEncoding.EncodeToString([]byte(challenge)), Origin: a.origin})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+//create - returns the credential of a registration
+func (a *authenticator) create(challenge string) *types.PasskeyCredential {
+	attestation := encodeCBOR(map[interface{}]interface{}{"fmt": "none", "attStmt": map[interface{}]interface{}{}, "authData": a.authData(flagUserPresent, true)})
+	return &types.PasskeyCredential{
+		ID:                base64.RawURLEncoding.EncodeToString(a.id),
+		ClientDataJSON:    a.clientData("webauthn.create", challenge),
+		AttestationObject: base64.RawURLEncoding.EncodeToString(attestation),
+	}
+}
+
+//get - returns the signed credential of a login
+func (a *authenticator) get(t *testing.T, challenge string) *types.PasskeyCredential {
+	a.signCount++
+	authData := a.authData(flagUserPresent, false)
+	clientDataJSON := a.clientData("webauthn.get", challenge)
+	raw, _ := base64.RawURLEncoding.DecodeString(clientDataJSON)
+	clientHash := sha256.Sum256(raw)
+	hash := sha256.Sum256(append(append([]byte{}, authData...), clientHash[:]...))
+	signature, err := a.key.Sign(rand.Reader, hash[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.PasskeyCredential{
+		ID:                base64.RawURLEncoding.EncodeToString(a.id),
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: base64.RawURLEncoding.EncodeToString(authData),
+		Signature:         base64.RawURLEncoding.EncodeToString(signature),
+	}
+}
+
+var rp = RelyingParty{ID: "example.com", Name: "Example", Origin: "https://app.example.com"}
+
+func TestRegisterAndLogin(t *testing.T) {
+	for _, alg := range []int{algES256, algRS256} {
+		a := newAuthenticator(t, alg)
+
+		credential, err := rp.VerifyRegistration("challenge-1", a.create("challenge-1"))
+		if err != nil {
+			t.Fatalf("alg %d: %v", alg, err)
+		}
+		if credential.ID != base64.RawURLEncoding.EncodeToString(a.id) || len(credential.PublicKey) == 0 {
+			t.Fatalf("alg %d: unexpected credential %+v", alg, credential)
+		}
+
+		signCount, err := rp.VerifyAssertion("challenge-2", credential, a.get(t, "challenge-2"))
+		if err != nil {
+			t.Fatalf("alg %d: %v", alg, err)
+		}
+		if signCount != 1 {
+			t.Errorf("alg %d: sign count %d; want 1", alg, signCount)
+		}
+	}
+}
+
+func TestRegistrationRejected(t *testing.T) {
+	a := newAuthenticator(t, algES256)
+	tests := []struct {
+		name   string
+		change func(a *authenticator) *types.PasskeyCredential
+	}{
+		{"other challenge", func(a *authenticator) *types.PasskeyCredential { return a.create("other") }},
+		{"other origin", func(a *authenticator) *types.PasskeyCredential {
+			a.origin = "https://evil.example.com"
+			return a.create("challenge")
+		}},
+		{"other site", func(a *authenticator) *types.PasskeyCredential {
+			a.rpID = "evil.com"
+			return a.create("challenge")
+		}},
+		{"login data", func(a *authenticator) *types.PasskeyCredential {
+			credential := a.create("challenge")
+			credential.ClientDataJSON = a.clientData("webauthn.get", "challenge")
+			return credential
+		}},
+		{"no credential", func(a *authenticator) *types.PasskeyCredential {
+			credential := a.create("challenge")
+			attestation := encodeCBOR(map[interface{}]interface{}{"fmt": "none", "attStmt": map[interface{}]interface{}{}, "authData": a.authData(flagUserPresent, false)})
+			credential.AttestationObject = base64.RawURLEncoding.EncodeToString(attestation)
+			return credential
+		}},
+		{"user not present", func(a *authenticator) *types.PasskeyCredential {
+			credential := a.create("challenge")
+			attestation := encodeCBOR(map[interface{}]interface{}{"fmt": "none", "attStmt": map[interface{}]interface{}{}, "authData": a.authData(0, true)})
+			credential.AttestationObject = base64.RawURLEncoding.EncodeToString(attestation)
+			return credential
+		}},
+		{"truncated", func(a *authenticator) *types.PasskeyCredential {
+			credential := a.create("challenge")
+			credential.AttestationObject = credential.AttestationObject[:40]
+			return credential
+		}},
+	}
+	for _, test := range tests {
+		changed := *a
+		if _, err := rp.VerifyRegistration("challenge", test.change(&changed)); err == nil {
+			t.Errorf("%s: registration accepted", test.name)
+		}
+	}
+}
+
+func TestLoginRejected(t *testing.T) {
+	a := newAuthenticator(t, algES256)
+	credential, err := rp.VerifyRegistration("challenge", a.create("challenge"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := newAuthenticator(t, algES256)
+
+	tests := []struct {
+		name   string
+		signed *types.PasskeyCredential
+		stored Credential
+	}{
+		{"other challenge", a.get(t, "other"), *credential},
+		{"other key", other.get(t, "challenge"), *credential},
+		{"replayed counter", a.get(t, "challenge"), Credential{PublicKey: credential.PublicKey, SignCount: 100}},
+		{"registration data", func() *types.PasskeyCredential {
+			signed := a.get(t, "challenge")
+			signed.ClientDataJSON = a.clientData("webauthn.create", "challenge")
+			return signed
+		}(), *credential},
+		{"changed data", func() *types.PasskeyCredential {
+			signed := a.get(t, "challenge")
+			a.signCount += 10
+			signed.AuthenticatorData = base64.RawURLEncoding.EncodeToString(a.authData(flagUserPresent, false))
+			return signed
+		}(), *credential},
+	}
+	for _, test := range tests {
+		if _, err := rp.VerifyAssertion("challenge", &test.stored, test.signed); err == nil {
+			t.Errorf("%s: login accepted", test.name)
+		}
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	value, rest, err := decodeCBOR([]byte{0xa2, 0x01, 0x02, 0x20, 0x43, 'a', 'b', 'c', 0xff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := value.(map[interface{}]interface{})
+	if items[int64(1)] != int64(2) || string(items[int64(-1)].([]byte)) != "abc" || len(rest) != 1 {
+		t.Errorf("unexpected value %v, rest %v", value, rest)
+	}
+
+	for _, data := range [][]byte{
+		{},
+		{0x43, 'a'},                    //String longer than data
+		{0x5f, 0x41, 'a', 0xff},        //Indefinite length
+		{0xa1, 0x01},                   //Map missing a value
+		{0xa2, 0x01, 0x02, 0x01, 0x03}, //Duplicate key
+		{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, //Huge array
+	} {
+		if _, _, err := decodeCBOR(data); err == nil {
+			t.Errorf("%x: decoded", data)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	if (RelyingParty{}).Init(types.PasskeyConfig{}, "https://app.example.com") != nil {
+		t.Error("passkeys setup without a relying party id")
+	}
+	party := RelyingParty{}.Init(types.PasskeyConfig{RPID: "example.com"}, "https://app.example.com/path")
+	if party == nil || party.Origin != "https://app.example.com" {
+		t.Errorf("unexpected relying party %+v", party)
+	}
+}